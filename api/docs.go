@@ -16,6 +16,493 @@ const docTemplate = `{
     "host": "{{.Host}}",
     "basePath": "{{.BasePath}}",
     "paths": {
+        "/collections": {
+            "get": {
+                "description": "This method returns all the Collections, the oldest first.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Collections"
+                ],
+                "summary": "Get the list of Gist Collections.",
+                "responses": {
+                    "200": {
+                        "description": "The list of Collections has been successfully retrieved.",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/models.CollectionInfo"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "The service has encountered unexpected error that it was not able to handle.",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/models.Error"
+                            }
+                        }
+                    }
+                }
+            },
+            "post": {
+                "description": "This method is called to create a new empty Collection.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Collections"
+                ],
+                "summary": "Create a new Gist Collection.",
+                "parameters": [
+                    {
+                        "description": "Collection definition",
+                        "name": "collection",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.Collection"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Collection has been created.",
+                        "schema": {
+                            "$ref": "#/definitions/models.CollectionInfo"
+                        }
+                    },
+                    "400": {
+                        "description": "Failed to parse JSON request content.",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/models.Error"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "The service has encountered unexpected error that it was not able to handle.",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/models.Error"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/collections/{id}": {
+            "get": {
+                "description": "This method returns the Collection with the ids of the member Gists.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Collections"
+                ],
+                "summary": "Get the Gist Collection.",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Collection id",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Collection has been successfully retrieved.",
+                        "schema": {
+                            "$ref": "#/definitions/models.CollectionInfo"
+                        }
+                    },
+                    "404": {
+                        "description": "The specified Collection does not exist.",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/models.Error"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "The service has encountered unexpected error that it was not able to handle.",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/models.Error"
+                            }
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "description": "This method is called to delete the Collection.\nThe member Gists are not deleted.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Collections"
+                ],
+                "summary": "Delete the Gist Collection.",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Collection id",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "Collection has been deleted."
+                    },
+                    "404": {
+                        "description": "The specified Collection does not exist.",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/models.Error"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "The service has encountered unexpected error that it was not able to handle.",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/models.Error"
+                            }
+                        }
+                    }
+                }
+            },
+            "patch": {
+                "description": "This method is called to change the name of the Collection.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Collections"
+                ],
+                "summary": "Rename the Gist Collection.",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Collection id",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "New Collection name",
+                        "name": "collection",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.CollectionRename"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Collection has been renamed.",
+                        "schema": {
+                            "$ref": "#/definitions/models.CollectionInfo"
+                        }
+                    },
+                    "400": {
+                        "description": "Failed to parse JSON request content.",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/models.Error"
+                            }
+                        }
+                    },
+                    "404": {
+                        "description": "The specified Collection does not exist.",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/models.Error"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "The service has encountered unexpected error that it was not able to handle.",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/models.Error"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/collections/{id}/gists": {
+            "get": {
+                "description": "This method returns the member Gists in the collection order.\nThe expired and deleted Gists are not returned.\nThe Gists are returned by pages of up to 'limit' Gists, starting after the 'offset' Gists.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Collections"
+                ],
+                "summary": "Get the Gists of the Collection.",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Collection id",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "maximum": 1000,
+                        "minimum": 1,
+                        "type": "integer",
+                        "default": 100,
+                        "description": "Maximum number of Gists",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "minimum": 0,
+                        "type": "integer",
+                        "default": 0,
+                        "description": "Number of Gists to skip",
+                        "name": "offset",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "The list of Gists has been successfully retrieved.",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/models.GistInfo"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Failed to parse query parameters.",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/models.Error"
+                            }
+                        }
+                    },
+                    "404": {
+                        "description": "The specified Collection does not exist.",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/models.Error"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "The service has encountered unexpected error that it was not able to handle.",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/models.Error"
+                            }
+                        }
+                    }
+                }
+            },
+            "put": {
+                "description": "This method is called to replace the member Gists of the Collection\nwith the specified Gists in the specified order.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Collections"
+                ],
+                "summary": "Reorder the Gists of the Collection.",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Collection id",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Gists in the new order",
+                        "name": "order",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.CollectionOrder"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Collection has been reordered.",
+                        "schema": {
+                            "$ref": "#/definitions/models.CollectionInfo"
+                        }
+                    },
+                    "400": {
+                        "description": "The Collection could not contain the same Gist more than once.",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/models.Error"
+                            }
+                        }
+                    },
+                    "404": {
+                        "description": "The specified Gist does not exist.",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/models.Error"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "The service has encountered unexpected error that it was not able to handle.",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/models.Error"
+                            }
+                        }
+                    }
+                }
+            },
+            "post": {
+                "description": "This method is called to add the Gist to the Collection at the specified position.\nIf the Gist is already a member of the Collection, it is moved to the new position.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Collections"
+                ],
+                "summary": "Add the Gist to the Collection.",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Collection id",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Gist to add",
+                        "name": "gist",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.CollectionGist"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Gist has been added to the Collection.",
+                        "schema": {
+                            "$ref": "#/definitions/models.CollectionInfo"
+                        }
+                    },
+                    "400": {
+                        "description": "Failed to parse JSON request content.",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/models.Error"
+                            }
+                        }
+                    },
+                    "404": {
+                        "description": "The specified Gist does not exist.",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/models.Error"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "The service has encountered unexpected error that it was not able to handle.",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/models.Error"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/collections/{id}/gists/{gistId}": {
+            "delete": {
+                "description": "This method is called to remove the Gist from the Collection.\nThe Gist itself is not deleted.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Collections"
+                ],
+                "summary": "Remove the Gist from the Collection.",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Collection id",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Gist id",
+                        "name": "gistId",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Gist has been removed from the Collection.",
+                        "schema": {
+                            "$ref": "#/definitions/models.CollectionInfo"
+                        }
+                    },
+                    "404": {
+                        "description": "The specified Gist is not a member of the Collection.",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/models.Error"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "The service has encountered unexpected error that it was not able to handle.",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/models.Error"
+                            }
+                        }
+                    }
+                }
+            }
+        },
         "/gists": {
             "get": {
                 "description": "This method returns the list of Gists, that are created using a particular programming language.\nThis is filtered subset of all available Gists.",
@@ -83,7 +570,16 @@ const docTemplate = `{
                         }
                     },
                     "400": {
-                        "description": "Failed to parse JSON request content.",
+                        "description": "The Gist expiration is invalid.",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/models.Error"
+                            }
+                        }
+                    },
+                    "422": {
+                        "description": "The Gist contains credentials or other secrets that must be removed.",
                         "schema": {
                             "type": "array",
                             "items": {
@@ -105,8 +601,10 @@ const docTemplate = `{
         },
         "/gists/{id}": {
             "get": {
+                "description": "This method returns the Gist definition as JSON.\nGists written in markdown could also be requested as a sanitized\nHTML page with a table of contents, using the 'Accept: text/html' header.",
                 "produces": [
-                    "application/json"
+                    "application/json",
+                    "text/html"
                 ],
                 "tags": [
                     "Gists"
@@ -137,6 +635,15 @@ const docTemplate = `{
                             }
                         }
                     },
+                    "406": {
+                        "description": "The requested representation of the resource is not available.",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/models.Error"
+                            }
+                        }
+                    },
                     "500": {
                         "description": "The service has encountered unexpected error that it was not able to handle.",
                         "schema": {
@@ -149,7 +656,7 @@ const docTemplate = `{
                 }
             },
             "put": {
-                "description": "This method is called to update and store an existing Gist definition.\n",
+                "description": "This method is called to update and store an existing Gist definition.\nThe Gist in the trash could not be replaced, until it is restored.",
                 "produces": [
                     "application/json"
                 ],
@@ -183,7 +690,25 @@ const docTemplate = `{
                         }
                     },
                     "400": {
-                        "description": "Failed to parse JSON request content.",
+                        "description": "The Gist expiration is invalid.",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/models.Error"
+                            }
+                        }
+                    },
+                    "409": {
+                        "description": "The specified Gist is in the trash, it should be restored first.",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/models.Error"
+                            }
+                        }
+                    },
+                    "422": {
+                        "description": "The Gist contains credentials or other secrets that must be removed.",
                         "schema": {
                             "type": "array",
                             "items": {
@@ -203,7 +728,7 @@ const docTemplate = `{
                 }
             },
             "delete": {
-                "description": "This method is called to delete an existing Gist definition.",
+                "description": "This method is called to move an existing Gist definition to the trash.\nThe Gist could be restored from the trash, until the retention period ends.",
                 "produces": [
                     "application/json"
                 ],
@@ -222,7 +747,127 @@ const docTemplate = `{
                 ],
                 "responses": {
                     "204": {
-                        "description": "Gist has been deleted.",
+                        "description": "Gist has been deleted."
+                    },
+                    "404": {
+                        "description": "The specified Gist does not exist.",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/models.Error"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "The service has encountered unexpected error that it was not able to handle.",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/models.Error"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/trash": {
+            "get": {
+                "description": "This method returns the deleted Gists, the most recently deleted first.\nThe Gists stay in the trash until they are restored or the retention period ends.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Trash"
+                ],
+                "summary": "Get the list of Gists in the trash.",
+                "responses": {
+                    "200": {
+                        "description": "The list of trashed Gists has been successfully retrieved.",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/models.TrashedGist"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "The service has encountered unexpected error that it was not able to handle.",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/models.Error"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/trash/{id}": {
+            "delete": {
+                "description": "This method is called to purge a previously deleted Gist.\nThe purged Gist could not be restored.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Trash"
+                ],
+                "summary": "Permanently delete the Gist from the trash.",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Gist id",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "Gist has been purged."
+                    },
+                    "404": {
+                        "description": "The specified Gist does not exist.",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/models.Error"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "The service has encountered unexpected error that it was not able to handle.",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/models.Error"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/trash/{id}/restore": {
+            "post": {
+                "description": "This method is called to restore a previously deleted Gist.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Trash"
+                ],
+                "summary": "Restore the Gist from the trash.",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Gist id",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Gist has been restored.",
                         "schema": {
                             "$ref": "#/definitions/models.GistInfo"
                         }
@@ -250,6 +895,124 @@ const docTemplate = `{
         }
     },
     "definitions": {
+        "models.Collection": {
+            "description": "Collection is a definition of a curated group of Gists.",
+            "type": "object",
+            "required": [
+                "name"
+            ],
+            "properties": {
+                "description": {
+                    "description": "Description is a human readable Collection description.",
+                    "type": "string",
+                    "example": "Different ways to generate a unique ID."
+                },
+                "name": {
+                    "description": "Name is a human readable Collection name.",
+                    "type": "string",
+                    "example": "Unique IDs"
+                }
+            }
+        },
+        "models.CollectionGist": {
+            "description": "CollectionGist defines the Gist that is added to the Collection and its position in the collection order.",
+            "type": "object",
+            "required": [
+                "id"
+            ],
+            "properties": {
+                "id": {
+                    "description": "Id is the id of the Gist.",
+                    "type": "string",
+                    "example": "d17043a0-216c-4c56-9127-b0bf5e3a4c16"
+                },
+                "position": {
+                    "description": "Position is the zero-based position of the Gist in the collection.\nThe Gist is appended to the end, if the position is not specified.",
+                    "type": "integer",
+                    "example": 0
+                }
+            }
+        },
+        "models.CollectionInfo": {
+            "description": "CollectionInfo provides the descriptive information about the Collection and the ids of the member Gists in the collection order.",
+            "type": "object",
+            "required": [
+                "createdAt",
+                "gistIds",
+                "id",
+                "name"
+            ],
+            "properties": {
+                "createdAt": {
+                    "description": "CreatedAt defines the date and time when the collection has been created.\nThis field uses RFC 3339 as the standard for the date-time format.",
+                    "type": "string",
+                    "example": "2023-06-07T18:27:25-04:00"
+                },
+                "description": {
+                    "description": "Description is a human readable Collection description.",
+                    "type": "string",
+                    "example": "Different ways to generate a unique ID."
+                },
+                "gistIds": {
+                    "description": "GistIds are the ids of the member Gists in the collection order.",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    },
+                    "example": [
+                        "d17043a0-216c-4c56-9127-b0bf5e3a4c16"
+                    ]
+                },
+                "id": {
+                    "description": "Id is a globally unique Collection ID that identifies this Collection.",
+                    "type": "string",
+                    "example": "6b1c4a47-4c47-4ff6-a1b6-7fcd6f1c3a5e"
+                },
+                "lastUpdated": {
+                    "description": "LastUpdated defines the date and time when the collection has been updated.\nThis field uses RFC 3339 as the standard for the date-time format.",
+                    "type": "string",
+                    "example": "2023-06-11T10:44:17-04:00"
+                },
+                "name": {
+                    "description": "Name is a human readable Collection name.",
+                    "type": "string",
+                    "example": "Unique IDs"
+                }
+            }
+        },
+        "models.CollectionOrder": {
+            "description": "CollectionOrder defines all the member Gists of the Collection in the new order. The Gists that are not listed are removed.",
+            "type": "object",
+            "required": [
+                "gistIds"
+            ],
+            "properties": {
+                "gistIds": {
+                    "description": "GistIds are the ids of the member Gists in the new order.",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    },
+                    "example": [
+                        "d17043a0-216c-4c56-9127-b0bf5e3a4c16"
+                    ]
+                }
+            }
+        },
+        "models.CollectionRename": {
+            "description": "CollectionRename defines the new name of the Collection.",
+            "type": "object",
+            "required": [
+                "name"
+            ],
+            "properties": {
+                "name": {
+                    "description": "Name is a human readable Collection name.",
+                    "type": "string",
+                    "example": "Unique IDs"
+                }
+            }
+        },
         "models.Error": {
             "description": "Error is a single error that has happened during the HTTP API request processing.  Sometimes, we may want to report more than one error for a request. In this case, we should return several errors in a list.",
             "type": "object",
@@ -295,6 +1058,11 @@ const docTemplate = `{
                     "type": "string",
                     "example": "Example of how to generate a unique ID in java script."
                 },
+                "expiresAt": {
+                    "description": "ExpiresAt defines the date and time when the gist expires and is deleted.\nThis field uses RFC 3339 as the standard for the date-time format.",
+                    "type": "string",
+                    "example": "2023-07-01T00:00:00Z"
+                },
                 "language": {
                     "description": "Language is a programming language that is used in the gist.",
                     "type": "string",
@@ -304,6 +1072,11 @@ const docTemplate = `{
                     "description": "Name is a human readable Gist name.",
                     "type": "string",
                     "example": "Generate unique ID"
+                },
+                "ttl": {
+                    "description": "Ttl defines the time to live of the gist, after which the gist expires.\nThis field uses Go duration format, for example \"30m\" or \"72h\".\nOnly one of 'expiresAt' and 'ttl' could be specified.",
+                    "type": "string",
+                    "example": "72h"
                 }
             }
         },
@@ -333,6 +1106,11 @@ const docTemplate = `{
                     "type": "string",
                     "example": "Example of how to generate a unique ID in java script."
                 },
+                "expiresAt": {
+                    "description": "ExpiresAt defines the date and time when the gist expires.\nThis field uses RFC 3339 as the standard for the date-time format.",
+                    "type": "string",
+                    "example": "2023-07-01T00:00:00Z"
+                },
                 "id": {
                     "description": "Id is a globally unique Gist ID that identifies this Gist entry.",
                     "type": "string",
@@ -374,6 +1152,53 @@ const docTemplate = `{
                     "type": "string",
                     "example": "Example of how to generate a unique ID in java script."
                 },
+                "expiresAt": {
+                    "description": "ExpiresAt defines the date and time when the gist expires.\nThis field uses RFC 3339 as the standard for the date-time format.",
+                    "type": "string",
+                    "example": "2023-07-01T00:00:00Z"
+                },
+                "id": {
+                    "description": "Id is a globally unique Gist ID that identifies this Gist entry.",
+                    "type": "string",
+                    "example": "d17043a0-216c-4c56-9127-b0bf5e3a4c16"
+                },
+                "language": {
+                    "description": "Language is a programming language that is used in the gist.",
+                    "type": "string",
+                    "example": "javascript"
+                },
+                "name": {
+                    "description": "Name is a human readable Gist name.",
+                    "type": "string",
+                    "example": "Generate unique ID"
+                }
+            }
+        },
+        "models.TrashedGist": {
+            "description": "TrashedGist provides the descriptive information about the Gist that has been moved to the trash.",
+            "type": "object",
+            "required": [
+                "deletedAt",
+                "id",
+                "language",
+                "name"
+            ],
+            "properties": {
+                "deletedAt": {
+                    "description": "DeletedAt defines the date and time when the gist has been moved to the trash.\nThis field uses RFC 3339 as the standard for the date-time format.",
+                    "type": "string",
+                    "example": "2023-06-24T08:13:59-04:00"
+                },
+                "description": {
+                    "description": "Description is a human readable Gist description.",
+                    "type": "string",
+                    "example": "Example of how to generate a unique ID in java script."
+                },
+                "expiresAt": {
+                    "description": "ExpiresAt defines the date and time when the gist expires.\nThis field uses RFC 3339 as the standard for the date-time format.",
+                    "type": "string",
+                    "example": "2023-07-01T00:00:00Z"
+                },
                 "id": {
                     "description": "Id is a globally unique Gist ID that identifies this Gist entry.",
                     "type": "string",
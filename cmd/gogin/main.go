@@ -2,6 +2,7 @@ package main
 
 import (
 	"log"
+	"os"
 
 	_ "git.lothric.net/examples/go/gogin/api"
 
@@ -20,7 +21,8 @@ func main() {
 		return
 	}
 
+	// The errors are already reported by the commands
 	if err = cmd.Execute(); err != nil {
-		log.Fatal(err)
+		os.Exit(1)
 	}
 }
@@ -60,3 +60,18 @@ Create the name of the service account to use
 {{- default "default" .Values.serviceAccount.name }}
 {{- end }}
 {{- end }}
+
+{{/*
+Scheme of the HTTP probes, HTTPS if the metrics listener uses TLS
+*/}}
+{{- define "gogin.httpProbeScheme" -}}
+{{- if .Values.appConfig.metrics.tls.cert }}HTTPS{{ else }}HTTP{{ end }}
+{{- end }}
+
+{{/*
+Arguments of grpc_health_probe if the status listener uses TLS,
+the certificate is not verified, because it is issued for the service name
+*/}}
+{{- define "gogin.grpcProbeTLS" -}}
+{{- if .Values.appConfig.status.tls.cert }}, "-tls", "-tls-no-verify"{{ end }}
+{{- end }}
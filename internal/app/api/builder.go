@@ -6,14 +6,20 @@ import (
 	"github.com/gin-gonic/gin"
 	swaggerfiles "github.com/swaggo/files"
 	ginSwagger "github.com/swaggo/gin-swagger"
+	"go.opentelemetry.io/otel/trace"
 
 	"git.lothric.net/examples/go/gogin/internal/app/api/middleware"
 	"git.lothric.net/examples/go/gogin/internal/app/api/v1/handlers"
+	"git.lothric.net/examples/go/gogin/internal/app/logic"
 	"git.lothric.net/examples/go/gogin/internal/pkg/logger"
+	"git.lothric.net/examples/go/gogin/internal/pkg/slo"
 
 	v1 "git.lothric.net/examples/go/gogin/internal/app/api/v1"
 )
 
+// AdminRoute is the parent route of the admin API.
+const AdminRoute = "/admin"
+
 // PathHandler defines an API Handler that could attach
 // it's underlying handlers to the parent API group.
 type PathHandler interface {
@@ -28,10 +34,22 @@ type PathHandler interface {
 type ComponentFactory interface {
 
 	// CreateMetricsReporter
-	CreateApiMetricsReporter() (handlers.ApiMetricsReporter, error)
+	CreateApiMetricsReporter() (middleware.ApiMetricsReporter, error)
+
+	// CreateApiTracer
+	CreateApiTracer() (trace.Tracer, error)
 
 	// CreateGistsLogic
 	CreateGistsLogic() (handlers.GistsLogic, error)
+
+	// CreateCollectionsLogic
+	CreateCollectionsLogic() (handlers.CollectionsLogic, error)
+
+	// CreateGistsSweeper
+	CreateGistsSweeper() (*logic.GistsSweeper, error)
+
+	// CreateSloTracker
+	CreateSloTracker() (*slo.Tracker, error)
 }
 
 // apiBuilder
@@ -77,7 +95,32 @@ func (b *apiBuilder) BuildApi(
 	// But if we go with the HTTP header based API versioning (that is recommended
 	// approach for the enterprise-grade software), we would need to replace the 'gin.Default()'
 	// router with something like mentioned above that supports header-based routing.
-	engine := gin.Default()
+	engine := gin.New()
+
+	// HTTP API metrics are recorded for all routes, including
+	// the unmatched ones, so the middleware is attached to the engine
+	// and every new handler is measured without any additional code.
+	//
+	// The metrics middleware goes before the 'gin.Default()' logger and
+	// recovery middlewares, so the panics recovered as the internal server
+	// errors are measured too.
+	metricsReporter, err := b.factory.CreateApiMetricsReporter()
+	if err != nil {
+		log.Error(err, "Failed to create API Metrics Reporter")
+		return nil, err
+	}
+	engine.Use(middleware.RecordMetrics(log, metricsReporter))
+	engine.Use(gin.Logger(), gin.Recovery())
+
+	// Every request is traced, and the request span is started
+	// right after the metrics middleware, so the recorded metrics
+	// are linked to the request trace with the exemplars.
+	tracer, err := b.factory.CreateApiTracer()
+	if err != nil {
+		log.Error(err, "Failed to create API Tracer")
+		return nil, err
+	}
+	engine.Use(middleware.TraceRequests(log, tracer))
 
 	// All our APIs are under 'api' group
 	apiGroup := engine.Group("api")
@@ -95,6 +138,7 @@ func (b *apiBuilder) BuildApi(
 	// These are global root level middlewares
 	// that are applied to all path handlers.
 	apiGroup.Use(middleware.EnsureCorrelationId(log))
+	apiGroup.Use(middleware.ExtractClientCertificate(log))
 
 	// v1 API group is directly attached to the "/api" root
 	// so all routes are under "/api".
@@ -119,13 +163,6 @@ func (b *apiBuilder) buildV1Api() (PathHandler, error) {
 	log := b.log.WithField(logger.FieldFunction, "buildV1Api")
 	log.Info("Building v1 API")
 
-	// Shared metrics reporter
-	metricsReporter, err := b.factory.CreateApiMetricsReporter()
-	if err != nil {
-		log.Error(err, "Failed to create API Metrics Reporter")
-		return nil, err
-	}
-
 	// Gists business logic
 	gistsLogic, err := b.factory.CreateGistsLogic()
 	if err != nil {
@@ -134,14 +171,35 @@ func (b *apiBuilder) buildV1Api() (PathHandler, error) {
 	}
 
 	// Gists API handler
-	gistsHandler, err := handlers.NewGistsHandler(log, gistsLogic, metricsReporter)
+	gistsHandler, err := handlers.NewGistsHandler(log, gistsLogic)
 	if err != nil {
 		log.Error(err, "Failed to create Gists Handler")
 		return nil, err
 	}
 
+	// Trash API handler
+	trashHandler, err := handlers.NewTrashHandler(log, gistsLogic)
+	if err != nil {
+		log.Error(err, "Failed to create Trash Handler")
+		return nil, err
+	}
+
+	// Collections business logic
+	collectionsLogic, err := b.factory.CreateCollectionsLogic()
+	if err != nil {
+		log.Error(err, "Failed to create Collections Logic")
+		return nil, err
+	}
+
+	// Collections API handler
+	collectionsHandler, err := handlers.NewCollectionsHandler(log, collectionsLogic)
+	if err != nil {
+		log.Error(err, "Failed to create Collections Handler")
+		return nil, err
+	}
+
 	// V1 router
-	v1router, err := v1.NewV1Router(log, gistsHandler)
+	v1router, err := v1.NewV1Router(log, gistsHandler, trashHandler, collectionsHandler)
 	if err != nil {
 		log.Error(err, "Failed to create v1 router")
 		return nil, err
@@ -149,3 +207,46 @@ func (b *apiBuilder) buildV1Api() (PathHandler, error) {
 
 	return v1router, nil
 }
+
+// BuildAdminApi creates the API Engine of the service administration,
+// that handles the calls under '/admin', for example '/admin/sweep'.
+//
+// The administration operations could permanently delete the data,
+// so the engine is served by the admin server only, that authorizes
+// the requests with the admin token, and never by the public API.
+// The swagger documentation describes the public API only, so it
+// doesn't include the administration operations either.
+func (b *apiBuilder) BuildAdminApi(
+	ctx context.Context,
+) (*gin.Engine, error) {
+	log := b.log.WithField(logger.FieldFunction, "BuildAdminApi")
+	log.Info("Building GoGin admin API")
+
+	engine := gin.New()
+	engine.Use(gin.Recovery())
+	engine.Use(middleware.EnsureCorrelationId(log))
+
+	// Expired gists sweeper
+	gistsSweeper, err := b.factory.CreateGistsSweeper()
+	if err != nil {
+		log.Error(err, "Failed to create Gists Sweeper")
+		return nil, err
+	}
+
+	// Service level objectives tracker
+	sloTracker, err := b.factory.CreateSloTracker()
+	if err != nil {
+		log.Error(err, "Failed to create SLO Tracker")
+		return nil, err
+	}
+
+	// Admin API handler
+	adminHandler, err := handlers.NewAdminHandler(log, gistsSweeper, sloTracker)
+	if err != nil {
+		log.Error(err, "Failed to create Admin Handler")
+		return nil, err
+	}
+	adminHandler.AttachTo(engine.Group(AdminRoute))
+
+	return engine, nil
+}
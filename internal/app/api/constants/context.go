@@ -0,0 +1,12 @@
+package constants
+
+const (
+	// ContextErrorCode is the gin context key that holds the code of the error
+	// that the request has been aborted with, so the middleware layer could
+	// report the failure after the request has been handled.
+	ContextErrorCode = "error-code"
+
+	// ContextClientSubject is the gin context key that holds the subject
+	// of the verified client certificate of the mutual TLS connection.
+	ContextClientSubject = "client-subject"
+)
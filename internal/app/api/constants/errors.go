@@ -13,4 +13,66 @@ const (
 
 	// ErrFailedToParseRequestJsonMsg happens when we have failed to parse JSON request content
 	ErrFailedToParseRequestJsonMsg = "Failed to parse JSON request content."
+
+	// ErrFailedToParseQueryCode uniquely identifies the cases when we have
+	// failed to parse the request query parameters
+	ErrFailedToParseQueryCode = "failed-to-parse-query"
+
+	// ErrFailedToParseQueryMsg happens when we have failed to parse query parameters
+	ErrFailedToParseQueryMsg = "Failed to parse query parameters."
+
+	// ErrInvalidExpirationCode uniquely identifies the cases when
+	// the gist expiration is invalid
+	ErrInvalidExpirationCode = "invalid-expiration"
+
+	// ErrInvalidExpirationMsg happens when the gist expiration time or TTL is invalid
+	ErrInvalidExpirationMsg = "The Gist expiration should be either 'expiresAt' in the future or a positive 'ttl', but not both."
+
+	// ErrGistNotFoundCode uniquely identifies the cases when the requested gist doesn't exist
+	ErrGistNotFoundCode = "gist-not-found"
+
+	// ErrGistNotFoundMsg happens when the requested gist doesn't exist
+	ErrGistNotFoundMsg = "The specified Gist does not exist."
+
+	// ErrGistTrashedCode uniquely identifies the cases when the gist
+	// could not be replaced, because it is in the trash
+	ErrGistTrashedCode = "gist-trashed"
+
+	// ErrGistTrashedMsg happens when the gist in the trash is replaced
+	ErrGistTrashedMsg = "The specified Gist is in the trash, it should be restored first."
+
+	// ErrNotAcceptableCode uniquely identifies the cases when the requested
+	// representation of the resource could not be provided
+	ErrNotAcceptableCode = "not-acceptable"
+
+	// ErrNotAcceptableMsg happens when the requested representation
+	// of the resource could not be provided
+	ErrNotAcceptableMsg = "The requested representation of the resource is not available."
+
+	// ErrSecretDetectedCode uniquely identifies the cases when the gist
+	// has been rejected, because it contains secrets
+	ErrSecretDetectedCode = "secret-detected"
+
+	// ErrSecretDetectedMsg happens when the gist contains secrets
+	ErrSecretDetectedMsg = "The Gist contains credentials or other secrets that must be removed."
+
+	// ErrCollectionNotFoundCode uniquely identifies the cases when the requested collection doesn't exist
+	ErrCollectionNotFoundCode = "collection-not-found"
+
+	// ErrCollectionNotFoundMsg happens when the requested collection doesn't exist
+	ErrCollectionNotFoundMsg = "The specified Collection does not exist."
+
+	// ErrCollectionGistNotFoundCode uniquely identifies the cases when
+	// the gist is not a member of the collection
+	ErrCollectionGistNotFoundCode = "collection-gist-not-found"
+
+	// ErrCollectionGistNotFoundMsg happens when the gist is not a member of the collection
+	ErrCollectionGistNotFoundMsg = "The specified Gist is not a member of the Collection."
+
+	// ErrDuplicateCollectionGistCode uniquely identifies the cases when
+	// the same gist is listed more than once in the collection
+	ErrDuplicateCollectionGistCode = "duplicate-collection-gist"
+
+	// ErrDuplicateCollectionGistMsg happens when the same gist is listed more than once in the collection
+	ErrDuplicateCollectionGistMsg = "The Collection could not contain the same Gist more than once."
 )
@@ -5,6 +5,7 @@ import (
 
 	"github.com/gin-gonic/gin"
 
+	"git.lothric.net/examples/go/gogin/internal/app/api/constants"
 	"git.lothric.net/examples/go/gogin/internal/app/api/v1/models"
 	"git.lothric.net/examples/go/gogin/internal/pkg/logger"
 )
@@ -22,6 +23,9 @@ func AbortWithError(
 	// Report the error to the log
 	log.Error(errors.New(errorCode), errorMsg)
 
+	// Keep the error code for the middleware layer
+	c.Set(constants.ContextErrorCode, errorCode)
+
 	// Abort the gin context and return the single error
 	c.AbortWithStatusJSON(
 		statusCode,
@@ -34,3 +38,34 @@ func AbortWithError(
 	)
 
 }
+
+// AbortWithErrorDetail is a wrapper that simplifies error cases,
+// when we return exactly one single error with the detail
+// that helps developers to investigate the error.
+func AbortWithErrorDetail(
+	c *gin.Context,
+	log logger.Log,
+	statusCode int,
+	errorCode string,
+	errorMsg string,
+	errorDetail string,
+) {
+
+	// Report the error to the log
+	log.Error(errors.New(errorCode), errorMsg, " ", errorDetail)
+
+	// Keep the error code for the middleware layer
+	c.Set(constants.ContextErrorCode, errorCode)
+
+	// Abort the gin context and return the single error
+	c.AbortWithStatusJSON(
+		statusCode,
+		[]models.Error{
+			{
+				Code:    errorCode,
+				Message: errorMsg,
+				Detail:  errorDetail,
+			},
+		},
+	)
+}
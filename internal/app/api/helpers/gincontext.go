@@ -23,6 +23,7 @@ var (
 //   - HTTP Request Headers
 //   - HTTP Request Cookies
 //   - HTTP Request JWT Auth Token
+//   - TLS Client Certificate
 //
 // This model doesn't include and SHOULD NEVER INCLUDE:
 //   - HTTP Query string parameters
@@ -37,6 +38,11 @@ type ContextModel struct {
 	// as well as internal calls between components of the system
 	CorrelationId string
 
+	// ClientSubject is the subject of the verified client certificate,
+	// if the request has been received via mutual TLS, otherwise empty.
+	// For example: "CN=billing,OU=payments,O=lothric"
+	ClientSubject string
+
 	// TODO: add more fields here
 }
 
@@ -47,10 +53,12 @@ func ExtractContextModel(c *gin.Context) (ContextModel, error) {
 
 	// Extract fields from the gin context
 	corrId := c.GetString(constants.HeaderCorrelationId)
+	clientSubject := c.GetString(constants.ContextClientSubject)
 
 	// Create context model
 	m := ContextModel{
 		CorrelationId: corrId,
+		ClientSubject: clientSubject,
 	}
 
 	// Note: return ErrFailedToParseGinContext defined above
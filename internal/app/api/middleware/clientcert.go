@@ -0,0 +1,37 @@
+package middleware
+
+import (
+	"github.com/gin-gonic/gin"
+
+	"git.lothric.net/examples/go/gogin/internal/app/api/constants"
+	"git.lothric.net/examples/go/gogin/internal/pkg/logger"
+)
+
+// ExtractClientCertificate middleware extracts the subject of the verified
+// client certificate, if the request has been received via mutual TLS,
+// so the HTTP API handlers could authorize the client.
+//
+// The subject is in the RFC 2253 form, for example:
+//   - CN=billing,OU=payments,O=lothric
+func ExtractClientCertificate(log logger.Log) gin.HandlerFunc {
+
+	// Create a closure to capture the adjusted log
+	log = log.WithFields(logger.Fields{
+		logger.FieldPackage:  "middleware",
+		logger.FieldFunction: "ExtractClientCertificate",
+	})
+
+	return func(c *gin.Context) {
+
+		// The client certificates are verified during the handshake,
+		// so the first one of the verified chain is the client's one
+		if tls := c.Request.TLS; tls != nil && len(tls.VerifiedChains) > 0 {
+			subject := tls.VerifiedChains[0][0].Subject.String()
+			log.Debug("Request has client certificate: ", subject)
+
+			c.Set(constants.ContextClientSubject, subject)
+		}
+
+		c.Next()
+	}
+}
@@ -0,0 +1,83 @@
+package middleware
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/require"
+
+	"git.lothric.net/examples/go/gogin/internal/app/api/helpers"
+	"git.lothric.net/examples/go/gogin/internal/pkg/logger"
+)
+
+func TestExtractClientCertificate(t *testing.T) {
+	for scenario, fn := range map[string]func(t *testing.T, e *gin.Engine){
+		"extracts verified subject":      testExtractsVerifiedSubject,
+		"ignores unverified certificate": testIgnoresUnverifiedCertificate,
+		"ignores plaintext request":      testIgnoresPlaintextRequest,
+	} {
+		t.Run(scenario, func(t *testing.T) {
+			gin.SetMode(gin.TestMode)
+			log, _ := logger.NewNullLogger()
+
+			engine := gin.New()
+			engine.Use(ExtractClientCertificate(log))
+			engine.GET("/gists", func(c *gin.Context) {
+				m, err := helpers.ExtractContextModel(c)
+				require.NoError(t, err)
+				c.String(http.StatusOK, m.ClientSubject)
+			})
+
+			fn(t, engine)
+		})
+	}
+}
+
+// certificate creates the client certificate of the subject.
+func certificate() *x509.Certificate {
+	return &x509.Certificate{
+		Subject: pkix.Name{
+			CommonName:         "billing",
+			OrganizationalUnit: []string{"payments"},
+			Organization:       []string{"lothric"},
+		},
+	}
+}
+
+func testExtractsVerifiedSubject(t *testing.T, e *gin.Engine) {
+	req := httptest.NewRequest(http.MethodGet, "/gists", nil)
+	req.TLS = &tls.ConnectionState{
+		PeerCertificates: []*x509.Certificate{certificate()},
+		VerifiedChains:   [][]*x509.Certificate{{certificate()}},
+	}
+
+	w := httptest.NewRecorder()
+	e.ServeHTTP(w, req)
+
+	require.Equal(t, "CN=billing,OU=payments,O=lothric", w.Body.String())
+}
+
+func testIgnoresUnverifiedCertificate(t *testing.T, e *gin.Engine) {
+	req := httptest.NewRequest(http.MethodGet, "/gists", nil)
+	req.TLS = &tls.ConnectionState{
+		PeerCertificates: []*x509.Certificate{certificate()},
+	}
+
+	w := httptest.NewRecorder()
+	e.ServeHTTP(w, req)
+
+	require.Empty(t, w.Body.String())
+}
+
+func testIgnoresPlaintextRequest(t *testing.T, e *gin.Engine) {
+	w := httptest.NewRecorder()
+	e.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/gists", nil))
+
+	require.Equal(t, http.StatusOK, w.Code)
+	require.Empty(t, w.Body.String())
+}
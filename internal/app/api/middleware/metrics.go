@@ -0,0 +1,132 @@
+package middleware
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"git.lothric.net/examples/go/gogin/internal/app/api/constants"
+	"git.lothric.net/examples/go/gogin/internal/pkg/logger"
+	"git.lothric.net/examples/go/gogin/internal/pkg/metrics"
+)
+
+const (
+
+	// unmatchedRoute is reported as a route of the requests,
+	// that don't match any of the registered routes, so the
+	// arbitrary request paths don't end up in the metric labels.
+	unmatchedRoute = "unmatched"
+)
+
+// ApiMetricsReporter reports the rate, errors and duration
+// metrics of the handled HTTP API requests.
+type ApiMetricsReporter interface {
+
+	// ApiRequestStarted tracks an API request that is being handled.
+	ApiRequestStarted(method string, route string)
+
+	// ApiRequestProcessed tracks an API request that has been handled
+	// with the 'status' in 'duration', with the request and response body sizes.
+	// The 'exemplar' labels link the request duration to the request logs.
+	ApiRequestProcessed(
+		method string,
+		route string,
+		status int,
+		duration time.Duration,
+		requestSize int64,
+		responseSize int64,
+		exemplar map[string]string,
+	)
+
+	// ApiRequestFailed tracks an API request that has been aborted
+	// with the 'status' and the error code 'failure'.
+	ApiRequestFailed(method string, route string, status int, failure string)
+}
+
+// RecordMetrics middleware records the metrics of every API request,
+// labeled by the route template, the HTTP method and the status,
+// so the handlers don't need to measure their requests.
+//
+// The middleware should be the first one in the chain,
+// so it measures the time spent in all other middlewares too.
+func RecordMetrics(log logger.Log, reporter ApiMetricsReporter) gin.HandlerFunc {
+
+	// Create a closure to capture the adjusted log
+	log = log.WithFields(logger.Fields{
+		logger.FieldPackage:  "middleware",
+		logger.FieldFunction: "RecordMetrics",
+	})
+
+	return func(c *gin.Context) {
+		start := time.Now()
+
+		// Route template, for example '/api/gists/:id'
+		route := c.FullPath()
+		if route == "" {
+			route = unmatchedRoute
+		}
+		method := c.Request.Method
+
+		reporter.ApiRequestStarted(method, route)
+
+		// The request is recorded as processed even if a handler panics,
+		// so the in-flight requests gauge doesn't stay raised
+		completed := false
+		defer func() {
+			status := c.Writer.Status()
+			if !completed {
+				// The panic is propagated further, and the response
+				// is not written yet, so it's reported as the server error
+				status = http.StatusInternalServerError
+			}
+
+			reporter.ApiRequestProcessed(
+				method,
+				route,
+				status,
+				time.Since(start),
+				size(c.Request.ContentLength),
+				size(int64(c.Writer.Size())),
+				exemplar(c),
+			)
+
+			// Failures are reported with the error code,
+			// that the request has been aborted with
+			if failure := c.GetString(constants.ContextErrorCode); failure != "" {
+				log.Debugf("Request has failed with [%d] %s", status, failure)
+				reporter.ApiRequestFailed(method, route, status, failure)
+			}
+		}()
+
+		c.Next()
+		completed = true
+	}
+}
+
+// exemplar returns the labels that link the request metrics to
+// the request logs and traces: the correlation id and the trace id,
+// if the request trace is sampled.
+func exemplar(c *gin.Context) map[string]string {
+
+	// The correlation id is set by the correlation middleware,
+	// unless the request hasn't matched any route
+	corrId := c.GetString(constants.HeaderCorrelationId)
+	if corrId == "" {
+		corrId = c.GetHeader(constants.HeaderCorrelationId)
+	}
+
+	// The request span is started by the tracing middleware
+	return map[string]string{
+		metrics.ExemplarRequestId: corrId,
+		metrics.ExemplarTraceId:   metrics.TraceId(c.Request.Context()),
+	}
+}
+
+// size returns the body size, or zero if the size is unknown.
+func size(n int64) int64 {
+	if n < 0 {
+		return 0
+	}
+	return n
+}
@@ -0,0 +1,234 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/require"
+
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+
+	"git.lothric.net/examples/go/gogin/internal/app/api/constants"
+	"git.lothric.net/examples/go/gogin/internal/app/api/helpers"
+	"git.lothric.net/examples/go/gogin/internal/pkg/logger"
+	"git.lothric.net/examples/go/gogin/internal/pkg/metrics"
+)
+
+func TestRecordMetrics(t *testing.T) {
+	for scenario, fn := range map[string]func(
+		t *testing.T,
+		e *gin.Engine,
+		m *reporterMock,
+	){
+		"records processed request":  testRecordsProcessedRequest,
+		"records failed request":     testRecordsFailedRequest,
+		"records unmatched route":    testRecordsUnmatchedRoute,
+		"records request body sizes": testRecordsRequestBodySizes,
+		"records exemplar":           testRecordsExemplar,
+		"records panicking request":  testRecordsPanickingRequest,
+		"records recovered request":  testRecordsRecoveredRequest,
+	} {
+		t.Run(scenario, func(t *testing.T) {
+			gin.SetMode(gin.TestMode)
+			log, _ := logger.NewNullLogger()
+			reporter := &reporterMock{}
+
+			// Only the traces sampled by the caller are sampled
+			tracer := sdktrace.NewTracerProvider(
+				sdktrace.WithSampler(sdktrace.ParentBased(sdktrace.NeverSample())),
+			).Tracer("test")
+
+			engine := gin.New()
+			engine.Use(RecordMetrics(log, reporter))
+			engine.Use(TraceRequests(log, tracer))
+
+			engine.GET("/gists/:id", func(c *gin.Context) {
+				c.String(http.StatusOK, "hello")
+			})
+			engine.POST("/gists", func(c *gin.Context) {
+				helpers.AbortWithError(c, log,
+					http.StatusBadRequest,
+					constants.ErrFailedToParseRequestJsonCode,
+					constants.ErrFailedToParseRequestJsonMsg)
+			})
+			engine.GET("/panic", func(c *gin.Context) {
+				panic("boom")
+			})
+			engine.GET("/recovered", gin.Recovery(), func(c *gin.Context) {
+				panic("boom")
+			})
+
+			fn(t, engine, reporter)
+		})
+	}
+}
+
+type request struct {
+	method        string
+	route         string
+	status        int
+	requestBytes  int64
+	responseBytes int64
+	exemplar      map[string]string
+	failure       string
+}
+
+type reporterMock struct {
+	started  int
+	requests []request
+}
+
+func (r *reporterMock) ApiRequestStarted(method string, route string) {
+	r.started++
+}
+
+func (r *reporterMock) ApiRequestProcessed(
+	method string,
+	route string,
+	status int,
+	duration time.Duration,
+	requestBytes int64,
+	responseBytes int64,
+	exemplar map[string]string,
+) {
+	r.requests = append(r.requests, request{
+		method:        method,
+		route:         route,
+		status:        status,
+		requestBytes:  requestBytes,
+		responseBytes: responseBytes,
+		exemplar:      exemplar,
+	})
+}
+
+func (r *reporterMock) ApiRequestFailed(method string, route string, status int, failure string) {
+	r.requests[len(r.requests)-1].failure = failure
+}
+
+func serve(e *gin.Engine, method string, path string, body string, headers ...string) {
+	req := httptest.NewRequest(method, path, strings.NewReader(body))
+	for i := 0; i+1 < len(headers); i += 2 {
+		req.Header.Set(headers[i], headers[i+1])
+	}
+	e.ServeHTTP(httptest.NewRecorder(), req)
+}
+
+func testRecordsProcessedRequest(
+	t *testing.T,
+	e *gin.Engine,
+	m *reporterMock,
+) {
+
+	serve(e, http.MethodGet, "/gists/42", "")
+
+	require.Equal(t, 1, m.started)
+	require.Equal(t, []request{
+		{
+			method:        "GET",
+			route:         "/gists/:id",
+			status:        200,
+			responseBytes: 5,
+			exemplar:      map[string]string{metrics.ExemplarRequestId: "", metrics.ExemplarTraceId: ""},
+		},
+	}, m.requests)
+}
+
+func testRecordsFailedRequest(
+	t *testing.T,
+	e *gin.Engine,
+	m *reporterMock,
+) {
+
+	serve(e, http.MethodPost, "/gists", "")
+
+	require.Len(t, m.requests, 1)
+	require.Equal(t, "/gists", m.requests[0].route)
+	require.Equal(t, http.StatusBadRequest, m.requests[0].status)
+	require.Equal(t, constants.ErrFailedToParseRequestJsonCode, m.requests[0].failure)
+}
+
+func testRecordsUnmatchedRoute(
+	t *testing.T,
+	e *gin.Engine,
+	m *reporterMock,
+) {
+
+	serve(e, http.MethodGet, "/unknown/path", "")
+
+	require.Len(t, m.requests, 1)
+	require.Equal(t, unmatchedRoute, m.requests[0].route)
+	require.Equal(t, http.StatusNotFound, m.requests[0].status)
+	require.Empty(t, m.requests[0].failure)
+}
+
+func testRecordsRequestBodySizes(
+	t *testing.T,
+	e *gin.Engine,
+	m *reporterMock,
+) {
+
+	serve(e, http.MethodPost, "/gists", `{"name":"hello"}`)
+
+	require.Len(t, m.requests, 1)
+	require.Equal(t, int64(16), m.requests[0].requestBytes)
+	require.Positive(t, m.requests[0].responseBytes)
+}
+
+func testRecordsExemplar(
+	t *testing.T,
+	e *gin.Engine,
+	m *reporterMock,
+) {
+
+	serve(e, http.MethodGet, "/gists/42", "",
+		constants.HeaderCorrelationId, "a1b2c3",
+		"traceparent", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+
+	// The traces that are not sampled are not linked
+	serve(e, http.MethodGet, "/gists/42", "",
+		"traceparent", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-00")
+
+	require.Len(t, m.requests, 2)
+	require.Equal(t, map[string]string{
+		metrics.ExemplarRequestId: "a1b2c3",
+		metrics.ExemplarTraceId:   "4bf92f3577b34da6a3ce929d0e0e4736",
+	}, m.requests[0].exemplar)
+	require.Equal(t, map[string]string{
+		metrics.ExemplarRequestId: "",
+		metrics.ExemplarTraceId:   "",
+	}, m.requests[1].exemplar)
+}
+
+func testRecordsPanickingRequest(
+	t *testing.T,
+	e *gin.Engine,
+	m *reporterMock,
+) {
+
+	require.Panics(t, func() {
+		serve(e, http.MethodGet, "/panic", "")
+	})
+
+	require.Equal(t, 1, m.started)
+	require.Len(t, m.requests, 1)
+	require.Equal(t, "/panic", m.requests[0].route)
+	require.Equal(t, http.StatusInternalServerError, m.requests[0].status)
+}
+
+func testRecordsRecoveredRequest(
+	t *testing.T,
+	e *gin.Engine,
+	m *reporterMock,
+) {
+
+	serve(e, http.MethodGet, "/recovered", "")
+
+	require.Equal(t, 1, m.started)
+	require.Len(t, m.requests, 1)
+	require.Equal(t, "/recovered", m.requests[0].route)
+	require.Equal(t, http.StatusInternalServerError, m.requests[0].status)
+}
@@ -0,0 +1,95 @@
+package middleware
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+
+	semconv "go.opentelemetry.io/otel/semconv/v1.20.0"
+
+	"git.lothric.net/examples/go/gogin/internal/app/api/constants"
+	"git.lothric.net/examples/go/gogin/internal/pkg/logger"
+	"git.lothric.net/examples/go/gogin/internal/pkg/tracing"
+)
+
+const (
+
+	// attributeRequestId is the span attribute with the request correlation id.
+	attributeRequestId = attribute.Key("http.request_id")
+
+	// attributeErrorCode is the span attribute with the error code
+	// the request has been aborted with.
+	attributeErrorCode = attribute.Key("error.code")
+)
+
+// TraceRequests middleware starts a server span for every API request.
+//
+// The W3C Trace Context of the caller is extracted from the 'traceparent'
+// and 'tracestate' headers, so the request span continues the caller's trace,
+// otherwise a new trace is started. The trace context of the request span
+// is injected back to the response headers, so the clients can find the trace.
+//
+// The span is stored in the request context and the handlers pass
+// it down the call chain, so the business logic and the storage spans
+// are the children of the request span.
+//
+// More on the W3C Trace Context could be found here:
+//   - https://www.w3.org/TR/trace-context/
+func TraceRequests(log logger.Log, tracer trace.Tracer) gin.HandlerFunc {
+
+	// Create a closure to capture the adjusted log
+	log = log.WithFields(logger.Fields{
+		logger.FieldPackage:  "middleware",
+		logger.FieldFunction: "TraceRequests",
+	})
+
+	return func(c *gin.Context) {
+		ctx := tracing.Propagator.Extract(c.Request.Context(), propagation.HeaderCarrier(c.Request.Header))
+
+		// Route template, for example '/api/gists/:id'
+		route := c.FullPath()
+		if route == "" {
+			route = unmatchedRoute
+		}
+
+		ctx, span := tracer.Start(ctx, c.Request.Method+" "+route,
+			trace.WithSpanKind(trace.SpanKindServer),
+			trace.WithAttributes(
+				semconv.HTTPMethod(c.Request.Method),
+				semconv.HTTPRoute(route),
+				semconv.HTTPTarget(c.Request.URL.Path),
+				semconv.UserAgentOriginal(c.Request.UserAgent()),
+			),
+		)
+		defer span.End()
+
+		tracing.Propagator.Inject(ctx, propagation.HeaderCarrier(c.Writer.Header()))
+		c.Request = c.Request.WithContext(ctx)
+
+		c.Next()
+
+		status := c.Writer.Status()
+		span.SetAttributes(semconv.HTTPStatusCode(status))
+
+		// The correlation id is set by the correlation middleware,
+		// unless the request hasn't matched any route
+		if corrId := c.GetString(constants.HeaderCorrelationId); corrId != "" {
+			span.SetAttributes(attributeRequestId.String(corrId))
+		}
+
+		failure := c.GetString(constants.ContextErrorCode)
+		if failure != "" {
+			span.SetAttributes(attributeErrorCode.String(failure))
+		}
+
+		// Client errors are not failures of the server span
+		if status >= http.StatusInternalServerError {
+			log.Debugf("Request span has failed with [%d] %s", status, failure)
+			span.SetStatus(codes.Error, failure)
+		}
+	}
+}
@@ -0,0 +1,141 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+
+	"git.lothric.net/examples/go/gogin/internal/app/api/constants"
+	"git.lothric.net/examples/go/gogin/internal/app/api/helpers"
+	"git.lothric.net/examples/go/gogin/internal/pkg/logger"
+)
+
+func TestTraceRequests(t *testing.T) {
+	for scenario, fn := range map[string]func(
+		t *testing.T,
+		e *gin.Engine,
+		r *tracetest.SpanRecorder,
+	){
+		"starts request span":         testStartsRequestSpan,
+		"continues caller trace":      testContinuesCallerTrace,
+		"propagates span to handlers": testPropagatesSpanToHandlers,
+		"records failed request":      testRecordsFailedRequestSpan,
+	} {
+		t.Run(scenario, func(t *testing.T) {
+			gin.SetMode(gin.TestMode)
+			log, _ := logger.NewNullLogger()
+			recorder := tracetest.NewSpanRecorder()
+			tracer := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)).Tracer("test")
+
+			engine := gin.New()
+			engine.Use(TraceRequests(log, tracer))
+
+			engine.GET("/gists/:id", func(c *gin.Context) {
+				c.Set(constants.HeaderCorrelationId, "a1b2c3")
+				c.String(http.StatusOK, trace.SpanContextFromContext(c.Request.Context()).SpanID().String())
+			})
+			engine.POST("/gists", func(c *gin.Context) {
+				helpers.AbortWithError(c, log,
+					http.StatusInternalServerError,
+					constants.ErrUnknownErrorCode,
+					constants.ErrUnknownErrorMsg)
+			})
+
+			fn(t, engine, recorder)
+		})
+	}
+}
+
+func attributes(span sdktrace.ReadOnlySpan) map[attribute.Key]attribute.Value {
+	result := map[attribute.Key]attribute.Value{}
+	for _, attr := range span.Attributes() {
+		result[attr.Key] = attr.Value
+	}
+	return result
+}
+
+func testStartsRequestSpan(
+	t *testing.T,
+	e *gin.Engine,
+	r *tracetest.SpanRecorder,
+) {
+
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/gists/42", nil))
+
+	spans := r.Ended()
+	require.Len(t, spans, 1)
+	require.Equal(t, "GET /gists/:id", spans[0].Name())
+	require.Equal(t, trace.SpanKindServer, spans[0].SpanKind())
+	require.False(t, spans[0].Parent().IsValid())
+
+	attrs := attributes(spans[0])
+	require.Equal(t, "/gists/:id", attrs["http.route"].AsString())
+	require.Equal(t, "/gists/42", attrs["http.target"].AsString())
+	require.Equal(t, int64(http.StatusOK), attrs["http.status_code"].AsInt64())
+	require.Equal(t, "a1b2c3", attrs[attributeRequestId].AsString())
+
+	// The trace context is returned to the caller
+	sc := spans[0].SpanContext()
+	require.Equal(t,
+		"00-"+sc.TraceID().String()+"-"+sc.SpanID().String()+"-01",
+		rec.Header().Get("traceparent"))
+}
+
+func testContinuesCallerTrace(
+	t *testing.T,
+	e *gin.Engine,
+	r *tracetest.SpanRecorder,
+) {
+
+	req := httptest.NewRequest(http.MethodGet, "/gists/42", nil)
+	req.Header.Set("traceparent", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	req.Header.Set("tracestate", "vendor=value")
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, req)
+
+	spans := r.Ended()
+	require.Len(t, spans, 1)
+	require.Equal(t, "4bf92f3577b34da6a3ce929d0e0e4736", spans[0].SpanContext().TraceID().String())
+	require.Equal(t, "00f067aa0ba902b7", spans[0].Parent().SpanID().String())
+	require.True(t, spans[0].Parent().IsRemote())
+	require.Equal(t, "vendor=value", rec.Header().Get("tracestate"))
+}
+
+func testPropagatesSpanToHandlers(
+	t *testing.T,
+	e *gin.Engine,
+	r *tracetest.SpanRecorder,
+) {
+
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/gists/42", nil))
+
+	spans := r.Ended()
+	require.Len(t, spans, 1)
+	require.Equal(t, spans[0].SpanContext().SpanID().String(), rec.Body.String())
+}
+
+func testRecordsFailedRequestSpan(
+	t *testing.T,
+	e *gin.Engine,
+	r *tracetest.SpanRecorder,
+) {
+
+	e.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodPost, "/gists", nil))
+
+	spans := r.Ended()
+	require.Len(t, spans, 1)
+	require.Equal(t, codes.Error, spans[0].Status().Code)
+	require.Equal(t, constants.ErrUnknownErrorCode, spans[0].Status().Description)
+	require.Equal(t, constants.ErrUnknownErrorCode, attributes(spans[0])[attributeErrorCode].AsString())
+}
@@ -0,0 +1,162 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"git.lothric.net/examples/go/gogin/internal/app/api/constants"
+	"git.lothric.net/examples/go/gogin/internal/app/api/helpers"
+	"git.lothric.net/examples/go/gogin/internal/app/api/v1/models"
+	"git.lothric.net/examples/go/gogin/internal/app/logic"
+	"git.lothric.net/examples/go/gogin/internal/pkg/logger"
+	"git.lothric.net/examples/go/gogin/internal/pkg/slo"
+)
+
+const (
+
+	// QueryDryRun is a query key that is used to request a dry run.
+	QueryDryRun = "dryRun"
+)
+
+// GistsSweeper deletes the expired gists.
+type GistsSweeper interface {
+
+	// Sweep deletes the expired gists, or only lists them if it is a dry run
+	Sweep(ctx context.Context, dryRun bool) ([]logic.Gist, error)
+}
+
+// SloTracker tracks the service level objectives of the API routes.
+type SloTracker interface {
+
+	// Status returns the current state of all objectives.
+	Status() []slo.Status
+
+	// Window returns the rolling window of the error budget.
+	Window() time.Duration
+
+	// BurnWindow returns the rolling window of the burn rate.
+	BurnWindow() time.Duration
+}
+
+// adminHandler handles all APIs calls for the service administration.
+type adminHandler struct {
+	log     logger.Log
+	sweeper GistsSweeper
+	slo     SloTracker
+}
+
+// NewAdminHandler creates a new instance of the API handler
+// that handles all requests to 'admin' resource of the admin server.
+func NewAdminHandler(
+	log logger.Log,
+	sweeper GistsSweeper,
+	slo SloTracker,
+) (*adminHandler, error) {
+
+	ah := &adminHandler{
+		log:     log.WithField(logger.FieldPackage, pkg),
+		sweeper: sweeper,
+		slo:     slo,
+	}
+
+	return ah, nil
+}
+
+// AttachTo attaches the adminHandler to the
+// provided parent router group.
+func (ah *adminHandler) AttachTo(g *gin.RouterGroup) error {
+
+	// POST /admin/sweep?dryRun=true
+	g.POST("sweep", ah.postSweep)
+
+	// GET /admin/slo
+	g.GET("slo", ah.getSlo)
+
+	return nil
+}
+
+// postSweep deletes all the expired Gists, the same way as the background sweeper does.
+// The dry run only returns the Gists that would be deleted, without deleting them.
+// The dry run is used by default, unless 'dryRun=false' is explicitly specified.
+// Even the dry run is served by the admin server only, see BuildAdminApi.
+func (ah *adminHandler) postSweep(c *gin.Context) {
+	log, ctx, _, err := helpers.ParseContext(ah.log, c, "postSweep")
+	if err != nil {
+		helpers.AbortWithError(c, log,
+			http.StatusInternalServerError,
+			constants.ErrUnknownErrorCode,
+			constants.ErrUnknownErrorMsg)
+		return
+	}
+	log.Info("Handling postSweep")
+
+	// Extract argument
+	dryRun, err := strconv.ParseBool(c.DefaultQuery(QueryDryRun, "true"))
+	if err != nil {
+		helpers.AbortWithError(c, log,
+			http.StatusBadRequest,
+			constants.ErrFailedToParseQueryCode,
+			constants.ErrFailedToParseQueryMsg)
+		return
+	}
+
+	gists, err := ah.sweeper.Sweep(ctx, dryRun)
+	if err != nil {
+		abortWithLogicError(c, log, err)
+		return
+	}
+
+	result := models.SweepResult{
+		DryRun: dryRun,
+		Gists:  make([]models.GistInfo, 0, len(gists)),
+	}
+	for _, gist := range gists {
+		result.Gists = append(result.Gists, toGistInfo(gist))
+	}
+
+	// Return result
+	c.AbortWithStatusJSON(http.StatusOK, result)
+}
+
+// getSlo returns the availability and latency objectives of the API routes
+// with the error rates, the burn rates and the error budgets consumed within the rolling window.
+// It is served by the admin server only, see BuildAdminApi.
+func (ah *adminHandler) getSlo(c *gin.Context) {
+	log, _, _, err := helpers.ParseContext(ah.log, c, "getSlo")
+	if err != nil {
+		helpers.AbortWithError(c, log,
+			http.StatusInternalServerError,
+			constants.ErrUnknownErrorCode,
+			constants.ErrUnknownErrorMsg)
+		return
+	}
+	log.Info("Handling getSlo")
+
+	statuses := ah.slo.Status()
+	result := models.SloReport{
+		Window:     ah.slo.Window().String(),
+		BurnWindow: ah.slo.BurnWindow().String(),
+		Objectives: make([]models.SloStatus, 0, len(statuses)),
+	}
+	for _, status := range statuses {
+		result.Objectives = append(result.Objectives, models.SloStatus{
+			Route:           status.Route,
+			Objective:       status.Kind,
+			Target:          status.Target,
+			Threshold:       status.Threshold,
+			Requests:        status.Requests,
+			Errors:          status.Errors,
+			ErrorRate:       status.ErrorRate,
+			BurnRate:        status.BurnRate,
+			BudgetConsumed:  status.BudgetConsumed,
+			BudgetRemaining: status.BudgetRemaining,
+		})
+	}
+
+	// Return result
+	c.AbortWithStatusJSON(http.StatusOK, result)
+}
@@ -0,0 +1,533 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+
+	"git.lothric.net/examples/go/gogin/internal/app/api/constants"
+	"git.lothric.net/examples/go/gogin/internal/app/api/helpers"
+	"git.lothric.net/examples/go/gogin/internal/app/api/v1/models"
+	"git.lothric.net/examples/go/gogin/internal/app/logic"
+	"git.lothric.net/examples/go/gogin/internal/pkg/logger"
+)
+
+const (
+
+	// QueryLimit is a query key that is used to specify the maximum number of items.
+	QueryLimit = "limit"
+
+	// QueryOffset is a query key that is used to specify the number of items to skip.
+	QueryOffset = "offset"
+
+	// defaultCollectionGistsLimit is the number of collection gists returned by default.
+	defaultCollectionGistsLimit = 100
+
+	// maxCollectionGistsLimit is the maximum number of collection gists returned at once.
+	maxCollectionGistsLimit = 1000
+)
+
+// CollectionsLogic is a business logic layer for gist collections.
+type CollectionsLogic interface {
+
+	// GetCollections returns all collections
+	GetCollections(ctx context.Context) ([]logic.Collection, error)
+
+	// GetCollection returns the collection with the specified id
+	GetCollection(ctx context.Context, id string) (logic.Collection, error)
+
+	// CreateCollection stores a new empty collection
+	CreateCollection(ctx context.Context, collection logic.Collection) (logic.Collection, error)
+
+	// RenameCollection changes the name of the collection
+	RenameCollection(ctx context.Context, id string, name string) (logic.Collection, error)
+
+	// DeleteCollection deletes the collection with the specified id
+	DeleteCollection(ctx context.Context, id string) error
+
+	// GetCollectionGists returns up to limit visible gists of the collection in order,
+	// skipping the first offset visible gists
+	GetCollectionGists(ctx context.Context, id string, offset int, limit int) ([]logic.Gist, error)
+
+	// AddCollectionGist adds the gist to the collection at the position
+	AddCollectionGist(ctx context.Context, id string, gistId string, position int) (logic.Collection, error)
+
+	// RemoveCollectionGist removes the gist from the collection
+	RemoveCollectionGist(ctx context.Context, id string, gistId string) (logic.Collection, error)
+
+	// SetCollectionGists replaces the gists of the collection in the provided order
+	SetCollectionGists(ctx context.Context, id string, gistIds []string) (logic.Collection, error)
+}
+
+// collectionsHandler handles all APIs calls for the 'collections' resource.
+type collectionsHandler struct {
+	log   logger.Log
+	logic CollectionsLogic
+}
+
+// NewCollectionsHandler creates a new instance of the API handler
+// that handles all requests to 'collections' resource.
+func NewCollectionsHandler(
+	log logger.Log,
+	logic CollectionsLogic,
+) (*collectionsHandler, error) {
+
+	ch := &collectionsHandler{
+		log:   log.WithField(logger.FieldPackage, pkg),
+		logic: logic,
+	}
+
+	return ch, nil
+}
+
+// AttachTo attaches the collectionsHandler to the
+// provided parent router group.
+func (ch *collectionsHandler) AttachTo(g *gin.RouterGroup) error {
+
+	// GET /api/collections
+	g.GET("", ch.getCollections)
+
+	// POST /api/collections
+	g.POST("", ch.postCollection)
+
+	// GET /api/collections/{id}
+	g.GET(":id", ch.getCollection)
+
+	// PATCH /api/collections/{id}
+	g.PATCH(":id", ch.patchCollection)
+
+	// DELETE /api/collections/{id}
+	g.DELETE(":id", ch.deleteCollection)
+
+	// GET /api/collections/{id}/gists
+	g.GET(":id/gists", ch.getCollectionGists)
+
+	// POST /api/collections/{id}/gists
+	g.POST(":id/gists", ch.postCollectionGist)
+
+	// PUT /api/collections/{id}/gists
+	g.PUT(":id/gists", ch.putCollectionGists)
+
+	// DELETE /api/collections/{id}/gists/{gistId}
+	g.DELETE(":id/gists/:gistId", ch.deleteCollectionGist)
+
+	return nil
+}
+
+// getCollections godoc
+//
+//	@Summary		Get the list of Gist Collections.
+//	@Description	This method returns all the Collections, the oldest first.
+//	@Tags			Collections
+//	@Produce		json
+//	@Success		200	{array}	models.CollectionInfo	"The list of Collections has been successfully retrieved."
+//	@Failure		500	{array}	models.Error			"The service has encountered unexpected error that it was not able to handle."
+//	@Router			/collections [get]
+func (ch *collectionsHandler) getCollections(c *gin.Context) {
+	log, ctx, _, err := helpers.ParseContext(ch.log, c, "getCollections")
+	if err != nil {
+		helpers.AbortWithError(c, log,
+			http.StatusInternalServerError,
+			constants.ErrUnknownErrorCode,
+			constants.ErrUnknownErrorMsg)
+		return
+	}
+	log.Info("Handling getCollections")
+
+	collections, err := ch.logic.GetCollections(ctx)
+	if err != nil {
+		abortWithLogicError(c, log, err)
+		return
+	}
+
+	collectionsInfo := make([]models.CollectionInfo, 0, len(collections))
+	for _, collection := range collections {
+		collectionsInfo = append(collectionsInfo, toCollectionInfo(collection))
+	}
+
+	// Return result
+	c.AbortWithStatusJSON(http.StatusOK, collectionsInfo)
+}
+
+// postCollection godoc
+//
+//	@Summary		Create a new Gist Collection.
+//	@Description	This method is called to create a new empty Collection.
+//	@Tags			Collections
+//	@Param			collection	body	models.Collection	true	"Collection definition"
+//	@Produce		json
+//	@Success		201	{object}	models.CollectionInfo	"Collection has been created."
+//	@Failure		400	{array}		models.Error			"Failed to parse JSON request content."
+//	@Failure		500	{array}		models.Error			"The service has encountered unexpected error that it was not able to handle."
+//	@Router			/collections [post]
+func (ch *collectionsHandler) postCollection(c *gin.Context) {
+	log, ctx, _, err := helpers.ParseContext(ch.log, c, "postCollection")
+	if err != nil {
+		helpers.AbortWithError(c, log,
+			http.StatusInternalServerError,
+			constants.ErrUnknownErrorCode,
+			constants.ErrUnknownErrorMsg)
+		return
+	}
+	log.Info("Handling postCollection")
+
+	// Extract argument
+	var collection models.Collection
+	if err := c.BindJSON(&collection); err != nil {
+		helpers.AbortWithError(c, log,
+			http.StatusBadRequest,
+			constants.ErrFailedToParseRequestJsonCode,
+			constants.ErrFailedToParseRequestJsonMsg)
+		return
+	}
+
+	created, err := ch.logic.CreateCollection(ctx, logic.Collection{
+		Name:        collection.Name,
+		Description: collection.Description,
+	})
+	if err != nil {
+		abortWithLogicError(c, log, err)
+		return
+	}
+
+	// Return result
+	c.AbortWithStatusJSON(http.StatusCreated, toCollectionInfo(created))
+}
+
+// getCollection godoc
+//
+//	@Summary		Get the Gist Collection.
+//	@Description	This method returns the Collection with the ids of the member Gists.
+//	@Tags			Collections
+//	@Param			id	path	string	true	"Collection id"
+//	@Produce		json
+//	@Success		200	{object}	models.CollectionInfo	"Collection has been successfully retrieved."
+//	@Failure		404	{array}		models.Error			"The specified Collection does not exist."
+//	@Failure		500	{array}		models.Error			"The service has encountered unexpected error that it was not able to handle."
+//	@Router			/collections/{id} [get]
+func (ch *collectionsHandler) getCollection(c *gin.Context) {
+	log, ctx, _, err := helpers.ParseContext(ch.log, c, "getCollection")
+	if err != nil {
+		helpers.AbortWithError(c, log,
+			http.StatusInternalServerError,
+			constants.ErrUnknownErrorCode,
+			constants.ErrUnknownErrorMsg)
+		return
+	}
+	log.Info("Handling getCollection")
+
+	// Extract argument
+	id := c.Param("id")
+
+	collection, err := ch.logic.GetCollection(ctx, id)
+	if err != nil {
+		abortWithLogicError(c, log, err)
+		return
+	}
+
+	// Return result
+	c.AbortWithStatusJSON(http.StatusOK, toCollectionInfo(collection))
+}
+
+// patchCollection godoc
+//
+//	@Summary		Rename the Gist Collection.
+//	@Description	This method is called to change the name of the Collection.
+//	@Tags			Collections
+//	@Param			id			path	string					true	"Collection id"
+//	@Param			collection	body	models.CollectionRename	true	"New Collection name"
+//	@Produce		json
+//	@Success		200	{object}	models.CollectionInfo	"Collection has been renamed."
+//	@Failure		400	{array}		models.Error			"Failed to parse JSON request content."
+//	@Failure		404	{array}		models.Error			"The specified Collection does not exist."
+//	@Failure		500	{array}		models.Error			"The service has encountered unexpected error that it was not able to handle."
+//	@Router			/collections/{id} [patch]
+func (ch *collectionsHandler) patchCollection(c *gin.Context) {
+	log, ctx, _, err := helpers.ParseContext(ch.log, c, "patchCollection")
+	if err != nil {
+		helpers.AbortWithError(c, log,
+			http.StatusInternalServerError,
+			constants.ErrUnknownErrorCode,
+			constants.ErrUnknownErrorMsg)
+		return
+	}
+	log.Info("Handling patchCollection")
+
+	// Extract arguments
+	id := c.Param("id")
+
+	var rename models.CollectionRename
+	if err := c.BindJSON(&rename); err != nil {
+		helpers.AbortWithError(c, log,
+			http.StatusBadRequest,
+			constants.ErrFailedToParseRequestJsonCode,
+			constants.ErrFailedToParseRequestJsonMsg)
+		return
+	}
+
+	renamed, err := ch.logic.RenameCollection(ctx, id, rename.Name)
+	if err != nil {
+		abortWithLogicError(c, log, err)
+		return
+	}
+
+	// Return result
+	c.AbortWithStatusJSON(http.StatusOK, toCollectionInfo(renamed))
+}
+
+// deleteCollection godoc
+//
+//	@Summary		Delete the Gist Collection.
+//	@Description	This method is called to delete the Collection.
+//	@Description	The member Gists are not deleted.
+//	@Tags			Collections
+//	@Param			id	path	string	true	"Collection id"
+//	@Produce		json
+//	@Success		204	"Collection has been deleted."
+//	@Failure		404	{array}	models.Error	"The specified Collection does not exist."
+//	@Failure		500	{array}	models.Error	"The service has encountered unexpected error that it was not able to handle."
+//	@Router			/collections/{id} [delete]
+func (ch *collectionsHandler) deleteCollection(c *gin.Context) {
+	log, ctx, _, err := helpers.ParseContext(ch.log, c, "deleteCollection")
+	if err != nil {
+		helpers.AbortWithError(c, log,
+			http.StatusInternalServerError,
+			constants.ErrUnknownErrorCode,
+			constants.ErrUnknownErrorMsg)
+		return
+	}
+	log.Info("Handling deleteCollection")
+
+	// Extract argument
+	id := c.Param("id")
+
+	if err := ch.logic.DeleteCollection(ctx, id); err != nil {
+		abortWithLogicError(c, log, err)
+		return
+	}
+
+	// Return result
+	c.AbortWithStatus(http.StatusNoContent)
+}
+
+// getCollectionGists godoc
+//
+//	@Summary		Get the Gists of the Collection.
+//	@Description	This method returns the member Gists in the collection order.
+//	@Description	The expired and deleted Gists are not returned.
+//	@Description	The Gists are returned by pages of up to 'limit' Gists, starting after the 'offset' Gists.
+//	@Tags			Collections
+//	@Param			id		path	string	true	"Collection id"
+//	@Param			limit	query	int		false	"Maximum number of Gists"		default(100)	minimum(1)	maximum(1000)
+//	@Param			offset	query	int		false	"Number of Gists to skip"	default(0)		minimum(0)
+//	@Produce		json
+//	@Success		200	{array}	models.GistInfo	"The list of Gists has been successfully retrieved."
+//	@Failure		400	{array}	models.Error	"Failed to parse query parameters."
+//	@Failure		404	{array}	models.Error	"The specified Collection does not exist."
+//	@Failure		500	{array}	models.Error	"The service has encountered unexpected error that it was not able to handle."
+//	@Router			/collections/{id}/gists [get]
+func (ch *collectionsHandler) getCollectionGists(c *gin.Context) {
+	log, ctx, _, err := helpers.ParseContext(ch.log, c, "getCollectionGists")
+	if err != nil {
+		helpers.AbortWithError(c, log,
+			http.StatusInternalServerError,
+			constants.ErrUnknownErrorCode,
+			constants.ErrUnknownErrorMsg)
+		return
+	}
+	log.Info("Handling getCollectionGists")
+
+	// Extract argument
+	id := c.Param("id")
+	limit, err := strconv.Atoi(c.DefaultQuery(QueryLimit, strconv.Itoa(defaultCollectionGistsLimit)))
+	if err != nil || limit < 1 || limit > maxCollectionGistsLimit {
+		helpers.AbortWithError(c, log,
+			http.StatusBadRequest,
+			constants.ErrFailedToParseQueryCode,
+			constants.ErrFailedToParseQueryMsg)
+		return
+	}
+	offset, err := strconv.Atoi(c.DefaultQuery(QueryOffset, "0"))
+	if err != nil || offset < 0 {
+		helpers.AbortWithError(c, log,
+			http.StatusBadRequest,
+			constants.ErrFailedToParseQueryCode,
+			constants.ErrFailedToParseQueryMsg)
+		return
+	}
+
+	gists, err := ch.logic.GetCollectionGists(ctx, id, offset, limit)
+	if err != nil {
+		abortWithLogicError(c, log, err)
+		return
+	}
+
+	gistsInfo := make([]models.GistInfo, 0, len(gists))
+	for _, gist := range gists {
+		gistsInfo = append(gistsInfo, toGistInfo(gist))
+	}
+
+	// Return result
+	c.AbortWithStatusJSON(http.StatusOK, gistsInfo)
+}
+
+// postCollectionGist godoc
+//
+//	@Summary		Add the Gist to the Collection.
+//	@Description	This method is called to add the Gist to the Collection at the specified position.
+//	@Description	If the Gist is already a member of the Collection, it is moved to the new position.
+//	@Tags			Collections
+//	@Param			id		path	string					true	"Collection id"
+//	@Param			gist	body	models.CollectionGist	true	"Gist to add"
+//	@Produce		json
+//	@Success		200	{object}	models.CollectionInfo	"Gist has been added to the Collection."
+//	@Failure		400	{array}		models.Error			"Failed to parse JSON request content."
+//	@Failure		404	{array}		models.Error			"The specified Collection does not exist."
+//	@Failure		404	{array}		models.Error			"The specified Gist does not exist."
+//	@Failure		500	{array}		models.Error			"The service has encountered unexpected error that it was not able to handle."
+//	@Router			/collections/{id}/gists [post]
+func (ch *collectionsHandler) postCollectionGist(c *gin.Context) {
+	log, ctx, _, err := helpers.ParseContext(ch.log, c, "postCollectionGist")
+	if err != nil {
+		helpers.AbortWithError(c, log,
+			http.StatusInternalServerError,
+			constants.ErrUnknownErrorCode,
+			constants.ErrUnknownErrorMsg)
+		return
+	}
+	log.Info("Handling postCollectionGist")
+
+	// Extract arguments
+	id := c.Param("id")
+
+	var gist models.CollectionGist
+	if err := c.BindJSON(&gist); err != nil {
+		helpers.AbortWithError(c, log,
+			http.StatusBadRequest,
+			constants.ErrFailedToParseRequestJsonCode,
+			constants.ErrFailedToParseRequestJsonMsg)
+		return
+	}
+
+	// Append to the end, unless the position is specified
+	position := -1
+	if gist.Position != nil {
+		position = *gist.Position
+	}
+
+	collection, err := ch.logic.AddCollectionGist(ctx, id, gist.Id, position)
+	if err != nil {
+		abortWithLogicError(c, log, err)
+		return
+	}
+
+	// Return result
+	c.AbortWithStatusJSON(http.StatusOK, toCollectionInfo(collection))
+}
+
+// putCollectionGists godoc
+//
+//	@Summary		Reorder the Gists of the Collection.
+//	@Description	This method is called to replace the member Gists of the Collection
+//	@Description	with the specified Gists in the specified order.
+//	@Tags			Collections
+//	@Param			id		path	string					true	"Collection id"
+//	@Param			order	body	models.CollectionOrder	true	"Gists in the new order"
+//	@Produce		json
+//	@Success		200	{object}	models.CollectionInfo	"Collection has been reordered."
+//	@Failure		400	{array}		models.Error			"Failed to parse JSON request content."
+//	@Failure		400	{array}		models.Error			"The Collection could not contain the same Gist more than once."
+//	@Failure		404	{array}		models.Error			"The specified Collection does not exist."
+//	@Failure		404	{array}		models.Error			"The specified Gist does not exist."
+//	@Failure		500	{array}		models.Error			"The service has encountered unexpected error that it was not able to handle."
+//	@Router			/collections/{id}/gists [put]
+func (ch *collectionsHandler) putCollectionGists(c *gin.Context) {
+	log, ctx, _, err := helpers.ParseContext(ch.log, c, "putCollectionGists")
+	if err != nil {
+		helpers.AbortWithError(c, log,
+			http.StatusInternalServerError,
+			constants.ErrUnknownErrorCode,
+			constants.ErrUnknownErrorMsg)
+		return
+	}
+	log.Info("Handling putCollectionGists")
+
+	// Extract arguments
+	id := c.Param("id")
+
+	var order models.CollectionOrder
+	if err := c.BindJSON(&order); err != nil {
+		helpers.AbortWithError(c, log,
+			http.StatusBadRequest,
+			constants.ErrFailedToParseRequestJsonCode,
+			constants.ErrFailedToParseRequestJsonMsg)
+		return
+	}
+
+	collection, err := ch.logic.SetCollectionGists(ctx, id, order.GistIds)
+	if err != nil {
+		abortWithLogicError(c, log, err)
+		return
+	}
+
+	// Return result
+	c.AbortWithStatusJSON(http.StatusOK, toCollectionInfo(collection))
+}
+
+// deleteCollectionGist godoc
+//
+//	@Summary		Remove the Gist from the Collection.
+//	@Description	This method is called to remove the Gist from the Collection.
+//	@Description	The Gist itself is not deleted.
+//	@Tags			Collections
+//	@Param			id		path	string	true	"Collection id"
+//	@Param			gistId	path	string	true	"Gist id"
+//	@Produce		json
+//	@Success		200	{object}	models.CollectionInfo	"Gist has been removed from the Collection."
+//	@Failure		404	{array}		models.Error			"The specified Collection does not exist."
+//	@Failure		404	{array}		models.Error			"The specified Gist is not a member of the Collection."
+//	@Failure		500	{array}		models.Error			"The service has encountered unexpected error that it was not able to handle."
+//	@Router			/collections/{id}/gists/{gistId} [delete]
+func (ch *collectionsHandler) deleteCollectionGist(c *gin.Context) {
+	log, ctx, _, err := helpers.ParseContext(ch.log, c, "deleteCollectionGist")
+	if err != nil {
+		helpers.AbortWithError(c, log,
+			http.StatusInternalServerError,
+			constants.ErrUnknownErrorCode,
+			constants.ErrUnknownErrorMsg)
+		return
+	}
+	log.Info("Handling deleteCollectionGist")
+
+	// Extract arguments
+	id := c.Param("id")
+	gistId := c.Param("gistId")
+
+	collection, err := ch.logic.RemoveCollectionGist(ctx, id, gistId)
+	if err != nil {
+		abortWithLogicError(c, log, err)
+		return
+	}
+
+	// Return result
+	c.AbortWithStatusJSON(http.StatusOK, toCollectionInfo(collection))
+}
+
+// toCollectionInfo converts the business logic collection to the API collection info.
+func toCollectionInfo(collection logic.Collection) models.CollectionInfo {
+	gistIds := collection.GistIds
+	if gistIds == nil {
+		gistIds = []string{}
+	}
+
+	return models.CollectionInfo{
+		Id:          collection.Id,
+		Name:        collection.Name,
+		Description: collection.Description,
+		GistIds:     gistIds,
+		CreatedAt:   formatTime(collection.CreatedAt),
+		LastUpdated: formatTime(collection.UpdatedAt),
+	}
+}
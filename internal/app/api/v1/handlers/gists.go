@@ -2,7 +2,7 @@ package handlers
 
 import (
 	"context"
-	"math/rand"
+	"errors"
 	"net/http"
 	"time"
 
@@ -11,6 +11,7 @@ import (
 	"git.lothric.net/examples/go/gogin/internal/app/api/constants"
 	"git.lothric.net/examples/go/gogin/internal/app/api/helpers"
 	"git.lothric.net/examples/go/gogin/internal/app/api/v1/models"
+	"git.lothric.net/examples/go/gogin/internal/app/logic"
 	"git.lothric.net/examples/go/gogin/internal/pkg/logger"
 )
 
@@ -27,24 +28,37 @@ const (
 type GistsLogic interface {
 
 	// GetGists returns a filtered list of gists
-	GetGists(ctx context.Context, language string) error
-}
+	GetGists(ctx context.Context, language string) ([]logic.Gist, error)
+
+	// GetGist returns the gist with the specified id
+	GetGist(ctx context.Context, id string) (logic.Gist, error)
+
+	// CreateGist stores a new gist
+	CreateGist(ctx context.Context, gist logic.Gist) (logic.Gist, error)
+
+	// UpdateGist creates or replaces the gist with the specified id
+	UpdateGist(ctx context.Context, id string, gist logic.Gist) (logic.Gist, error)
+
+	// DeleteGist deletes the gist with the specified id
+	DeleteGist(ctx context.Context, id string) error
 
-// MetricsReporter is metrics reporting handler for gists APIs.
-type ApiMetricsReporter interface {
+	// RenderGist renders the markdown gist to a HTML page
+	RenderGist(ctx context.Context, id string) ([]byte, error)
 
-	// ApiRequestProcessed
-	ApiRequestProcessed(operation string, milliseconds float64)
+	// GetTrash returns the gists in the trash
+	GetTrash(ctx context.Context) ([]logic.Gist, error)
 
-	// ApiRequestFailed
-	ApiRequestFailed(operation string, failure string)
+	// RestoreGist restores the gist from the trash
+	RestoreGist(ctx context.Context, id string) (logic.Gist, error)
+
+	// PurgeGist permanently deletes the gist from the trash
+	PurgeGist(ctx context.Context, id string) error
 }
 
 // gistsHandler handles all APIs calls for the 'gists' resource.
 type gistsHandler struct {
-	log     logger.Log
-	logic   GistsLogic
-	metrics ApiMetricsReporter
+	log   logger.Log
+	logic GistsLogic
 }
 
 // NewGistsHandler creates a new instance of the API handler
@@ -52,13 +66,11 @@ type gistsHandler struct {
 func NewGistsHandler(
 	log logger.Log,
 	logic GistsLogic,
-	metrics ApiMetricsReporter,
 ) (*gistsHandler, error) {
 
 	gh := &gistsHandler{
-		log:     log.WithField(logger.FieldPackage, pkg),
-		logic:   logic,
-		metrics: metrics,
+		log:   log.WithField(logger.FieldPackage, pkg),
+		logic: logic,
 	}
 
 	return gh, nil
@@ -98,7 +110,6 @@ func (gh *gistsHandler) AttachTo(g *gin.RouterGroup) error {
 //	@Failure		500	{array}	models.Error	"The service has encountered unexpected error that it was not able to handle."
 //	@Router			/gists [get]
 func (gh *gistsHandler) getGists(c *gin.Context) {
-	defer timer(gh.metrics, "get_gists")()
 	log, ctx, _, err := helpers.ParseContext(gh.log, c, "getGists")
 	if err != nil {
 		helpers.AbortWithError(c, log,
@@ -112,25 +123,15 @@ func (gh *gistsHandler) getGists(c *gin.Context) {
 	// Extract argument
 	lang := c.Query(QueryLanguage)
 
-	// Note: just an example of the call
-	_ = gh.logic.GetGists(ctx, lang)
-
-	// Simulate processing
-	time.Sleep(time.Millisecond * time.Duration(rand.Intn(100)+10))
-
-	gistsInfo := []models.GistInfo{
-		{
-			Id:          "d17043a0-216c-4c56-9127-b0bf5e3a4c16",
-			Language:    lang,
-			Name:        "Convert PNG to JPG",
-			Description: "Convert PNG image to JPG image using pillow library",
-		},
-		{
-			Id:          "9faa0e0f-e189-4cff-ae6f-7c0c52b3c876",
-			Language:    lang,
-			Name:        "Generate unique ID",
-			Description: "Generate a unique UUIDv4",
-		},
+	gists, err := gh.logic.GetGists(ctx, lang)
+	if err != nil {
+		abortWithLogicError(c, log, err)
+		return
+	}
+
+	gistsInfo := make([]models.GistInfo, 0, len(gists))
+	for _, gist := range gists {
+		gistsInfo = append(gistsInfo, toGistInfo(gist))
 	}
 
 	c.AbortWithStatusJSON(http.StatusOK, gistsInfo)
@@ -145,11 +146,12 @@ func (gh *gistsHandler) getGists(c *gin.Context) {
 //	@Produce		json
 //	@Success		201	{object}	models.GistInfo	"Gist has been created."
 //	@Failure		400	{array}		models.Error	"Failed to parse JSON request content."
+//	@Failure		400	{array}		models.Error	"The Gist expiration is invalid."
+//	@Failure		422	{array}		models.Error	"The Gist contains credentials or other secrets that must be removed."
 //	@Failure		500	{array}		models.Error	"The service has encountered unexpected error that it was not able to handle."
 //	@Router			/gists [post]
 func (gh *gistsHandler) postGist(c *gin.Context) {
-	defer timer(gh.metrics, "post_gists")()
-	log, _, _, err := helpers.ParseContext(gh.log, c, "postGist")
+	log, ctx, _, err := helpers.ParseContext(gh.log, c, "postGist")
 	if err != nil {
 		helpers.AbortWithError(c, log,
 			http.StatusInternalServerError,
@@ -169,30 +171,38 @@ func (gh *gistsHandler) postGist(c *gin.Context) {
 		return
 	}
 
-	gistInfo := models.GistInfo{
-		Id:          "d17043a0-216c-4c56-9127-b0bf5e3a4c16",
-		Language:    "haskell",
-		Name:        "Convert PNG to JPG",
-		Description: "Convert PNG image to JPG image using pillow library",
+	newGist, err := fromGist(gist)
+	if err != nil {
+		abortWithLogicError(c, log, err)
+		return
+	}
+
+	created, err := gh.logic.CreateGist(ctx, newGist)
+	if err != nil {
+		abortWithLogicError(c, log, err)
+		return
 	}
 
 	// Return result
-	c.AbortWithStatusJSON(http.StatusCreated, gistInfo)
+	c.AbortWithStatusJSON(http.StatusCreated, toGistInfo(created))
 }
 
 // getGist godoc
 //
-//	@Summary	Get the detailed information about the Gist.
-//	@Tags		Gists
-//	@Param		id	path	string	true	"Gist id"
-//	@Produce	json
-//	@Success	200	{object}	models.GistDetails	"The Gist definition has been successfully retrieved."
-//	@Failure	404	{array}		models.Error		"The specified Gist does not exist."
-//	@Failure	500	{array}		models.Error		"The service has encountered unexpected error that it was not able to handle."
-//	@Router		/gists/{id} [get]
+//	@Summary		Get the detailed information about the Gist.
+//	@Description	This method returns the Gist definition as JSON.
+//	@Description	Gists written in markdown could also be requested as a sanitized
+//	@Description	HTML page with a table of contents, using the 'Accept: text/html' header.
+//	@Tags			Gists
+//	@Param			id	path	string	true	"Gist id"
+//	@Produce		json,html
+//	@Success		200	{object}	models.GistDetails	"The Gist definition has been successfully retrieved."
+//	@Failure		404	{array}		models.Error		"The specified Gist does not exist."
+//	@Failure		406	{array}		models.Error		"The requested representation of the resource is not available."
+//	@Failure		500	{array}		models.Error		"The service has encountered unexpected error that it was not able to handle."
+//	@Router			/gists/{id} [get]
 func (gh *gistsHandler) getGist(c *gin.Context) {
-	defer timer(gh.metrics, "get_gist")()
-	log, _, _, err := helpers.ParseContext(gh.log, c, "getGist")
+	log, ctx, _, err := helpers.ParseContext(gh.log, c, "getGist")
 	if err != nil {
 		helpers.AbortWithError(c, log,
 			http.StatusInternalServerError,
@@ -205,37 +215,56 @@ func (gh *gistsHandler) getGist(c *gin.Context) {
 	// Extract argument
 	id := c.Param("id")
 
-	gistDetails := models.GistDetails{
-		Id:           id,
-		Language:     "haskell",
-		Name:         "Convert PNG to JPG",
-		Code:         "for (let i = 0; i < 5; i++) {...}",
-		Description:  "Convert PNG image to JPG image using pillow library",
-		CreatedAt:    "2023-06-07T18:27:25-04:00",
-		LastAccessed: "2023-06-24T08:13:59-04:00",
-		LastUpdated:  "2023-06-11T10:44:17-04:00",
+	// The representation is selected by the 'Accept' header,
+	// JSON is used if the header is missing.
+	switch c.NegotiateFormat(gin.MIMEJSON, gin.MIMEHTML) {
+	case gin.MIMEJSON:
+		gist, err := gh.logic.GetGist(ctx, id)
+		if err != nil {
+			abortWithLogicError(c, log, err)
+			return
+		}
+
+		// Return result
+		c.AbortWithStatusJSON(http.StatusOK, toGistDetails(gist))
+
+	case gin.MIMEHTML:
+		page, err := gh.logic.RenderGist(ctx, id)
+		if err != nil {
+			abortWithLogicError(c, log, err)
+			return
+		}
+
+		// Return result
+		c.Data(http.StatusOK, "text/html; charset=utf-8", page)
+		c.Abort()
+
+	default:
+		helpers.AbortWithError(c, log,
+			http.StatusNotAcceptable,
+			constants.ErrNotAcceptableCode,
+			constants.ErrNotAcceptableMsg)
 	}
-
-	// Return result
-	c.AbortWithStatusJSON(http.StatusOK, gistDetails)
 }
 
 // putGist godoc
 //
 //	@Summary		Create or replace the Gist.
 //	@Description	This method is called to update and store an existing Gist definition.
-//	@Description
+//	@Description	The Gist in the trash could not be replaced, until it is restored.
 //	@Tags		Gists
 //	@Param		id			path	string		true	"Gist id"
 //	@Param		template	body	models.Gist	true	"Gist definition"
 //	@Produce	json
 //	@Success	201	{object}	models.GistInfo	"Gist has been updated."
 //	@Failure	400	{array}		models.Error	"Failed to parse JSON request content."
+//	@Failure	400	{array}		models.Error	"The Gist expiration is invalid."
+//	@Failure	409	{array}		models.Error	"The specified Gist is in the trash, it should be restored first."
+//	@Failure	422	{array}		models.Error	"The Gist contains credentials or other secrets that must be removed."
 //	@Failure	500	{array}		models.Error	"The service has encountered unexpected error that it was not able to handle."
 //	@Router		/gists/{id} [put]
 func (gh *gistsHandler) putGist(c *gin.Context) {
-	defer timer(gh.metrics, "put_gist")()
-	log, _, _, err := helpers.ParseContext(gh.log, c, "putGist")
+	log, ctx, _, err := helpers.ParseContext(gh.log, c, "putGist")
 	if err != nil {
 		helpers.AbortWithError(c, log,
 			http.StatusInternalServerError,
@@ -256,31 +285,36 @@ func (gh *gistsHandler) putGist(c *gin.Context) {
 		return
 	}
 
-	gistInfo := models.GistInfo{
-		Id:          id,
-		Language:    gist.Language,
-		Name:        gist.Name,
-		Description: gist.Description,
+	newGist, err := fromGist(gist)
+	if err != nil {
+		abortWithLogicError(c, log, err)
+		return
+	}
+
+	updated, err := gh.logic.UpdateGist(ctx, id, newGist)
+	if err != nil {
+		abortWithLogicError(c, log, err)
+		return
 	}
 
 	// Return result
-	c.AbortWithStatusJSON(http.StatusCreated, gistInfo)
+	c.AbortWithStatusJSON(http.StatusCreated, toGistInfo(updated))
 }
 
 // deleteGist godoc
 //
 //	@Summary		Delete previously created Gist.
-//	@Description	This method is called to delete an existing Gist definition.
+//	@Description	This method is called to move an existing Gist definition to the trash.
+//	@Description	The Gist could be restored from the trash, until the retention period ends.
 //	@Tags			Gists
 //	@Param			id	path	string	true	"Gist id"
 //	@Produce		json
-//	@Success		204	{object}	models.GistInfo	"Gist has been deleted."
-//	@Failure		404	{array}		models.Error	"The specified Gist does not exist."
-//	@Failure		500	{array}		models.Error	"The service has encountered unexpected error that it was not able to handle."
+//	@Success		204	"Gist has been deleted."
+//	@Failure		404	{array}	models.Error	"The specified Gist does not exist."
+//	@Failure		500	{array}	models.Error	"The service has encountered unexpected error that it was not able to handle."
 //	@Router			/gists/{id} [delete]
 func (gh *gistsHandler) deleteGist(c *gin.Context) {
-	defer timer(gh.metrics, "delete_gist")()
-	log, _, _, err := helpers.ParseContext(gh.log, c, "deleteGist")
+	log, ctx, _, err := helpers.ParseContext(gh.log, c, "deleteGist")
 	if err != nil {
 		helpers.AbortWithError(c, log,
 			http.StatusInternalServerError,
@@ -293,21 +327,144 @@ func (gh *gistsHandler) deleteGist(c *gin.Context) {
 	// Extract argument
 	id := c.Param("id")
 
-	gistInfo := models.GistInfo{
-		Id:          id,
-		Language:    "haskell",
-		Name:        "Convert PNG to JPG",
-		Description: "Convert PNG image to JPG image using pillow library",
+	if err := gh.logic.DeleteGist(ctx, id); err != nil {
+		abortWithLogicError(c, log, err)
+		return
 	}
 
 	// Return result
-	c.AbortWithStatusJSON(http.StatusNoContent, gistInfo)
+	c.AbortWithStatus(http.StatusNoContent)
+}
+
+// abortWithLogicError aborts the request with the error
+// that corresponds to the business logic error.
+func abortWithLogicError(c *gin.Context, log logger.Log, err error) {
+	var secretErr *logic.SecretDetectedError
+
+	switch {
+	case errors.As(err, &secretErr):
+		helpers.AbortWithErrorDetail(c, log,
+			http.StatusUnprocessableEntity,
+			constants.ErrSecretDetectedCode,
+			constants.ErrSecretDetectedMsg,
+			secretErr.Detail())
+
+	case errors.Is(err, logic.ErrGistNotFound):
+		helpers.AbortWithError(c, log,
+			http.StatusNotFound,
+			constants.ErrGistNotFoundCode,
+			constants.ErrGistNotFoundMsg)
+
+	case errors.Is(err, logic.ErrGistTrashed):
+		helpers.AbortWithError(c, log,
+			http.StatusConflict,
+			constants.ErrGistTrashedCode,
+			constants.ErrGistTrashedMsg)
+
+	case errors.Is(err, logic.ErrCollectionNotFound):
+		helpers.AbortWithError(c, log,
+			http.StatusNotFound,
+			constants.ErrCollectionNotFoundCode,
+			constants.ErrCollectionNotFoundMsg)
+
+	case errors.Is(err, logic.ErrCollectionGistNotFound):
+		helpers.AbortWithError(c, log,
+			http.StatusNotFound,
+			constants.ErrCollectionGistNotFoundCode,
+			constants.ErrCollectionGistNotFoundMsg)
+
+	case errors.Is(err, logic.ErrDuplicateCollectionGist):
+		helpers.AbortWithError(c, log,
+			http.StatusBadRequest,
+			constants.ErrDuplicateCollectionGistCode,
+			constants.ErrDuplicateCollectionGistMsg)
+
+	case errors.Is(err, logic.ErrInvalidExpiration):
+		helpers.AbortWithError(c, log,
+			http.StatusBadRequest,
+			constants.ErrInvalidExpirationCode,
+			constants.ErrInvalidExpirationMsg)
+
+	case errors.Is(err, logic.ErrGistNotRenderable):
+		helpers.AbortWithError(c, log,
+			http.StatusNotAcceptable,
+			constants.ErrNotAcceptableCode,
+			constants.ErrNotAcceptableMsg)
+
+	default:
+		log.Error(err, "Failed to handle the request")
+		helpers.AbortWithError(c, log,
+			http.StatusInternalServerError,
+			constants.ErrUnknownErrorCode,
+			constants.ErrUnknownErrorMsg)
+	}
+}
+
+// fromGist converts the API gist definition to the business logic gist.
+//
+// The gist expiration could be specified either as the absolute time
+// or as the time to live, otherwise logic.ErrInvalidExpiration is returned.
+func fromGist(gist models.Gist) (logic.Gist, error) {
+	result := logic.Gist{
+		Name:        gist.Name,
+		Description: gist.Description,
+		Language:    gist.Language,
+		Code:        gist.Code,
+	}
+
+	switch {
+	case gist.ExpiresAt != "" && gist.Ttl != "":
+		return logic.Gist{}, logic.ErrInvalidExpiration
+
+	case gist.ExpiresAt != "":
+		expiresAt, err := time.Parse(time.RFC3339, gist.ExpiresAt)
+		if err != nil {
+			return logic.Gist{}, logic.ErrInvalidExpiration
+		}
+		result.ExpiresAt = expiresAt
+
+	case gist.Ttl != "":
+		ttl, err := time.ParseDuration(gist.Ttl)
+		if err != nil || ttl <= 0 {
+			return logic.Gist{}, logic.ErrInvalidExpiration
+		}
+		result.Ttl = ttl
+	}
+
+	return result, nil
+}
+
+// toGistInfo converts the business logic gist to the API gist info.
+func toGistInfo(gist logic.Gist) models.GistInfo {
+	return models.GistInfo{
+		Id:          gist.Id,
+		Name:        gist.Name,
+		Description: gist.Description,
+		Language:    gist.Language,
+		ExpiresAt:   formatTime(gist.ExpiresAt),
+	}
+}
+
+// toGistDetails converts the business logic gist to the API gist details.
+func toGistDetails(gist logic.Gist) models.GistDetails {
+	return models.GistDetails{
+		Id:           gist.Id,
+		Name:         gist.Name,
+		Description:  gist.Description,
+		Language:     gist.Language,
+		Code:         gist.Code,
+		CreatedAt:    formatTime(gist.CreatedAt),
+		LastUpdated:  formatTime(gist.UpdatedAt),
+		LastAccessed: formatTime(gist.AccessedAt),
+		ExpiresAt:    formatTime(gist.ExpiresAt),
+	}
 }
 
-// timer measures api request processing time
-func timer(reporter ApiMetricsReporter, operation string) func() {
-	start := time.Now()
-	return func() {
-		reporter.ApiRequestProcessed(operation, float64(time.Since(start).Milliseconds()))
+// formatTime formats the time using RFC 3339,
+// the zero time is formatted as an empty string.
+func formatTime(t time.Time) string {
+	if t.IsZero() {
+		return ""
 	}
+	return t.Format(time.RFC3339)
 }
@@ -0,0 +1,158 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"git.lothric.net/examples/go/gogin/internal/app/api/constants"
+	"git.lothric.net/examples/go/gogin/internal/app/api/helpers"
+	"git.lothric.net/examples/go/gogin/internal/app/api/v1/models"
+	"git.lothric.net/examples/go/gogin/internal/pkg/logger"
+)
+
+// trashHandler handles all APIs calls for the 'trash' resource.
+type trashHandler struct {
+	log   logger.Log
+	logic GistsLogic
+}
+
+// NewTrashHandler creates a new instance of the API handler
+// that handles all requests to 'trash' resource.
+func NewTrashHandler(
+	log logger.Log,
+	logic GistsLogic,
+) (*trashHandler, error) {
+
+	th := &trashHandler{
+		log:   log.WithField(logger.FieldPackage, pkg),
+		logic: logic,
+	}
+
+	return th, nil
+}
+
+// AttachTo attaches the trashHandler to the
+// provided parent router group.
+func (th *trashHandler) AttachTo(g *gin.RouterGroup) error {
+
+	// GET /api/trash
+	g.GET("", th.getTrash)
+
+	// POST /api/trash/{id}/restore
+	g.POST(":id/restore", th.postRestore)
+
+	// DELETE /api/trash/{id}
+	g.DELETE(":id", th.deleteTrashed)
+
+	return nil
+}
+
+// getTrash godoc
+//
+//	@Summary		Get the list of Gists in the trash.
+//	@Description	This method returns the deleted Gists, the most recently deleted first.
+//	@Description	The Gists stay in the trash until they are restored or the retention period ends.
+//	@Tags			Trash
+//	@Produce		json
+//	@Success		200	{array}	models.TrashedGist	"The list of trashed Gists has been successfully retrieved."
+//	@Failure		500	{array}	models.Error		"The service has encountered unexpected error that it was not able to handle."
+//	@Router			/trash [get]
+func (th *trashHandler) getTrash(c *gin.Context) {
+	log, ctx, _, err := helpers.ParseContext(th.log, c, "getTrash")
+	if err != nil {
+		helpers.AbortWithError(c, log,
+			http.StatusInternalServerError,
+			constants.ErrUnknownErrorCode,
+			constants.ErrUnknownErrorMsg)
+		return
+	}
+	log.Info("Handling getTrash")
+
+	gists, err := th.logic.GetTrash(ctx)
+	if err != nil {
+		abortWithLogicError(c, log, err)
+		return
+	}
+
+	trashed := make([]models.TrashedGist, 0, len(gists))
+	for _, gist := range gists {
+		trashed = append(trashed, models.TrashedGist{
+			GistInfo:  toGistInfo(gist),
+			DeletedAt: formatTime(gist.DeletedAt),
+		})
+	}
+
+	// Return result
+	c.AbortWithStatusJSON(http.StatusOK, trashed)
+}
+
+// postRestore godoc
+//
+//	@Summary		Restore the Gist from the trash.
+//	@Description	This method is called to restore a previously deleted Gist.
+//	@Tags			Trash
+//	@Param			id	path	string	true	"Gist id"
+//	@Produce		json
+//	@Success		200	{object}	models.GistInfo	"Gist has been restored."
+//	@Failure		404	{array}		models.Error	"The specified Gist does not exist."
+//	@Failure		500	{array}		models.Error	"The service has encountered unexpected error that it was not able to handle."
+//	@Router			/trash/{id}/restore [post]
+func (th *trashHandler) postRestore(c *gin.Context) {
+	log, ctx, _, err := helpers.ParseContext(th.log, c, "postRestore")
+	if err != nil {
+		helpers.AbortWithError(c, log,
+			http.StatusInternalServerError,
+			constants.ErrUnknownErrorCode,
+			constants.ErrUnknownErrorMsg)
+		return
+	}
+	log.Info("Handling postRestore")
+
+	// Extract argument
+	id := c.Param("id")
+
+	restored, err := th.logic.RestoreGist(ctx, id)
+	if err != nil {
+		abortWithLogicError(c, log, err)
+		return
+	}
+
+	// Return result
+	c.AbortWithStatusJSON(http.StatusOK, toGistInfo(restored))
+}
+
+// deleteTrashed godoc
+//
+//	@Summary		Permanently delete the Gist from the trash.
+//	@Description	This method is called to purge a previously deleted Gist.
+//	@Description	The purged Gist could not be restored.
+//	@Tags			Trash
+//	@Param			id	path	string	true	"Gist id"
+//	@Produce		json
+//	@Success		204	"Gist has been purged."
+//	@Failure		404	{array}	models.Error	"The specified Gist does not exist."
+//	@Failure		500	{array}	models.Error	"The service has encountered unexpected error that it was not able to handle."
+//	@Router			/trash/{id} [delete]
+func (th *trashHandler) deleteTrashed(c *gin.Context) {
+	log, ctx, _, err := helpers.ParseContext(th.log, c, "deleteTrashed")
+	if err != nil {
+		helpers.AbortWithError(c, log,
+			http.StatusInternalServerError,
+			constants.ErrUnknownErrorCode,
+			constants.ErrUnknownErrorMsg)
+		return
+	}
+	log.Info("Handling deleteTrashed")
+
+	// Extract argument
+	id := c.Param("id")
+
+	if err := th.logic.PurgeGist(ctx, id); err != nil {
+		abortWithLogicError(c, log, err)
+		return
+	}
+
+	// Return result
+	c.AbortWithStatus(http.StatusNoContent)
+}
@@ -0,0 +1,66 @@
+package models
+
+// SweepResult is a result of the expired gists sweep.
+//
+//	@Description	SweepResult lists the expired Gists that have been deleted,
+//	@Description	or would be deleted in case of the dry run.
+type SweepResult struct {
+
+	// DryRun is true if the gists have not been actually deleted.
+	DryRun bool `json:"dryRun" example:"true"`
+
+	// Gists are the expired gists.
+	Gists []GistInfo `json:"gists" binding:"required"`
+}
+
+// SloReport is the current state of the service level objectives.
+//
+//	@Description	SloReport lists the service level objectives of the API routes
+//	@Description	with the error budget consumed within the rolling window.
+type SloReport struct {
+
+	// Window is the rolling window the error budget is calculated for.
+	Window string `json:"window" example:"1h0m0s"`
+
+	// BurnWindow is the rolling window the burn rate is calculated for.
+	BurnWindow string `json:"burnWindow" example:"5m0s"`
+
+	// Objectives are the states of the objectives ordered by the route.
+	Objectives []SloStatus `json:"objectives" binding:"required"`
+}
+
+// SloStatus is the current state of the service level objective of the route.
+type SloStatus struct {
+
+	// Route is the route template.
+	Route string `json:"route" example:"/api/gists/:id"`
+
+	// Objective is either 'availability' or 'latency'.
+	Objective string `json:"objective" example:"latency" enums:"availability,latency"`
+
+	// Target is the ratio of the good requests.
+	Target float64 `json:"target" example:"0.99"`
+
+	// Threshold is the latency threshold in seconds of the 'latency' objective.
+	Threshold float64 `json:"threshold,omitempty" example:"0.1"`
+
+	// Requests is the number of requests within the window.
+	Requests float64 `json:"requests" example:"2000"`
+
+	// Errors is the number of failed or slow requests within the window.
+	Errors float64 `json:"errors" example:"5"`
+
+	// ErrorRate is the ratio of the errors within the window.
+	ErrorRate float64 `json:"errorRate" example:"0.0025"`
+
+	// BurnRate is the error budget burn rate within the burn window,
+	// the rate of 1 consumes exactly the whole budget in the window.
+	BurnRate float64 `json:"burnRate" example:"0.5"`
+
+	// BudgetConsumed is the ratio of the error budget consumed within the window.
+	BudgetConsumed float64 `json:"budgetConsumed" example:"0.25"`
+
+	// BudgetRemaining is the ratio of the error budget left within the window,
+	// it is negative if the objective is violated.
+	BudgetRemaining float64 `json:"budgetRemaining" example:"0.75"`
+}
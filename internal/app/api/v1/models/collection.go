@@ -0,0 +1,72 @@
+package models
+
+// Collection is a declaration of a new gists collection.
+//
+//	@Description	Collection is a definition of a curated group of Gists.
+type Collection struct {
+
+	// Name is a human readable Collection name.
+	Name string `json:"name" binding:"required" example:"Unique IDs"`
+
+	// Description is a human readable Collection description.
+	Description string `json:"description" example:"Different ways to generate a unique ID."`
+}
+
+// CollectionRename is a new name of the collection.
+//
+//	@Description	CollectionRename defines the new name of the Collection.
+type CollectionRename struct {
+
+	// Name is a human readable Collection name.
+	Name string `json:"name" binding:"required" example:"Unique IDs"`
+}
+
+// CollectionInfo provides the information about the Collection.
+//
+//	@Description	CollectionInfo provides the descriptive information about the
+//	@Description	Collection and the ids of the member Gists in the collection order.
+type CollectionInfo struct {
+	// Id is a globally unique Collection ID that identifies this Collection.
+	Id string `json:"id" binding:"required" example:"6b1c4a47-4c47-4ff6-a1b6-7fcd6f1c3a5e"`
+
+	// Name is a human readable Collection name.
+	Name string `json:"name" binding:"required" example:"Unique IDs"`
+
+	// Description is a human readable Collection description.
+	Description string `json:"description" example:"Different ways to generate a unique ID."`
+
+	// GistIds are the ids of the member Gists in the collection order.
+	GistIds []string `json:"gistIds" binding:"required" example:"d17043a0-216c-4c56-9127-b0bf5e3a4c16"`
+
+	// CreatedAt defines the date and time when the collection has been created.
+	// This field uses RFC 3339 as the standard for the date-time format.
+	CreatedAt string `json:"createdAt" binding:"required" example:"2023-06-07T18:27:25-04:00"`
+
+	// LastUpdated defines the date and time when the collection has been updated.
+	// This field uses RFC 3339 as the standard for the date-time format.
+	LastUpdated string `json:"lastUpdated,omitempty" example:"2023-06-11T10:44:17-04:00"`
+}
+
+// CollectionGist is a gist added to the collection.
+//
+//	@Description	CollectionGist defines the Gist that is added to the Collection
+//	@Description	and its position in the collection order.
+type CollectionGist struct {
+
+	// Id is the id of the Gist.
+	Id string `json:"id" binding:"required" example:"d17043a0-216c-4c56-9127-b0bf5e3a4c16"`
+
+	// Position is the zero-based position of the Gist in the collection.
+	// The Gist is appended to the end, if the position is not specified.
+	Position *int `json:"position,omitempty" example:"0"`
+}
+
+// CollectionOrder is the new order of the collection gists.
+//
+//	@Description	CollectionOrder defines all the member Gists of the Collection
+//	@Description	in the new order. The Gists that are not listed are removed.
+type CollectionOrder struct {
+
+	// GistIds are the ids of the member Gists in the new order.
+	GistIds []string `json:"gistIds" binding:"required" example:"d17043a0-216c-4c56-9127-b0bf5e3a4c16"`
+}
@@ -16,6 +16,15 @@ type Gist struct {
 
 	// Code is a Source Code gits.
 	Code string `json:"code" binding:"required" example:"for (let i = 0; i < 5; i++) {...}"`
+
+	// ExpiresAt defines the date and time when the gist expires and is deleted.
+	// This field uses RFC 3339 as the standard for the date-time format.
+	ExpiresAt string `json:"expiresAt,omitempty" example:"2023-07-01T00:00:00Z"`
+
+	// Ttl defines the time to live of the gist, after which the gist expires.
+	// This field uses Go duration format, for example "30m" or "72h".
+	// Only one of 'expiresAt' and 'ttl' could be specified.
+	Ttl string `json:"ttl,omitempty" example:"72h"`
 }
 
 // GistInfo provides a high-level information about the Gist.
@@ -34,6 +43,10 @@ type GistInfo struct {
 
 	// Language is a programming language that is used in the gist.
 	Language string `json:"language" binding:"required" example:"javascript"`
+
+	// ExpiresAt defines the date and time when the gist expires.
+	// This field uses RFC 3339 as the standard for the date-time format.
+	ExpiresAt string `json:"expiresAt,omitempty" example:"2023-07-01T00:00:00Z"`
 }
 
 // GistDetails provided the detailed information about Gist entry.
@@ -72,4 +85,20 @@ type GistDetails struct {
 	//
 	// This field uses RFC 3339 as the standard for the date-time format.
 	LastAccessed string `json:"lastAccessed,omitempty" example:"2023-06-24T08:13:59-04:00"`
+
+	// ExpiresAt defines the date and time when the gist expires.
+	// This field uses RFC 3339 as the standard for the date-time format.
+	ExpiresAt string `json:"expiresAt,omitempty" example:"2023-07-01T00:00:00Z"`
+}
+
+// TrashedGist provides a high-level information about the Gist in the trash.
+//
+//	@Description	TrashedGist provides the descriptive information about
+//	@Description	the Gist that has been moved to the trash.
+type TrashedGist struct {
+	GistInfo
+
+	// DeletedAt defines the date and time when the gist has been moved to the trash.
+	// This field uses RFC 3339 as the standard for the date-time format.
+	DeletedAt string `json:"deletedAt" binding:"required" example:"2023-06-24T08:13:59-04:00"`
 }
@@ -11,6 +11,12 @@ import (
 const (
 	// gistsRoute is the parent route for gists
 	gistsRoute = "gists"
+
+	// trashRoute is the parent route for the trashed gists
+	trashRoute = "trash"
+
+	// collectionsRoute is the parent route for the gist collections
+	collectionsRoute = "collections"
 )
 
 var (
@@ -19,6 +25,12 @@ var (
 
 	// ErrNoGistsHandlerProvided happens when Gists Handler is not provided.
 	ErrNoGistsHandlerProvided = errors.New("no gists handler provided")
+
+	// ErrNoTrashHandlerProvided happens when Trash Handler is not provided.
+	ErrNoTrashHandlerProvided = errors.New("no trash handler provided")
+
+	// ErrNoCollectionsHandlerProvided happens when Collections Handler is not provided.
+	ErrNoCollectionsHandlerProvided = errors.New("no collections handler provided")
 )
 
 // PathHandler defines an API Handler that could attach
@@ -33,8 +45,10 @@ type PathHandler interface {
 // v1Router is a v1 API root-level path handler, that constructs all underlying
 // API groups that constitute v1 API groups.
 type v1Router struct {
-	log          logger.Log
-	gistsHandler PathHandler
+	log                logger.Log
+	gistsHandler       PathHandler
+	trashHandler       PathHandler
+	collectionsHandler PathHandler
 }
 
 // NewV1PathHandler creates a new API v1 root level
@@ -42,6 +56,8 @@ type v1Router struct {
 func NewV1Router(
 	log logger.Log,
 	gistsHandler PathHandler,
+	trashHandler PathHandler,
+	collectionsHandler PathHandler,
 ) (PathHandler, error) {
 
 	if log == nil {
@@ -52,9 +68,19 @@ func NewV1Router(
 		return nil, ErrNoGistsHandlerProvided
 	}
 
+	if trashHandler == nil {
+		return nil, ErrNoTrashHandlerProvided
+	}
+
+	if collectionsHandler == nil {
+		return nil, ErrNoCollectionsHandlerProvided
+	}
+
 	return &v1Router{
-		log:          log.WithField(logger.FieldPackage, "v1"),
-		gistsHandler: gistsHandler,
+		log:                log.WithField(logger.FieldPackage, "v1"),
+		gistsHandler:       gistsHandler,
+		trashHandler:       trashHandler,
+		collectionsHandler: collectionsHandler,
 	}, nil
 }
 
@@ -68,6 +94,16 @@ func (p *v1Router) AttachTo(g *gin.RouterGroup) error {
 	gistsGroup := g.Group(gistsRoute)
 	p.gistsHandler.AttachTo(gistsGroup)
 
+	// ------------
+	// Attach trash handler to the parent API group.
+	trashGroup := g.Group(trashRoute)
+	p.trashHandler.AttachTo(trashGroup)
+
+	// ------------
+	// Attach collections handler to the parent API group.
+	collectionsGroup := g.Group(collectionsRoute)
+	p.collectionsHandler.AttachTo(collectionsGroup)
+
 	// ------------
 	// Note: Attach more handlers here
 	// ------------
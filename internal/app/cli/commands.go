@@ -0,0 +1,207 @@
+package cli
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+	"github.com/spf13/viper"
+
+	"git.lothric.net/examples/go/gogin/internal/pkg/admin"
+)
+
+const (
+
+	// printEffective is the flag of the print command, that prints all the values
+	printEffective = "effective"
+
+	// Sources of the configuration values, in the order of precedence
+	sourceFlag    = "flag"
+	sourceEnv     = "env"
+	sourceEnvFile = "env file"
+	sourceFile    = "file"
+	sourceDefault = "default"
+)
+
+// configCommand creates the command, that inspects
+// the configuration without running the service.
+func (c *cli) configCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "config",
+		Short: "Inspect the configuration without running the service.",
+	}
+
+	validate := &cobra.Command{
+		Use:   "validate",
+		Short: "Validate the configuration from flags, environment and config file.",
+		Args:  cobra.NoArgs,
+		RunE:  c.validate,
+	}
+
+	printCmd := &cobra.Command{
+		Use:   "print",
+		Short: "Print the configuration values, that are not defaults, with the secrets redacted.",
+		Args:  cobra.NoArgs,
+		RunE:  c.print,
+	}
+	printCmd.Flags().Bool(printEffective, false, "Print all the values, including defaults, with their source: flag, env, file or default.")
+
+	schema := &cobra.Command{
+		Use:   "schema",
+		Short: "Print JSON Schema of the config file.",
+		Args:  cobra.NoArgs,
+		RunE:  c.schema,
+	}
+
+	docsCmd := &cobra.Command{
+		Use:   "docs",
+		Short: "Print markdown reference of the configuration options.",
+		Args:  cobra.NoArgs,
+		RunE:  c.docs,
+	}
+
+	cmd.AddCommand(validate, printCmd, schema, docsCmd)
+	return cmd
+}
+
+// validate validates the configuration and reports all the invalid values.
+func (c *cli) validate(cmd *cobra.Command, args []string) error {
+	cmd.SilenceUsage = true
+	cmd.SilenceErrors = true
+
+	if err := c.loadConfig(cmd); err != nil {
+		return err
+	}
+
+	if err := validateConfig(); err != nil {
+		fmt.Fprintln(cmd.OutOrStdout(), err.Error())
+		return err
+	}
+
+	fmt.Fprintln(cmd.OutOrStdout(), "Configuration is valid.")
+	return nil
+}
+
+// print prints the configuration values with their sources.
+func (c *cli) print(cmd *cobra.Command, args []string) error {
+	cmd.SilenceUsage = true
+
+	effective, err := cmd.Flags().GetBool(printEffective)
+	if err != nil {
+		return err
+	}
+
+	if err := c.loadConfig(cmd); err != nil {
+		return err
+	}
+
+	schema := configSchema()
+	keys := make([]string, 0, len(schema))
+	for key := range schema {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	w := tabwriter.NewWriter(cmd.OutOrStdout(), 0, 4, 2, ' ', 0)
+	fmt.Fprintln(w, "KEY\tVALUE\tSOURCE")
+
+	for _, key := range keys {
+		source := keySource(cmd.Flags(), key)
+		if source == sourceDefault && !effective {
+			continue
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\n", key, formatValue(schema[key], viper.Get(key)), source)
+	}
+
+	return w.Flush()
+}
+
+// schema prints JSON Schema of the config file.
+func (c *cli) schema(cmd *cobra.Command, args []string) error {
+	content, err := json.MarshalIndent(jsonSchema(configOptions()), "", "  ")
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprintln(cmd.OutOrStdout(), string(content))
+	return nil
+}
+
+// docs prints markdown reference of the configuration options.
+func (c *cli) docs(cmd *cobra.Command, args []string) error {
+	fmt.Fprint(cmd.OutOrStdout(), docs(configOptions()))
+	return nil
+}
+
+// keySource returns the source the value of the 'key' is taken from.
+func keySource(flags *pflag.FlagSet, key string) string {
+	if flag := flags.Lookup(key); flag != nil && flag.Changed {
+		return sourceFlag
+	}
+
+	for _, env := range envKeys[key] {
+		if os.Getenv(env) != "" {
+			return sourceEnv
+		}
+	}
+
+	// The secret file of the '*_FILE' variable is bound as the file reference
+	if strings.HasPrefix(viper.GetString(key), secretFileScheme) {
+		for _, env := range envFileNames(envKeys[key]) {
+			if os.Getenv(env) != "" {
+				return sourceEnvFile
+			}
+		}
+	}
+
+	if viper.InConfig(key) {
+		return sourceFile
+	}
+
+	return sourceDefault
+}
+
+// formatValue formats the 'value' of the key for printing, the secrets
+// and the credentials of the URLs are redacted.
+func formatValue(s keySchema, value interface{}) string {
+	if value == nil {
+		return ""
+	}
+
+	parsed, err := s.parse(value)
+	if err != nil {
+		parsed = value
+	}
+
+	if s.Secret {
+		if fmt.Sprint(parsed) == "" {
+			return ""
+		}
+		return admin.Redacted
+	}
+
+	switch v := admin.Redact(parsed).(type) {
+	case []interface{}:
+		items := make([]string, len(v))
+		for i, item := range v {
+			items[i] = fmt.Sprint(item)
+		}
+		return strings.Join(items, ",")
+
+	case map[string]interface{}:
+		entries := make([]string, 0, len(v))
+		for key, value := range v {
+			entries = append(entries, fmt.Sprintf("%s=%v", key, value))
+		}
+		sort.Strings(entries)
+		return strings.Join(entries, ",")
+
+	default:
+		return fmt.Sprint(v)
+	}
+}
@@ -8,6 +8,9 @@ import (
 
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
+
+	"git.lothric.net/examples/go/gogin/internal/pkg/logger"
+	"git.lothric.net/examples/go/gogin/internal/pkg/tlsconfig"
 )
 
 const (
@@ -17,23 +20,105 @@ const (
 	// Common
 	configFile = "config"
 	nodeName   = "node.name"
+	envPrefix  = "env.prefix"
 
 	// HTTP & Gin
 	httpPort = "http.port"
 	ginMode  = "http.gin.mode"
 
+	// Lifecycle
+	shutdownDrainTimeout = "shutdown.drain-timeout"
+	shutdownDrainDelay   = "shutdown.drain-delay"
+
+	// Configuration reload
+	reloadWatch = "reload.watch"
+
+	// Admin
+	adminAddr  = "admin.addr"
+	adminToken = "admin.token"
+
+	// TLS of the listeners, the keys are prefixed with the listener name
+	tlsCert           = "tls.cert"
+	tlsKey            = "tls.key"
+	tlsClientCA       = "tls.client-ca"
+	tlsMinVersion     = "tls.min-version"
+	tlsCipherSuites   = "tls.cipher-suites"
+	tlsReloadInterval = "tls.reload-interval"
+
+	// Listeners that support TLS
+	tlsListenerHttp    = "http"
+	tlsListenerMetrics = "metrics"
+	tlsListenerStatus  = "status"
+	tlsListenerAdmin   = "admin"
+
 	// Logger
-	logLevel     = "log.level"
-	logFormatter = "log.formatter"
+	logLevel          = "log.level"
+	logFormatter      = "log.formatter"
+	logFieldNames     = "log.field-names"
+	logGcpProject     = "log.gcp.project"
+	logOutputs        = "log.outputs"
+	logStdoutLevel    = "log.stdout.level"
+	logStderrLevel    = "log.stderr.level"
+	logFileLevel      = "log.file.level"
+	logFilePath       = "log.file.path"
+	logFileMaxSize    = "log.file.max-size"
+	logFileMaxAge     = "log.file.max-age"
+	logFileMaxBackups = "log.file.max-backups"
+	logFileCompress   = "log.file.compress"
+	logSyslogLevel    = "log.syslog.level"
+	logSyslogNetwork  = "log.syslog.network"
+	logSyslogAddr     = "log.syslog.addr"
+	logSyslogTag      = "log.syslog.tag"
+	logRedactFields   = "log.redact.fields"
+	logRedactPatterns = "log.redact.patterns"
+	logRedactAllow    = "log.redact.allow"
 
 	// Health check
-	statusRpcAddr = "status.rpc.addr"
+	statusRpcAddr       = "status.rpc.addr"
+	statusCheckInterval = "status.check.interval"
+	statusCheckTimeout  = "status.check.timeout"
 
 	// Metrics
 	metricsPrometheusAddr = "metrics.prometheus.addr"
 	metricsPrometheusPath = "metrics.prometheus.path"
+	metricsBuckets        = "metrics.buckets"
+	metricsObjectives     = "metrics.objectives"
+	metricsNativeFactor   = "metrics.histograms.native-factor"
+	metricsPushUrl        = "metrics.push.url"
+	metricsPushInterval   = "metrics.push.interval"
+
+	// Tracing
+	tracingExporter     = "tracing.exporter"
+	tracingOtlpEndpoint = "tracing.otlp.endpoint"
+	tracingOtlpInsecure = "tracing.otlp.insecure"
+	tracingFilePath     = "tracing.file.path"
+	tracingSampleRatio  = "tracing.sample-ratio"
+
+	// Service level objectives
+	sloAvailability = "slo.availability"
+	sloLatency      = "slo.latency"
+	sloWindow       = "slo.window"
+	sloBurnWindow   = "slo.burn-window"
+	sloInterval     = "slo.interval"
+
+	// Secrets
+	secretsPolicy = "secrets.policy"
+	secretsRules  = "secrets.rules"
+
+	// Gists
+	gistsSweeperInterval  = "gists.sweeper.interval"
+	gistsSweeperBatchSize = "gists.sweeper.batch"
+	gistsTrashRetention   = "gists.trash.retention"
+	gistsTrashInterval    = "gists.trash.interval"
+	gistsTrashBatchSize   = "gists.trash.batch"
 )
 
+// tlsListeners are the listeners that support TLS
+var tlsListeners = []string{tlsListenerHttp, tlsListenerMetrics, tlsListenerStatus, tlsListenerAdmin}
+
+// envKeys are the environment variables bound to the configuration keys
+var envKeys = map[string][]string{}
+
 // cli
 type cli struct {
 	cfg appConfig
@@ -53,41 +138,59 @@ func NewCli() (*cobra.Command, error) {
 		return nil, err
 	}
 
+	cmd.AddCommand(cli.configCommand())
+
 	return cmd, nil
 }
 
-// setupFlags configures the acceptable command line arguments
+// setupFlags configures the acceptable command line arguments,
+// that are generated from the configuration options
 func setupFlags(cmd *cobra.Command) error {
-	hostname, err := os.Hostname()
-	if err != nil {
-		log.Fatal(err)
+
+	// The flags are shared by the subcommands
+	flags := cmd.PersistentFlags()
+
+	// The config file is not a configuration key itself
+	flags.String(configFile, "", "Path to config file.")
+
+	for _, o := range configOptions() {
+		o.addFlag(flags)
 	}
 
-	// Common
-	cmd.Flags().String(configFile, "", "Path to config file.")
-	cmd.Flags().String(nodeName, hostname, "Unique server ID.")
+	return viper.BindPFlags(flags)
+}
 
-	// HTTP & Gin
-	cmd.Flags().String(httpPort, "8080", "HTTP API port.")
-	cmd.Flags().String(ginMode, "release", "Gin mode.")
+// setupConfig reads the config file (if any), binds the environment
+// variables to the viper keys, validates and creates the application configuration
+func (c *cli) setupConfig(cmd *cobra.Command, args []string) error {
 
-	// Log
-	cmd.Flags().String(logLevel, "info", "Log level.")
-	cmd.Flags().String(logFormatter, "json", "Log formatter.")
+	// The flags are parsed already, so the usage doesn't help
+	// with the errors below, and they are reported here
+	cmd.SilenceUsage = true
+	cmd.SilenceErrors = true
 
-	// Status
-	cmd.Flags().String(statusRpcAddr, ":8400", "Rpc address of status server.")
+	if err := c.loadConfig(cmd); err != nil {
+		return err
+	}
 
-	// Metrics
-	cmd.Flags().String(metricsPrometheusAddr, ":8880", "HTTP address of prometheus metrics endpoint.")
-	cmd.Flags().String(metricsPrometheusPath, "/metrics", "HTTP URL endpoint of prometheus metrics endpoint.")
+	// Validate all the values at once, so all the errors are reported
+	if err := validateConfig(); err != nil {
+		fmt.Printf("Failed to validate application configuration: %s \n", err.Error())
+		return err
+	}
+
+	// Create and initialize application configuration
+	if err := createConfig(&c.cfg); err != nil {
+		fmt.Printf("Failed to create application configuration: %s \n", err.Error())
+		return err
+	}
 
-	return viper.BindPFlags(cmd.Flags())
+	return nil
 }
 
-// setupConfig reads the config file (if any), binds the environment
-// variables to the viper keys and creates the application configuration
-func (c *cli) setupConfig(cmd *cobra.Command, args []string) error {
+// loadConfig reads the config file (if any) and binds the environment
+// variables to the viper keys, the flags are bound already
+func (c *cli) loadConfig(cmd *cobra.Command) error {
 
 	// Try to load config from file
 	if err := c.loadConfigFile(cmd); err != nil {
@@ -100,12 +203,7 @@ func (c *cli) setupConfig(cmd *cobra.Command, args []string) error {
 		fmt.Printf("Failed to bind environment variables: %s \n", err.Error())
 		return err
 	}
-
-	// Create and initialize application configuration
-	if err := c.createConfig(); err != nil {
-		fmt.Printf("Failed to create application configuration: %s \n", err.Error())
-		return err
-	}
+	bindSecretFiles(cmd.Flags())
 
 	return nil
 }
@@ -139,63 +237,188 @@ func (c *cli) loadConfigFile(cmd *cobra.Command) error {
 		return err
 	}
 
-	// We can enable watchdog for config, if required:
-	// viper.WatchConfig()
-
 	return nil
 }
 
-// bindEnv binds configuration keys to environment variables.
+// bindEnv binds configuration keys to environment variables,
+// that are generated from the configuration options.
 func (c *cli) bindEnv() error {
+	options := configOptions()
 
-	// HTTP & Gin
-	viper.BindEnv(httpPort, "HTTP_PORT")
-	viper.BindEnv(ginMode, "HTTP_GIN_MODE")
-
-	// Log
-	viper.BindEnv(logLevel, "LOG_LEVEL")
-	viper.BindEnv(logFormatter, "LOG_FORMATTER")
-
-	// Status
-	viper.BindEnv(statusRpcAddr, "STATUS_RPC_ADDR")
+	// The prefix is bound first, so it could be set by the environment as well
+	for _, o := range options {
+		if o.Key == envPrefix {
+			o.bindEnv("")
+		}
+	}
+	prefix := viper.GetString(envPrefix)
 
-	// Metrics
-	viper.BindEnv(metricsPrometheusAddr, "METRICS_PROMETHEUS_ADDR")
-	viper.BindEnv(metricsPrometheusPath, "METRICS_PROMETHEUS_PATH")
+	for _, o := range options {
+		envKeys[o.Key] = o.envNames(prefix)
+		o.bindEnv(prefix)
+	}
 
 	return nil
 }
 
 // createConfig initializes application configuration
-func (c *cli) createConfig() error {
-	config := &c.cfg
+func createConfig(config *appConfig) error {
 
 	// Generic
 	config.NodeName = viper.GetString(nodeName)
+	if config.NodeName == "" {
+		hostname, err := os.Hostname()
+		if err != nil {
+			return err
+		}
+		config.NodeName = hostname
+	}
 	config.ServiceName = serviceName
 
 	// HTTP & Gin
 	httpConfig := &config.Http
 	httpConfig.HttpPort = viper.GetUint16(httpPort)
 	httpConfig.GinMode = viper.GetString(ginMode)
+	httpConfig.TLS = createTLSConfig(tlsListenerHttp)
+
+	// Lifecycle
+	lifecycleConfig := &config.Lifecycle
+	lifecycleConfig.DrainTimeout = viper.GetDuration(shutdownDrainTimeout)
+	lifecycleConfig.DrainDelay = viper.GetDuration(shutdownDrainDelay)
+
+	// Configuration reload
+	reloadConfig := &config.Reload
+	reloadConfig.Watch = viper.GetBool(reloadWatch)
+
+	// Admin
+	adminConfig := &config.Admin
+	adminConfig.Addr = viper.GetString(adminAddr)
+	token, err := secretValue(adminToken)
+	if err != nil {
+		return err
+	}
+	adminConfig.Token = token
+	adminConfig.TLS = createTLSConfig(tlsListenerAdmin)
 
 	// Log
 	logConfig := &config.Log
 	logConfig.Level = viper.GetString(logLevel)
 	logConfig.Formatter = viper.GetString(logFormatter)
+	logConfig.FieldNames = mapValue(logFieldNames)
+	logConfig.GcpProject = viper.GetString(logGcpProject)
+	logConfig.Sinks = createLogSinks()
+	logConfig.Redact = createLogRedact()
 
 	// Status
 	statusConfig := &config.Status
 	statusConfig.RpcAddr = viper.GetString(statusRpcAddr)
+	statusConfig.CheckInterval = viper.GetDuration(statusCheckInterval)
+	statusConfig.CheckTimeout = viper.GetDuration(statusCheckTimeout)
+	statusConfig.TLS = createTLSConfig(tlsListenerStatus)
 
 	// Metrics
 	metricsConfig := &config.Metrics
 	metricsConfig.Addr = viper.GetString(metricsPrometheusAddr)
 	metricsConfig.Path = viper.GetString(metricsPrometheusPath)
+	metricsConfig.Buckets = mapValue(metricsBuckets)
+	metricsConfig.Objectives = mapValue(metricsObjectives)
+	metricsConfig.NativeHistogramFactor = viper.GetFloat64(metricsNativeFactor)
+	metricsConfig.PushUrl = viper.GetString(metricsPushUrl)
+	metricsConfig.PushInterval = viper.GetDuration(metricsPushInterval)
+	metricsConfig.TLS = createTLSConfig(tlsListenerMetrics)
+
+	// Tracing
+	tracingConfig := &config.Tracing
+	tracingConfig.Exporter = viper.GetString(tracingExporter)
+	tracingConfig.Endpoint = viper.GetString(tracingOtlpEndpoint)
+	tracingConfig.Insecure = viper.GetBool(tracingOtlpInsecure)
+	tracingConfig.File = viper.GetString(tracingFilePath)
+	tracingConfig.SampleRatio = viper.GetFloat64(tracingSampleRatio)
+
+	// Service level objectives
+	sloConfig := &config.Slo
+	sloConfig.Availability = mapValue(sloAvailability)
+	sloConfig.Latency = mapValue(sloLatency)
+	sloConfig.Window = viper.GetDuration(sloWindow)
+	sloConfig.BurnWindow = viper.GetDuration(sloBurnWindow)
+	sloConfig.Interval = viper.GetDuration(sloInterval)
+
+	// Secrets
+	secretsConfig := &config.Secrets
+	secretsConfig.Policy = viper.GetString(secretsPolicy)
+	secretsConfig.Rules = mapValue(secretsRules)
+
+	// Gists
+	sweeperConfig := &config.Sweeper
+	sweeperConfig.Interval = viper.GetDuration(gistsSweeperInterval)
+	sweeperConfig.BatchSize = viper.GetInt(gistsSweeperBatchSize)
+
+	trashConfig := &config.Trash
+	trashConfig.Retention = viper.GetDuration(gistsTrashRetention)
+	trashConfig.Interval = viper.GetDuration(gistsTrashInterval)
+	trashConfig.BatchSize = viper.GetInt(gistsTrashBatchSize)
 
 	return nil
 }
 
+// createLogRedact creates the configuration of the log redaction.
+func createLogRedact() logger.RedactConfig {
+	return logger.RedactConfig{
+		Fields:   listValue(logRedactFields),
+		Patterns: mapValue(logRedactPatterns),
+		Allow:    listValue(logRedactAllow),
+	}
+}
+
+// createLogSinks creates the configuration of the log outputs in their order.
+func createLogSinks() []logger.SinkConfig {
+	var sinks []logger.SinkConfig
+	for _, output := range listValue(logOutputs) {
+		sink := logger.SinkConfig{Type: output}
+
+		switch output {
+		case logger.SinkStdout:
+			sink.Level = viper.GetString(logStdoutLevel)
+
+		case logger.SinkStderr:
+			sink.Level = viper.GetString(logStderrLevel)
+
+		case logger.SinkFile:
+			sink.Level = viper.GetString(logFileLevel)
+			sink.File = logger.FileConfig{
+				Path:       viper.GetString(logFilePath),
+				MaxSize:    viper.GetInt(logFileMaxSize),
+				MaxAge:     viper.GetDuration(logFileMaxAge),
+				MaxBackups: viper.GetInt(logFileMaxBackups),
+				Compress:   viper.GetBool(logFileCompress),
+			}
+
+		case logger.SinkSyslog:
+			sink.Level = viper.GetString(logSyslogLevel)
+			sink.Syslog = logger.SyslogConfig{
+				Network: viper.GetString(logSyslogNetwork),
+				Addr:    viper.GetString(logSyslogAddr),
+				Tag:     viper.GetString(logSyslogTag),
+			}
+		}
+
+		sinks = append(sinks, sink)
+	}
+	return sinks
+}
+
+// createTLSConfig creates TLS configuration of the 'listener'
+func createTLSConfig(listener string) tlsconfig.Config {
+	return tlsconfig.Config{
+		CertFile:       viper.GetString(listener + "." + tlsCert),
+		KeyFile:        viper.GetString(listener + "." + tlsKey),
+		ClientCAFile:   viper.GetString(listener + "." + tlsClientCA),
+		MinVersion:     viper.GetString(listener + "." + tlsMinVersion),
+		CipherSuites:   listValue(listener + "." + tlsCipherSuites),
+		ReloadInterval: viper.GetDuration(tlsReloadInterval),
+	}
+}
+
 // run bootstraps and runs the application
 func (c *cli) run(cmd *cobra.Command, args []string) error {
 
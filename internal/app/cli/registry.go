@@ -0,0 +1,302 @@
+package cli
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/spf13/pflag"
+	"github.com/spf13/viper"
+
+	"git.lothric.net/examples/go/gogin/internal/pkg/logger"
+)
+
+// envPrefixSeparator separates the optional prefix from the environment variable name.
+const envPrefixSeparator = "_"
+
+// option declares the configuration key in one place: the command line flag,
+// the environment variable, the schema, the help text and the documentation
+// are all generated from it.
+type option struct {
+	keySchema
+
+	// Key is the viper key and the flag name.
+	// For example: "http.port"
+	Key string
+
+	// Default is the default value of the key type: string, int, float64,
+	// bool, time.Duration, []string or map[string]string.
+	Default interface{}
+
+	// Description is the help text of the key.
+	Description string
+
+	// Env is the environment variable name, the key in upper case
+	// with '.' and '-' replaced by '_' if it is empty.
+	// For example: "HTTP_PORT"
+	Env string
+}
+
+// envName returns the environment variable name of the option.
+func (o option) envName() string {
+	if o.Env != "" {
+		return o.Env
+	}
+	return strings.NewReplacer(".", "_", "-", "_").Replace(strings.ToUpper(o.Key))
+}
+
+// envNames returns the environment variables bound to the option in the order
+// of precedence: the name with the 'prefix' first, if any, and the name without it,
+// so the prefix could be adopted without breaking the existing deployments.
+func (o option) envNames(prefix string) []string {
+	name := o.envName()
+	if prefix == "" || o.Key == envPrefix {
+		return []string{name}
+	}
+	return []string{strings.ToUpper(prefix) + envPrefixSeparator + name, name}
+}
+
+// configOptions returns all the configuration options, except the config file.
+func configOptions() []option {
+	options := []option{
+		// Common
+		{Key: nodeName, Default: "", keySchema: keySchema{Type: typeString},
+			Description: "Unique server ID, the hostname if empty."},
+		{Key: envPrefix, Default: "", keySchema: keySchema{Type: typeString},
+			Description: "Prefix of environment variables, for example GOGIN, the variables without prefix are used as well."},
+
+		// HTTP & Gin
+		{Key: httpPort, Default: 8080, keySchema: portSchema,
+			Description: "HTTP API port."},
+		{Key: ginMode, Default: "release", keySchema: keySchema{Type: typeString, Enum: []string{"debug", "release", "test"}},
+			Description: "Gin mode."},
+
+		// Lifecycle
+		{Key: shutdownDrainTimeout, Default: 3 * time.Second, keySchema: intervalSchema,
+			Description: "Time the components have to finish the work in progress on shutdown."},
+		{Key: shutdownDrainDelay, Default: time.Duration(0), keySchema: keySchema{Type: typeDuration},
+			Description: "Time between the readiness flip and the components stop on shutdown, so the load balancers stop sending new requests."},
+
+		// Configuration reload
+		{Key: reloadWatch, Default: true, keySchema: keySchema{Type: typeBoolean},
+			Description: "Apply changes of config file to log level, log formatter and admin token without restart."},
+
+		// Admin
+		{Key: adminAddr, Default: "127.0.0.1:8900", keySchema: addressSchema,
+			Description: "HTTP address of admin server, empty disables it."},
+		{Key: adminToken, Default: "", keySchema: keySchema{Type: typeString, Secret: true},
+			Description: "Bearer token of admin server, required unless it listens on loopback."},
+
+		// TLS
+		{Key: tlsReloadInterval, Default: 10 * time.Second, keySchema: intervalSchema,
+			Description: "Interval between checks of TLS certificate changes."},
+
+		// Log
+		{Key: logLevel, Default: "info", keySchema: levelSchema,
+			Description: "Log level."},
+		{Key: logFormatter, Default: logger.FormatterJson, keySchema: keySchema{Type: typeString, Enum: []string{logger.FormatterText, logger.FormatterJson, logger.FormatterLogfmt, logger.FormatterEcs, logger.FormatterGcp}},
+			Description: "Log formatter: text, json, logfmt, ecs or gcp."},
+		{Key: logFieldNames, Default: map[string]string{}, keySchema: mapSchema,
+			Description: "Log field names as field=name pairs, for example correlationId=request_id, over the names of the formatter."},
+		{Key: logGcpProject, Default: "", keySchema: keySchema{Type: typeString},
+			Description: "Google Cloud project of trace ids of gcp log formatter, that links the entries to Cloud Trace."},
+		{Key: logOutputs, Default: []string{logger.SinkStdout}, keySchema: keySchema{Type: typeList, Enum: []string{logger.SinkStdout, logger.SinkStderr, logger.SinkFile, logger.SinkSyslog}},
+			Description: "Log outputs the entries are written to: stdout, stderr, file or syslog."},
+		{Key: logStdoutLevel, Default: "trace", keySchema: levelSchema,
+			Description: "Minimal level of the entries written to stdout output, it could only raise the log level."},
+		{Key: logStderrLevel, Default: "trace", keySchema: levelSchema,
+			Description: "Minimal level of the entries written to stderr output, it could only raise the log level."},
+		{Key: logFileLevel, Default: "trace", keySchema: levelSchema,
+			Description: "Minimal level of the entries written to file output, it could only raise the log level."},
+		{Key: logFilePath, Default: "", keySchema: fileSchema,
+			Description: "Path to log file of file output."},
+		{Key: logFileMaxSize, Default: 100, keySchema: keySchema{Type: typeInteger, Minimum: bound(0)},
+			Description: "Size of log file in megabytes it is rotated at, 0 disables rotation."},
+		{Key: logFileMaxAge, Default: time.Duration(0), keySchema: keySchema{Type: typeDuration},
+			Description: "Age of rotated log files they are removed at, 0 keeps them."},
+		{Key: logFileMaxBackups, Default: 5, keySchema: keySchema{Type: typeInteger, Minimum: bound(0)},
+			Description: "Number of rotated log files that are kept, 0 keeps all of them."},
+		{Key: logFileCompress, Default: false, keySchema: keySchema{Type: typeBoolean},
+			Description: "Compress rotated log files with gzip."},
+		{Key: logSyslogLevel, Default: "trace", keySchema: levelSchema,
+			Description: "Minimal level of the entries written to syslog output, it could only raise the log level."},
+		{Key: logSyslogNetwork, Default: logger.SyslogUnix, keySchema: keySchema{Type: typeString, Enum: []string{logger.SyslogUdp, logger.SyslogTcp, logger.SyslogUnix}},
+			Description: "Network of syslog server: udp, tcp or unix."},
+		{Key: logSyslogAddr, Default: "/dev/log", keySchema: keySchema{Type: typeString},
+			Description: "Address of syslog server, for example localhost:514 or /dev/log."},
+		{Key: logSyslogTag, Default: serviceName, keySchema: keySchema{Type: typeString},
+			Description: "Tag of syslog messages."},
+		{Key: logRedactFields, Default: []string{}, keySchema: keySchema{Type: typeList},
+			Description: "Names of log fields, map keys and headers redacted in addition to authorization, cookie, password, secret, token and api-key."},
+		{Key: logRedactPatterns, Default: map[string]string{}, keySchema: mapSchema,
+			Description: "Regular expressions of secrets redacted in log messages and values as name=pattern pairs, in addition to the built-in ones."},
+		{Key: logRedactAllow, Default: []string{}, keySchema: keySchema{Type: typeList},
+			Description: "Names of log fields that are never redacted."},
+
+		// Status
+		{Key: statusRpcAddr, Default: ":8400", keySchema: addressSchema,
+			Description: "Rpc address of status server."},
+		{Key: statusCheckInterval, Default: 10 * time.Second, keySchema: intervalSchema,
+			Description: "Interval between health checks of components."},
+		{Key: statusCheckTimeout, Default: time.Second, keySchema: intervalSchema,
+			Description: "Timeout of health check of a component."},
+
+		// Metrics
+		{Key: metricsPrometheusAddr, Default: ":8880", keySchema: addressSchema,
+			Description: "HTTP address of prometheus metrics endpoint."},
+		{Key: metricsPrometheusPath, Default: "/metrics", keySchema: keySchema{Type: typeString, Format: formatPath},
+			Description: "HTTP URL endpoint of prometheus metrics endpoint."},
+		{Key: metricsBuckets, Default: map[string]string{}, keySchema: mapSchema,
+			Description: "Histogram buckets as metric=layout pairs, where layout is linear:start:width:count, exponential:start:factor:count or bound;bound;..."},
+		{Key: metricsObjectives, Default: map[string]string{}, keySchema: mapSchema,
+			Description: "Summary objectives as metric=quantile:error;quantile:error;... pairs."},
+		{Key: metricsNativeFactor, Default: 0.0, keySchema: keySchema{Type: typeNumber, Minimum: bound(0)},
+			Description: "Bucket growth factor of native histograms, greater than 1 enables them."},
+		{Key: metricsPushUrl, Default: "", keySchema: keySchema{Type: typeString, Format: formatUrl},
+			Description: "URL of Pushgateway the metrics are pushed to, empty disables pushing."},
+		{Key: metricsPushInterval, Default: 15 * time.Second, keySchema: intervalSchema,
+			Description: "Interval between metrics pushes to Pushgateway."},
+
+		// Tracing
+		{Key: tracingExporter, Default: "none", keySchema: keySchema{Type: typeString, Enum: []string{"none", "otlp", "stdout", "file"}},
+			Description: "Trace exporter: none, otlp, stdout or file."},
+		{Key: tracingOtlpEndpoint, Default: "localhost:4318", keySchema: keySchema{Type: typeString},
+			Description: "OTLP/HTTP endpoint of OpenTelemetry collector."},
+		{Key: tracingOtlpInsecure, Default: false, keySchema: keySchema{Type: typeBoolean},
+			Description: "Disable TLS of connection to OpenTelemetry collector."},
+		{Key: tracingFilePath, Default: "", keySchema: fileSchema,
+			Description: "Path to file the trace spans are appended to by file exporter."},
+		{Key: tracingSampleRatio, Default: 1.0, keySchema: keySchema{Type: typeNumber, Minimum: bound(0), Maximum: bound(1)},
+			Description: "Ratio of sampled new traces, the traces started by callers follow their sampling."},
+
+		// Service level objectives
+		{Key: sloAvailability, Default: map[string]string{}, keySchema: mapSchema,
+			Description: "Availability objectives as route=percent pairs."},
+		{Key: sloLatency, Default: map[string]string{}, keySchema: mapSchema,
+			Description: "Latency objectives as route=seconds:percent pairs, where seconds is a request duration histogram bucket."},
+		{Key: sloWindow, Default: time.Hour, keySchema: intervalSchema,
+			Description: "Rolling window of the SLO error budget."},
+		{Key: sloBurnWindow, Default: 5 * time.Minute, keySchema: intervalSchema,
+			Description: "Rolling window of the SLO error budget burn rate."},
+		{Key: sloInterval, Default: 10 * time.Second, keySchema: intervalSchema,
+			Description: "Interval between samples of the request metrics for SLOs."},
+
+		// Secrets
+		{Key: secretsPolicy, Default: "reject", keySchema: keySchema{Type: typeString, Enum: []string{"reject", "redact", "warn"}},
+			Description: "Policy for gists with secrets: reject, redact or warn."},
+		{Key: secretsRules, Default: map[string]string{}, keySchema: mapSchema,
+			Description: "Custom secret detection rules as name=regex pairs."},
+
+		// Gists
+		{Key: gistsSweeperInterval, Default: time.Minute, keySchema: intervalSchema,
+			Description: "Interval between sweeps of the expired gists."},
+		{Key: gistsSweeperBatchSize, Default: 100, keySchema: batchSchema,
+			Description: "Maximum number of expired gists deleted at once."},
+		{Key: gistsTrashRetention, Default: 30 * 24 * time.Hour, keySchema: intervalSchema,
+			Description: "How long deleted gists stay in the trash."},
+		{Key: gistsTrashInterval, Default: time.Hour, keySchema: intervalSchema,
+			Description: "Interval between purges of the trash."},
+		{Key: gistsTrashBatchSize, Default: 100, keySchema: batchSchema,
+			Description: "Maximum number of trashed gists purged at once."},
+	}
+
+	for _, listener := range tlsListeners {
+		options = append(options,
+			option{Key: listener + "." + tlsCert, Default: "", keySchema: fileSchema,
+				Description: fmt.Sprintf("Path to PEM certificate of %s listener, enables TLS.", listener)},
+			option{Key: listener + "." + tlsKey, Default: "", keySchema: fileSchema,
+				Description: fmt.Sprintf("Path to PEM private key of %s listener.", listener)},
+			option{Key: listener + "." + tlsClientCA, Default: "", keySchema: fileSchema,
+				Description: fmt.Sprintf("Path to PEM CA that verifies client certificates of %s listener, enables mutual TLS.", listener)},
+			option{Key: listener + "." + tlsMinVersion, Default: "1.2", keySchema: keySchema{Type: typeString, Enum: []string{"1.2", "1.3"}},
+				Description: fmt.Sprintf("Minimal TLS version of %s listener: 1.2 or 1.3.", listener)},
+			option{Key: listener + "." + tlsCipherSuites, Default: []string{}, keySchema: keySchema{Type: typeList},
+				Description: fmt.Sprintf("TLS 1.2 cipher suites of %s listener, Go defaults if empty.", listener)},
+		)
+	}
+
+	sort.Slice(options, func(i, j int) bool { return options[i].Key < options[j].Key })
+	return options
+}
+
+// configSchema returns the schemas of all the configuration keys by the key.
+func configSchema() map[string]keySchema {
+	schema := make(map[string]keySchema)
+	for _, o := range configOptions() {
+		schema[o.Key] = o.keySchema
+	}
+	return schema
+}
+
+// addFlag adds the command line flag of the option to the 'flags',
+// the help text mentions the environment variable.
+func (o option) addFlag(flags *pflag.FlagSet) {
+	usage := fmt.Sprintf("%s [$%s]", o.Description, o.envName())
+
+	switch v := o.Default.(type) {
+	case string:
+		flags.String(o.Key, v, usage)
+	case int:
+		flags.Int(o.Key, v, usage)
+	case float64:
+		flags.Float64(o.Key, v, usage)
+	case bool:
+		flags.Bool(o.Key, v, usage)
+	case time.Duration:
+		flags.Duration(o.Key, v, usage)
+	case []string:
+		flags.StringSlice(o.Key, v, usage)
+	case map[string]string:
+		flags.StringToString(o.Key, v, usage)
+	default:
+		panic(fmt.Sprintf("unsupported default value %T of the configuration key %s", o.Default, o.Key))
+	}
+}
+
+// bindEnv binds the option to its environment variables with the 'prefix'.
+func (o option) bindEnv(prefix string) {
+	viper.BindEnv(append([]string{o.Key}, o.envNames(prefix)...)...)
+}
+
+// docs writes the markdown reference of the 'options'.
+func docs(options []option) string {
+	var b strings.Builder
+	b.WriteString("| Option | Environment | Type | Default | Description |\n")
+	b.WriteString("|--------|-------------|------|---------|-------------|\n")
+
+	for _, o := range options {
+		fmt.Fprintf(&b, "| `--%s` | `%s` | %s | %s | %s |\n",
+			o.Key, o.envName(), o.Type, formatDefault(o), strings.ReplaceAll(o.Description, "|", "\\|"))
+	}
+	return b.String()
+}
+
+// formatDefault formats the default value of the option for the documentation.
+func formatDefault(o option) string {
+	value := formatValue(o.keySchema, o.Default)
+	if value == "" {
+		return ""
+	}
+	return "`" + value + "`"
+}
+
+// listValue returns the list value of the 'key', that is parsed
+// from the comma separated string if it is set by the environment.
+func listValue(key string) []string {
+	value, err := keySchema{Type: typeList}.parse(viper.Get(key))
+	if err != nil {
+		return nil
+	}
+	return value.([]string)
+}
+
+// mapValue returns the map value of the 'key', that is parsed
+// from the comma separated pairs if it is set by the environment.
+func mapValue(key string) map[string]string {
+	value, err := keySchema{Type: typeMap}.parse(viper.Get(key))
+	if err != nil {
+		return nil
+	}
+	return value.(map[string]string)
+}
@@ -0,0 +1,394 @@
+package cli
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/spf13/viper"
+
+	"git.lothric.net/examples/go/gogin/internal/pkg/lifecycle"
+	"git.lothric.net/examples/go/gogin/internal/pkg/logger"
+	"git.lothric.net/examples/go/gogin/internal/pkg/metrics"
+)
+
+const (
+
+	// reloadSettleDelay is the time the configuration file is given to be
+	// fully written, the editors and the Kubernetes volumes update it in
+	// several steps, so the file could be empty on the first event.
+	reloadSettleDelay = 100 * time.Millisecond
+
+	// kubernetesDataDir is the symlink of the mounted volume data,
+	// that Kubernetes swaps to update all the volume files at once.
+	kubernetesDataDir = "..data"
+
+	// Reasons of the failed configuration reloads
+	reloadFailureInvalid       = "invalid"
+	reloadFailureNonReloadable = "non_reloadable"
+)
+
+var (
+	// ErrNonReloadableKeys happens when the keys, that require the restart, have changed.
+	ErrNonReloadableKeys = errors.New("configuration keys require restart")
+)
+
+// subscriber applies the reloadable configuration keys to the running component.
+type subscriber struct {
+
+	// name is the name of the subscriber in the logs.
+	name string
+
+	// keys are the configuration keys the subscriber applies.
+	keys []string
+
+	// validate checks the new configuration before any subscriber applies it.
+	validate func(config appConfig) error
+
+	// apply applies the new configuration.
+	apply func(config appConfig) error
+}
+
+// configReloader reloads the configuration file and the secret files once they have
+// changed and notifies the subscribers of the reloadable keys. The changes of the other
+// keys, for example the listener addresses, are rejected and reported, they require the restart.
+//
+// The files are watched and read by the Run goroutine only, so viper is not accessed concurrently.
+type configReloader struct {
+	log logger.Log
+
+	// watcher watches the directories of the configuration and secret files,
+	// and files are the watched files, the other files of the directories are ignored
+	watcher *fsnotify.Watcher
+	files   map[string]bool
+
+	// settings are the current values of all the configuration keys
+	mu          sync.Mutex
+	settings    map[string]interface{}
+	subscribers []subscriber
+	stopped     bool
+
+	reloads  prometheus.Counter
+	failures *prometheus.CounterVec
+
+	runner *lifecycle.Runner
+}
+
+// newConfigReloader creates a new reloader of the configuration, that
+// starts with the current 'settings' and reports to the metrics 'registry'.
+func newConfigReloader(
+	log logger.Log,
+	settings map[string]interface{},
+	registry *metrics.Registry,
+) (*configReloader, error) {
+
+	r := &configReloader{
+		log:      log.WithField(logger.FieldPackage, "cli"),
+		files:    make(map[string]bool),
+		settings: settings,
+		reloads: prometheus.NewCounter(prometheus.CounterOpts{
+			Name: "config_reloads_total",
+			Help: "Total number of applied configuration reloads.",
+		}),
+		failures: prometheus.NewCounterVec(
+			prometheus.CounterOpts{
+				Name: "config_reload_failures_total",
+				Help: "Total number of rejected configuration reloads and changes by reason.",
+			},
+			[]string{"reason"},
+		),
+		runner: lifecycle.NewRunner(),
+	}
+
+	for _, c := range []prometheus.Collector{r.reloads, r.failures} {
+		if err := registry.Register(c); err != nil {
+			return nil, err
+		}
+	}
+
+	return r, nil
+}
+
+// Subscribe adds the subscriber of the reloadable 'keys', that
+// validates and applies the new configuration.
+func (r *configReloader) Subscribe(
+	name string,
+	keys []string,
+	validate func(config appConfig) error,
+	apply func(config appConfig) error,
+) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.subscribers = append(r.subscribers, subscriber{
+		name:     name,
+		keys:     keys,
+		validate: validate,
+		apply:    apply,
+	})
+}
+
+// Run watches the configuration file and the secret files until the reloader is stopped.
+func (r *configReloader) Run() error {
+	return r.runner.Run(r.watch)
+}
+
+// Stop stops applying the configuration changes and waits for the watcher to close.
+func (r *configReloader) Stop(ctx context.Context) error {
+	r.mu.Lock()
+	r.stopped = true
+	r.mu.Unlock()
+
+	return r.runner.Stop(ctx)
+}
+
+// watch watches the configuration file and the secret files until the 'ctx' is done.
+// The reload is delayed until the files have settled, so the several events of the
+// same update are reloaded once.
+func (r *configReloader) watch(ctx context.Context) error {
+	log := r.log.WithField(logger.FieldFunction, "watch")
+
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return err
+	}
+	defer watcher.Close()
+
+	r.watcher = watcher
+	r.watchFiles()
+
+	var settled <-chan time.Time
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+
+		case event, ok := <-watcher.Events:
+			if !ok {
+				return nil
+			}
+			// The events of the same update find the reload pending
+			if r.changed(event) && settled == nil {
+				settled = time.After(reloadSettleDelay)
+			}
+
+		case <-settled:
+			settled = nil
+			r.reload()
+
+		case err, ok := <-watcher.Errors:
+			if !ok {
+				return nil
+			}
+			log.Error(err, "Failed to watch the configuration files.")
+		}
+	}
+}
+
+// changed returns true if the 'event' changes any of the watched files.
+func (r *configReloader) changed(event fsnotify.Event) bool {
+	if event.Op == fsnotify.Chmod {
+		return false
+	}
+
+	// Kubernetes replaces the mounted files by swapping
+	// the symlink of the volume data directory
+	name := filepath.Clean(event.Name)
+	return r.files[name] || filepath.Base(name) == kubernetesDataDir
+}
+
+// reload reads the configuration file and reloads the configuration
+// with the current secret files.
+func (r *configReloader) reload() {
+	log := r.log.WithField(logger.FieldFunction, "reload")
+
+	if viper.ConfigFileUsed() != "" {
+		if err := viper.ReadInConfig(); err != nil {
+			log.Error(err, "Rejected the invalid configuration file.")
+			r.failures.WithLabelValues(reloadFailureInvalid).Inc()
+			return
+		}
+	}
+
+	// The secrets could reference the new files
+	r.watchFiles()
+
+	if err := validateConfig(); err != nil {
+		log.Error(err, "Rejected the invalid configuration file.")
+		r.failures.WithLabelValues(reloadFailureInvalid).Inc()
+		return
+	}
+
+	var config appConfig
+	if err := createConfig(&config); err != nil {
+		log.Error(err, "Rejected the invalid configuration file.")
+		r.failures.WithLabelValues(reloadFailureInvalid).Inc()
+		return
+	}
+
+	// The secret files could change since the config has been created,
+	// so the secrets are compared by the values the subscribers apply
+	settings := configSettings()
+	for key, value := range secretSettings(config) {
+		settings[key] = value
+	}
+
+	// The failures are logged and reported by Reload
+	_ = r.Reload(settings, config)
+}
+
+// watchFiles watches the directories of the configuration file and the secret
+// files, the already watched directories are ignored by the watcher.
+func (r *configReloader) watchFiles() {
+	log := r.log.WithField(logger.FieldFunction, "watchFiles")
+
+	files := secretFiles()
+	if file := viper.ConfigFileUsed(); file != "" {
+		files = append(files, filepath.Clean(file))
+	}
+
+	for _, file := range files {
+		if err := r.watcher.Add(filepath.Dir(file)); err != nil {
+			log.Error(err, "Failed to watch the file ", file, ".")
+			continue
+		}
+		r.files[file] = true
+	}
+}
+
+// Reload applies the new 'config' with the 'settings' values of the keys,
+// the failures are logged and reported by the metrics.
+//
+// The new configuration is rejected if any subscriber doesn't accept it.
+// The changes of the keys without subscribers are rejected with
+// ErrNonReloadableKeys, but the reloadable keys are still applied.
+func (r *configReloader) Reload(settings map[string]interface{}, config appConfig) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.stopped {
+		return nil
+	}
+
+	log := r.log.WithField(logger.FieldFunction, "Reload")
+
+	changed := changedKeys(r.settings, settings)
+	if len(changed) == 0 {
+		return nil
+	}
+
+	reloadable := make(map[string]bool)
+	affected := make([]subscriber, 0, len(r.subscribers))
+	for _, s := range r.subscribers {
+		matched := false
+		for _, key := range s.keys {
+			reloadable[key] = true
+			matched = matched || contains(changed, key)
+		}
+		if matched {
+			affected = append(affected, s)
+		}
+	}
+
+	var rejected []string
+	for _, key := range changed {
+		if !reloadable[key] {
+			rejected = append(rejected, key)
+		}
+	}
+
+	// Validate all the affected subscribers first,
+	// so the configuration is either fully applied or not at all
+	for _, s := range affected {
+		if s.validate == nil {
+			continue
+		}
+		if err := s.validate(config); err != nil {
+			err = fmt.Errorf("invalid %s configuration: %w", s.name, err)
+			log.Error(err, "Rejected the configuration reload.")
+			r.failures.WithLabelValues(reloadFailureInvalid).Inc()
+			return err
+		}
+	}
+
+	var errs []error
+	for _, s := range affected {
+		if err := s.apply(config); err != nil {
+			err = fmt.Errorf("failed to apply %s configuration: %w", s.name, err)
+			log.Error(err, "Failed to reload the configuration.")
+			errs = append(errs, err)
+			continue
+		}
+
+		for _, key := range s.keys {
+			if value, ok := settings[key]; ok {
+				r.settings[key] = value
+			}
+		}
+		log.Info("Reloaded ", s.name, " configuration.")
+	}
+
+	if len(affected) > 0 && len(errs) == 0 {
+		r.reloads.Inc()
+	}
+
+	// The rejected keys keep their running values, so they
+	// are reported again until the service is restarted
+	if len(rejected) > 0 {
+		r.failures.WithLabelValues(reloadFailureNonReloadable).Inc()
+		log.Warn("Ignored changes of the configuration keys, that require restart: ", strings.Join(rejected, ", "))
+		errs = append(errs, fmt.Errorf("%w: %s", ErrNonReloadableKeys, strings.Join(rejected, ", ")))
+	}
+
+	return errors.Join(errs...)
+}
+
+// configSettings returns the current values of all the configuration keys,
+// the secrets are read from their files, so the changes of the files are found.
+func configSettings() map[string]interface{} {
+	schema := configSchema()
+
+	settings := make(map[string]interface{})
+	for _, key := range viper.AllKeys() {
+		settings[key] = viper.Get(key)
+
+		if schema[key].Secret {
+			if value, err := secretValue(key); err == nil {
+				settings[key] = value
+			}
+		}
+	}
+	return settings
+}
+
+// changedKeys returns the sorted keys that have different values in 'previous' and 'current'.
+func changedKeys(previous map[string]interface{}, current map[string]interface{}) []string {
+	var changed []string
+	for key, value := range current {
+		if !reflect.DeepEqual(previous[key], value) {
+			changed = append(changed, key)
+		}
+	}
+	for key := range previous {
+		if _, ok := current[key]; !ok {
+			changed = append(changed, key)
+		}
+	}
+
+	sort.Strings(changed)
+	return changed
+}
+
+// contains returns true if the sorted 'keys' contain the 'key'.
+func contains(keys []string, key string) bool {
+	i := sort.SearchStrings(keys, key)
+	return i < len(keys) && keys[i] == key
+}
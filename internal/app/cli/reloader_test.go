@@ -0,0 +1,269 @@
+package cli
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/require"
+
+	"git.lothric.net/examples/go/gogin/internal/pkg/logger"
+	"git.lothric.net/examples/go/gogin/internal/pkg/metrics"
+	"git.lothric.net/examples/go/gogin/internal/pkg/metrics/metricstest"
+)
+
+// errInvalidLevel is the validation error of the test subscriber.
+var errInvalidLevel = errors.New("invalid level")
+
+// applied records the configurations applied by the test subscriber.
+type applied struct {
+	configs []appConfig
+}
+
+func TestConfigReloader(t *testing.T) {
+	for scenario, fn := range map[string]func(
+		t *testing.T,
+		r *configReloader,
+		registry *metrics.Registry,
+		log *applied,
+	){
+		"applies reloadable keys":           testAppliesReloadableKeys,
+		"ignores unchanged keys":            testIgnoresUnchangedKeys,
+		"rejects non-reloadable keys":       testRejectsNonReloadableKeys,
+		"applies reloadable keys of mixed":  testAppliesReloadableKeysOfMixed,
+		"reports non-reloadable keys again": testReportsNonReloadableKeysAgain,
+		"rejects invalid config":            testRejectsInvalidConfig,
+		"stops applying once stopped":       testStopsApplyingOnceStopped,
+	} {
+		t.Run(scenario, func(t *testing.T) {
+			l, _ := logger.NewNullLogger()
+
+			registry, err := metrics.NewRegistry(metrics.Config{})
+			require.NoError(t, err)
+
+			r, err := newConfigReloader(l, map[string]interface{}{
+				logLevel:      "info",
+				logFormatter:  "json",
+				httpPort:      "8080",
+				statusRpcAddr: ":8400",
+			}, registry)
+			require.NoError(t, err)
+
+			log := &applied{}
+			r.Subscribe("log", []string{logLevel, logFormatter},
+				func(c appConfig) error {
+					if c.Log.Level == "verbose" {
+						return errInvalidLevel
+					}
+					return nil
+				},
+				func(c appConfig) error {
+					log.configs = append(log.configs, c)
+					return nil
+				},
+			)
+
+			fn(t, r, registry, log)
+		})
+	}
+}
+
+// settings returns the test settings with the 'changes' applied.
+func settings(changes map[string]interface{}) map[string]interface{} {
+	s := map[string]interface{}{
+		logLevel:      "info",
+		logFormatter:  "json",
+		httpPort:      "8080",
+		statusRpcAddr: ":8400",
+	}
+	for key, value := range changes {
+		s[key] = value
+	}
+	return s
+}
+
+// withLevel returns the application configuration with the log 'level'.
+func withLevel(level string) appConfig {
+	return appConfig{Log: logger.Config{Level: level, Formatter: "json"}}
+}
+
+// reloads returns the number of applied reloads and the failures by the 'reason'.
+func reloads(t *testing.T, registry *metrics.Registry, reason string) (float64, float64) {
+	return metricstest.Value(t, registry.Gatherer(), "config_reloads_total", nil),
+		metricstest.Value(t, registry.Gatherer(), "config_reload_failures_total", map[string]string{"reason": reason})
+}
+
+func testAppliesReloadableKeys(t *testing.T, r *configReloader, registry *metrics.Registry, log *applied) {
+	err := r.Reload(settings(map[string]interface{}{logLevel: "debug"}), withLevel("debug"))
+	require.NoError(t, err)
+
+	require.Len(t, log.configs, 1)
+	require.Equal(t, "debug", log.configs[0].Log.Level)
+
+	succeeded, failed := reloads(t, registry, reloadFailureNonReloadable)
+	require.Equal(t, 1.0, succeeded)
+	require.Equal(t, 0.0, failed)
+}
+
+func testIgnoresUnchangedKeys(t *testing.T, r *configReloader, registry *metrics.Registry, log *applied) {
+	require.NoError(t, r.Reload(settings(nil), withLevel("info")))
+
+	require.NoError(t, r.Reload(settings(map[string]interface{}{logLevel: "debug"}), withLevel("debug")))
+	require.NoError(t, r.Reload(settings(map[string]interface{}{logLevel: "debug"}), withLevel("debug")))
+
+	require.Len(t, log.configs, 1)
+
+	succeeded, _ := reloads(t, registry, reloadFailureNonReloadable)
+	require.Equal(t, 1.0, succeeded)
+}
+
+func testRejectsNonReloadableKeys(t *testing.T, r *configReloader, registry *metrics.Registry, log *applied) {
+	err := r.Reload(settings(map[string]interface{}{httpPort: "9090", statusRpcAddr: ":9400"}), withLevel("info"))
+	require.ErrorIs(t, err, ErrNonReloadableKeys)
+	require.ErrorContains(t, err, "http.port, status.rpc.addr")
+
+	require.Empty(t, log.configs)
+
+	succeeded, failed := reloads(t, registry, reloadFailureNonReloadable)
+	require.Equal(t, 0.0, succeeded)
+	require.Equal(t, 1.0, failed)
+}
+
+func testAppliesReloadableKeysOfMixed(t *testing.T, r *configReloader, registry *metrics.Registry, log *applied) {
+	err := r.Reload(settings(map[string]interface{}{logLevel: "warning", httpPort: "9090"}), withLevel("warning"))
+	require.ErrorIs(t, err, ErrNonReloadableKeys)
+
+	require.Len(t, log.configs, 1)
+	require.Equal(t, "warning", log.configs[0].Log.Level)
+
+	succeeded, failed := reloads(t, registry, reloadFailureNonReloadable)
+	require.Equal(t, 1.0, succeeded)
+	require.Equal(t, 1.0, failed)
+}
+
+func testReportsNonReloadableKeysAgain(t *testing.T, r *configReloader, registry *metrics.Registry, log *applied) {
+	changed := settings(map[string]interface{}{httpPort: "9090"})
+	require.ErrorIs(t, r.Reload(changed, withLevel("info")), ErrNonReloadableKeys)
+	require.ErrorIs(t, r.Reload(changed, withLevel("info")), ErrNonReloadableKeys)
+
+	_, failed := reloads(t, registry, reloadFailureNonReloadable)
+	require.Equal(t, 2.0, failed)
+
+	// Reverting the change stops the reports
+	require.NoError(t, r.Reload(settings(nil), withLevel("info")))
+}
+
+func testRejectsInvalidConfig(t *testing.T, r *configReloader, registry *metrics.Registry, log *applied) {
+	err := r.Reload(settings(map[string]interface{}{logLevel: "verbose"}), withLevel("verbose"))
+	require.ErrorIs(t, err, errInvalidLevel)
+	require.Empty(t, log.configs)
+
+	succeeded, failed := reloads(t, registry, reloadFailureInvalid)
+	require.Equal(t, 0.0, succeeded)
+	require.Equal(t, 1.0, failed)
+
+	// The rejected value is not the running one,
+	// so the valid change is applied afterwards
+	require.NoError(t, r.Reload(settings(map[string]interface{}{logLevel: "debug"}), withLevel("debug")))
+	require.Len(t, log.configs, 1)
+}
+
+func testStopsApplyingOnceStopped(t *testing.T, r *configReloader, registry *metrics.Registry, log *applied) {
+	done := make(chan error)
+	go func() { done <- r.Run() }()
+
+	require.NoError(t, r.Stop(context.Background()))
+	require.NoError(t, <-done)
+
+	require.NoError(t, r.Reload(settings(map[string]interface{}{logLevel: "debug"}), withLevel("debug")))
+	require.Empty(t, log.configs)
+}
+
+func TestLogReload(t *testing.T) {
+	l, _ := logger.NewNullLogger()
+	require.NoError(t, l.SetLevel("info"))
+
+	registry, err := metrics.NewRegistry(metrics.Config{})
+	require.NoError(t, err)
+
+	r, err := newConfigReloader(l, settings(nil), registry)
+	require.NoError(t, err)
+
+	running := &runningConfig{log: l, config: withLevel("info")}
+	subscribeLog(r, l, running)
+
+	// The level changed by the admin server is kept on the formatter change
+	require.NoError(t, l.SetLevel("debug"))
+
+	config := withLevel("info")
+	config.Log.Formatter = logger.FormatterText
+	require.NoError(t, r.Reload(settings(map[string]interface{}{logFormatter: logger.FormatterText}), config))
+
+	require.Equal(t, "debug", l.Level())
+	require.Equal(t, logger.FormatterText, running.Get().(appConfig).Log.Formatter)
+
+	// The level change is applied
+	config = withLevel("warning")
+	config.Log.Formatter = logger.FormatterText
+	require.NoError(t, r.Reload(settings(map[string]interface{}{
+		logLevel:     "warning",
+		logFormatter: logger.FormatterText,
+	}), config))
+
+	require.Equal(t, "warning", l.Level())
+}
+
+func TestConfigFileReload(t *testing.T) {
+	viper.Reset()
+	defer viper.Reset()
+
+	dir := t.TempDir()
+	file := filepath.Join(dir, "gogin.yaml")
+	require.NoError(t, os.WriteFile(file, []byte("log:\n  level: info\n"), 0600))
+
+	newFlags(t)
+	viper.SetConfigFile(file)
+	require.NoError(t, viper.ReadInConfig())
+
+	l, _ := logger.NewNullLogger()
+	registry, err := metrics.NewRegistry(metrics.Config{})
+	require.NoError(t, err)
+
+	r, err := newConfigReloader(l, configSettings(), registry)
+	require.NoError(t, err)
+
+	var mu sync.Mutex
+	var levels []string
+	r.Subscribe("log", []string{logLevel, logFormatter}, nil, func(c appConfig) error {
+		mu.Lock()
+		defer mu.Unlock()
+		levels = append(levels, c.Log.Level)
+		return nil
+	})
+
+	done := make(chan error, 1)
+	go func() { done <- r.Run() }()
+	defer func() {
+		require.NoError(t, r.Stop(context.Background()))
+		require.NoError(t, <-done)
+	}()
+
+	// The changes of the other files of the directory are ignored
+	require.Eventually(t, func() bool {
+		if err := os.WriteFile(filepath.Join(dir, "gogin.log"), []byte("{}\n"), 0600); err != nil {
+			return false
+		}
+		if err := os.WriteFile(file, []byte("log:\n  level: debug\n"), 0600); err != nil {
+			return false
+		}
+
+		mu.Lock()
+		defer mu.Unlock()
+		return len(levels) > 0 && levels[len(levels)-1] == "debug"
+	}, 2*time.Second, 50*time.Millisecond)
+}
@@ -2,20 +2,30 @@ package cli
 
 import (
 	"context"
+	"crypto/tls"
 	"fmt"
+	"net"
 	"net/http"
 	"os"
 	"os/signal"
+	"sync"
 	"syscall"
-	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/spf13/viper"
 
 	"git.lothric.net/examples/go/gogin/internal/app/api"
 	"git.lothric.net/examples/go/gogin/internal/app/components"
+	"git.lothric.net/examples/go/gogin/internal/app/logic"
+	"git.lothric.net/examples/go/gogin/internal/pkg/admin"
+	"git.lothric.net/examples/go/gogin/internal/pkg/lifecycle"
 	"git.lothric.net/examples/go/gogin/internal/pkg/logger"
 	"git.lothric.net/examples/go/gogin/internal/pkg/metrics"
+	"git.lothric.net/examples/go/gogin/internal/pkg/secrets"
+	"git.lothric.net/examples/go/gogin/internal/pkg/slo"
 	"git.lothric.net/examples/go/gogin/internal/pkg/status"
+	"git.lothric.net/examples/go/gogin/internal/pkg/tlsconfig"
+	"git.lothric.net/examples/go/gogin/internal/pkg/tracing"
 )
 
 // appConfig defines the global application configuration and
@@ -25,10 +35,18 @@ type appConfig struct {
 	NodeName    string
 	ServiceName string
 
-	Http    httpConfig
-	Log     logger.Config
-	Status  status.Config
-	Metrics metrics.Config
+	Http      httpConfig
+	Admin     admin.Config
+	Reload    reloadConfig
+	Lifecycle lifecycle.Config
+	Log       logger.Config
+	Status    status.Config
+	Metrics   metrics.Config
+	Tracing   tracing.Config
+	Slo       slo.Config
+	Secrets   secrets.Config
+	Sweeper   logic.SweeperConfig
+	Trash     logic.TrashConfig
 }
 
 // httpConfig defines HTTP API server configuration
@@ -42,6 +60,72 @@ type httpConfig struct {
 	//  - debug
 	//  - release
 	GinMode string
+
+	// TLS is the TLS configuration of HTTP API server.
+	TLS tlsconfig.Config
+}
+
+// reloadConfig defines the configuration file reload
+type reloadConfig struct {
+
+	// Watch enables applying the changes of the configuration
+	// file to the reloadable keys without the restart
+	Watch bool
+}
+
+// runningConfig is the configuration of the running service, the reloaded
+// keys are updated once their subscribers have applied them.
+type runningConfig struct {
+	log logger.Log
+
+	mu     sync.RWMutex
+	config appConfig
+}
+
+// Get returns the current configuration with the log level,
+// that could be changed by the admin server as well.
+func (r *runningConfig) Get() interface{} {
+	r.mu.RLock()
+	config := r.config
+	r.mu.RUnlock()
+
+	config.Log.Level = r.log.Level()
+	return config
+}
+
+// Update updates the current configuration by the 'apply'.
+func (r *runningConfig) Update(apply func(config *appConfig)) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	apply(&r.config)
+}
+
+// subscribeLog subscribes the log level and the log formatter to the reloads separately,
+// so the change of the formatter doesn't reset the level changed by the admin server.
+func subscribeLog(r *configReloader, log logger.Log, running *runningConfig) {
+	validate := func(c appConfig) error {
+		return c.Log.Validate()
+	}
+
+	r.Subscribe("log level", []string{logLevel}, validate,
+		func(c appConfig) error {
+			return log.SetLevel(c.Log.Level)
+		},
+	)
+
+	r.Subscribe("log formatter", []string{logFormatter}, validate,
+		func(c appConfig) error {
+			if err := log.SetFormatter(c.Log.Formatter); err != nil {
+				return err
+			}
+
+			running.Update(func(config *appConfig) {
+				config.Log.Formatter = c.Log.Formatter
+			})
+			return nil
+		},
+	)
 }
 
 // runApp bootstraps and runs the application
@@ -49,9 +133,10 @@ func runApp(config appConfig) error {
 
 	cleanLog, err := logger.New(config.Log)
 	if err != nil {
-		fmt.Printf("Failed to create the application logger: %s", err.Error())
+		fmt.Printf("Failed to create the application logger: %s\n", err.Error())
 		return err
 	}
+	defer cleanLog.Close()
 
 	log := cleanLog.WithFields(logger.Fields{
 		logger.FieldNode:     config.NodeName,
@@ -63,98 +148,370 @@ func runApp(config appConfig) error {
 	gin.SetMode(config.Http.GinMode)
 
 	// --------------
-	// Health status server
-	statusServer, err := status.NewStatusServer()
+	// Lifecycle manager, the components are started in the order
+	// they are added and stopped in the reverse order
+	manager, err := lifecycle.NewManager(log, config.Lifecycle)
+	if err != nil {
+		log.Error(err, "Failed to create the lifecycle manager.")
+		return err
+	}
+
+	// --------------
+	// Tracer provider shared by all components,
+	// it is stopped last to flush the spans of all of them
+	tracerProvider, err := tracing.NewProvider(config.Tracing, config.ServiceName, config.NodeName)
+	if err != nil {
+		log.Error(err, "Failed to create the tracer provider.")
+		return err
+	}
+
+	manager.Add(lifecycle.Component{
+		Name: "tracer provider",
+		Stop: tracerProvider.Shutdown,
+	})
+
+	// --------------
+	// Metrics registry shared by all components
+	metricsRegistry, err := metrics.NewRegistry(config.Metrics)
+	if err != nil {
+		log.Error(err, "Failed to create the metrics registry.")
+		return err
+	}
+
+	// --------------
+	// Pushgateway metrics exporter, for the processes that could exit
+	// before Prometheus scrapes them. It is stopped after all other
+	// components, so the final push has all their metrics
+	if config.Metrics.PushUrl != "" {
+		pusher, err := metrics.NewPushExporter(log, config.Metrics, metricsRegistry, config.ServiceName, config.NodeName)
+		if err != nil {
+			log.Error(err, "Failed to create the metrics push exporter.")
+			return err
+		}
+
+		manager.Add(lifecycle.Component{
+			Name: "metrics push exporter",
+			Run:  pusher.Run,
+			Stop: pusher.Stop,
+		})
+	}
+
+	// --------------
+	// Health status server, all services are not serving until the startup
+	// is completed and from the start of the shutdown
+	health, err := status.NewHealth(log, config.Status)
+	if err != nil {
+		log.Error(err, "Failed to create the health registry.")
+		return err
+	}
+
+	manager.OnReady(health.Ready)
+	manager.OnShutdown(health.Shutdown)
+
+	statusTLS, err := listenerTLS(log, manager, "status server", config.Status.TLS)
+	if err != nil {
+		log.Error(err, "Failed to load the status server TLS configuration.")
+		return err
+	}
+
+	statusServer, err := status.NewStatusServer(config.Status, health, statusTLS)
 	if err != nil {
 		log.Error(err, "Failed to create the status server.")
 		return err
 	}
 
-	go func(cfg status.Config) {
-		statusServer.Serve(cfg)
-	}(config.Status)
+	manager.Add(lifecycle.Component{
+		Name:   "status server",
+		Listen: statusServer.Listen,
+		Run:    statusServer.Serve,
+		Stop:   statusServer.Stop,
+	})
 
 	// --------------
 	// Prometheus metrics server
-	metricsServer, err := metrics.NewPrometheusServer(config.Metrics)
+	metricsTLS, err := listenerTLS(log, manager, "metrics server", config.Metrics.TLS)
+	if err != nil {
+		log.Error(err, "Failed to load the metrics server TLS configuration.")
+		return err
+	}
+
+	metricsServer, err := metrics.NewPrometheusServer(config.Metrics, metricsRegistry, metricsTLS)
 	if err != nil {
 		log.Error(err, "Failed to create the metrics server.")
 		return err
 	}
 
-	go func() {
-		metricsServer.Serve()
-	}()
+	// HTTP health probes, for the environments
+	// that can't run the gRPC health checks
+	probes, err := status.NewProbeHandlers(health)
+	if err != nil {
+		log.Error(err, "Failed to create the health probes.")
+		return err
+	}
+
+	for path, probe := range probes {
+		metricsServer.Handle(path, probe)
+	}
+
+	manager.Add(lifecycle.Component{
+		Name:   "metrics server",
+		Listen: metricsServer.Listen,
+		Run:    metricsServer.Serve,
+		Stop:   metricsServer.Stop,
+	})
+
+	// The configuration reported by the admin server
+	running := &runningConfig{log: cleanLog, config: config}
+
+	// --------------
+	// Admin server for debugging of the running service,
+	// it is stopped late to profile the shutdown as well
+	var adminServer *admin.Server
+	if config.Admin.Enabled() {
+		adminTLS, err := listenerTLS(log, manager, "admin server", config.Admin.TLS)
+		if err != nil {
+			log.Error(err, "Failed to load the admin server TLS configuration.")
+			return err
+		}
+
+		adminServer, err = admin.NewServer(log, config.Admin, running.Get, adminTLS)
+		if err != nil {
+			log.Error(err, "Failed to create the admin server.")
+			return err
+		}
+
+		manager.Add(lifecycle.Component{
+			Name:   "admin server",
+			Listen: adminServer.Listen,
+			Run:    adminServer.Serve,
+			Stop:   adminServer.Stop,
+		})
+	}
 
 	// --------------
-	// HTTP API server
-	ctx := context.Background()
+	// Configuration and secret files reloader, that applies the
+	// reloadable keys to the running components without the restart
+	if config.Reload.Watch && (viper.ConfigFileUsed() != "" || len(secretFiles()) > 0) {
+		configReloader, err := newConfigReloader(log, configSettings(), metricsRegistry)
+		if err != nil {
+			log.Error(err, "Failed to create the configuration reloader.")
+			return err
+		}
+
+		subscribeLog(configReloader, cleanLog, running)
+
+		if adminServer != nil {
+			configReloader.Subscribe("admin", []string{adminToken},
+				func(c appConfig) error {
+					return admin.Config{Addr: config.Admin.Addr, Token: c.Admin.Token}.Validate()
+				},
+				func(c appConfig) error {
+					if err := adminServer.SetToken(c.Admin.Token); err != nil {
+						return err
+					}
+
+					running.Update(func(config *appConfig) {
+						config.Admin.Token = c.Admin.Token
+					})
+					return nil
+				},
+			)
+		}
 
-	componentFactory, err := components.NewComponentFactory(log)
+		manager.Add(lifecycle.Component{
+			Name: "configuration reloader",
+			Run:  configReloader.Run,
+			Stop: configReloader.Stop,
+		})
+	}
+
+	// --------------
+	// Component factory
+	componentFactory, err := components.NewComponentFactory(log, components.Config{
+		Secrets: config.Secrets,
+		Sweeper: config.Sweeper,
+		Trash:   config.Trash,
+		Slo:     config.Slo,
+	}, metricsRegistry, tracerProvider)
 	if err != nil {
 		log.Error(err, "Failed to create component factory")
 		return err
 	}
 
+	// --------------
+	// Health checks of the components
+	checkers, err := componentFactory.CreateHealthCheckers()
+	if err != nil {
+		log.Error(err, "Failed to create the health checkers.")
+		return err
+	}
+
+	for service, checker := range checkers {
+		if err := health.Register(service, checker); err != nil {
+			log.Error(err, "Failed to register the health checker.")
+			return err
+		}
+	}
+
+	manager.Add(lifecycle.Component{
+		Name: "health checks",
+		Run:  health.Run,
+		Stop: health.Stop,
+	})
+
+	// --------------
+	// Expired gists sweeper
+	sweeper, err := componentFactory.CreateGistsSweeper()
+	if err != nil {
+		log.Error(err, "Failed to create the expired gists sweeper.")
+		return err
+	}
+
+	manager.Add(lifecycle.Component{
+		Name: "expired gists sweeper",
+		Run:  sweeper.Run,
+		Stop: sweeper.Stop,
+	})
+
+	// --------------
+	// Trashed gists purger
+	purger, err := componentFactory.CreateTrashPurger()
+	if err != nil {
+		log.Error(err, "Failed to create the trashed gists purger.")
+		return err
+	}
+
+	manager.Add(lifecycle.Component{
+		Name: "trashed gists purger",
+		Run:  purger.Run,
+		Stop: purger.Stop,
+	})
+
+	// --------------
+	// Service level objectives tracker
+	sloTracker, err := componentFactory.CreateSloTracker()
+	if err != nil {
+		log.Error(err, "Failed to create the SLO tracker.")
+		return err
+	}
+
+	manager.Add(lifecycle.Component{
+		Name: "SLO tracker",
+		Run:  sloTracker.Run,
+		Stop: sloTracker.Stop,
+	})
+
+	// --------------
+	// HTTP API server, it is started last and stopped first,
+	// so the requests in progress could use all other components
 	apiBuilder, err := api.NewApiBuilder(log, componentFactory)
 	if err != nil {
 		log.Error(err, "Failed to create HTTP API server.")
 		return err
 	}
 
-	router, err := apiBuilder.BuildApi(ctx)
+	router, err := apiBuilder.BuildApi(context.Background())
 	if err != nil {
 		log.Error(err, "Failed to Build API router.")
 		return err
 	}
 
+	// The admin API could permanently delete the data, so it is served
+	// by the admin server only, that authorizes the requests by the token
+	if adminServer != nil {
+		adminRouter, err := apiBuilder.BuildAdminApi(context.Background())
+		if err != nil {
+			log.Error(err, "Failed to Build admin API router.")
+			return err
+		}
+		adminServer.Handle(api.AdminRoute+"/", adminRouter)
+	}
+
+	apiTLS, err := listenerTLS(log, manager, "API server", config.Http.TLS)
+	if err != nil {
+		log.Error(err, "Failed to load the API server TLS configuration.")
+		return err
+	}
+
 	srv := &http.Server{
 		Addr:    fmt.Sprintf(":%d", config.Http.HttpPort),
 		Handler: router,
 	}
 
-	go func() {
-		// Serve HTTP API endpoints
-		if err := srv.ListenAndServe(); err != nil && err != http.ErrServerClosed {
-			log.Error(err, "Failed to listen and serve HTTP API endpoint.")
-		}
-	}()
-
-	log.Info("The service is up and running.")
+	var apiListener net.Listener
+	manager.Add(lifecycle.Component{
+		Name: "API server",
+		Listen: func() (err error) {
+			apiListener, err = net.Listen("tcp", srv.Addr)
+			if err == nil && apiTLS != nil {
+				apiListener = tls.NewListener(apiListener, apiTLS)
+			}
+			return err
+		},
+		Run: func() error {
+			if err := srv.Serve(apiListener); err != http.ErrServerClosed {
+				return err
+			}
+			return nil
+		},
+		Stop: srv.Shutdown,
+	})
 
 	// -------------------------
-	// Wait for the app termination
+	// Run until the app termination is requested or any component fails,
+	// the second request cuts the drain delay short
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, syscall.SIGINT, syscall.SIGTERM)
+	defer signal.Stop(signals)
 
-	// Wait for interrupt signal to gracefully shutdown the server
-	quit := make(chan os.Signal, 1)
-	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
-	<-quit
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
 
-	log.Info("Requested app termination. Waiting 3 seconds...")
+	done := make(chan struct{})
+	defer close(done)
 
-	// Wait 3 seconds for server to terminate
-	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
-	defer cancel()
+	go func() {
+		select {
+		case <-signals:
+			cancel()
+		case <-done:
+			return
+		}
+
+		select {
+		case <-signals:
+			manager.Interrupt()
+		case <-done:
+		}
+	}()
 
-	// Status
-	log.Info("Gracefully terminating status server...")
-	statusServer.Stop()
+	return manager.Run(ctx)
+}
 
-	// Metrics
-	log.Info("Gracefully terminating metrics server...")
-	if err := metricsServer.Stop(ctx); err != nil {
-		log.Error(err, "Failed to gracefully shutdown prometheus metrics server.")
+// listenerTLS creates the TLS configuration of the listener 'name', that
+// reloads the certificates while the service is running, or returns nil
+// if TLS is not configured.
+func listenerTLS(
+	log logger.Log,
+	manager *lifecycle.Manager,
+	name string,
+	config tlsconfig.Config,
+) (*tls.Config, error) {
+
+	if !config.Enabled() {
+		return nil, nil
 	}
 
-	// HTTP API
-	log.Info("Gracefully terminating API server...")
-	if err := srv.Shutdown(ctx); err != nil {
-		log.Error(err, "Failed to gracefully shutdown HTTP API server.")
+	reloader, err := tlsconfig.NewReloader(log, config)
+	if err != nil {
+		return nil, err
 	}
 
-	// Wait
-	<-ctx.Done()
-	log.Info("Timeout of 3 seconds has ended. Exiting.")
+	manager.Add(lifecycle.Component{
+		Name: name + " TLS reloader",
+		Run:  reloader.Run,
+		Stop: reloader.Stop,
+	})
 
-	return nil
+	return reloader.TLSConfig(), nil
 }
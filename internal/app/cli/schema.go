@@ -0,0 +1,354 @@
+package cli
+
+import (
+	"fmt"
+	"net"
+	"net/url"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// keyType is the type of the configuration key value.
+type keyType string
+
+const (
+	typeString   keyType = "string"
+	typeInteger  keyType = "integer"
+	typeNumber   keyType = "number"
+	typeBoolean  keyType = "boolean"
+	typeDuration keyType = "duration"
+	typeList     keyType = "list"
+	typeMap      keyType = "map"
+)
+
+// Formats of the string values
+const (
+	formatAddress = "address"
+	formatUrl     = "url"
+	formatPath    = "path"
+)
+
+// durationPattern is the JSON Schema pattern of the Go durations, for example "1m30s".
+const durationPattern = `^([0-9]+(\.[0-9]+)?(ns|us|µs|ms|s|m|h))+$`
+
+// keySchema describes the valid values of the configuration key.
+type keySchema struct {
+
+	// Type is the type of the value.
+	Type keyType
+
+	// Enum are the allowed values of the string or the list items, if any.
+	Enum []string
+
+	// Minimum and Maximum are the inclusive bounds of the number.
+	Minimum *float64
+	Maximum *float64
+
+	// Positive requires the number or the duration to be greater than zero.
+	Positive bool
+
+	// Format is the format of the non-empty string:
+	// formatAddress, formatUrl or formatPath.
+	Format string
+
+	// Secret values are never printed.
+	Secret bool
+}
+
+// bound returns the pointer to the bound 'v'.
+func bound(v float64) *float64 {
+	return &v
+}
+
+var (
+	portSchema     = keySchema{Type: typeInteger, Minimum: bound(1), Maximum: bound(65535)}
+	addressSchema  = keySchema{Type: typeString, Format: formatAddress}
+	intervalSchema = keySchema{Type: typeDuration, Positive: true}
+	batchSchema    = keySchema{Type: typeInteger, Minimum: bound(1)}
+	fileSchema     = keySchema{Type: typeString}
+	mapSchema      = keySchema{Type: typeMap}
+	levelSchema    = keySchema{Type: typeString, Enum: []string{"trace", "debug", "info", "warning", "warn", "error", "fatal", "panic"}}
+)
+
+// parse converts the raw 'value' of the key to its type strictly,
+// for example the string "8080" of the integer key is converted to 8080,
+// but the string "80a" is rejected.
+func (s keySchema) parse(value interface{}) (interface{}, error) {
+	v := reflect.ValueOf(value)
+
+	switch s.Type {
+	case typeInteger:
+		switch v.Kind() {
+		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+			return v.Int(), nil
+		case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+			return int64(v.Uint()), nil
+		case reflect.String:
+			if i, err := strconv.ParseInt(strings.TrimSpace(v.String()), 10, 64); err == nil {
+				return i, nil
+			}
+		}
+		return nil, fmt.Errorf("should be an integer")
+
+	case typeNumber:
+		switch v.Kind() {
+		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+			return float64(v.Int()), nil
+		case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+			return float64(v.Uint()), nil
+		case reflect.Float32, reflect.Float64:
+			return v.Float(), nil
+		case reflect.String:
+			if f, err := strconv.ParseFloat(strings.TrimSpace(v.String()), 64); err == nil {
+				return f, nil
+			}
+		}
+		return nil, fmt.Errorf("should be a number")
+
+	case typeBoolean:
+		switch v.Kind() {
+		case reflect.Bool:
+			return v.Bool(), nil
+		case reflect.String:
+			if b, err := strconv.ParseBool(strings.TrimSpace(v.String())); err == nil {
+				return b, nil
+			}
+		}
+		return nil, fmt.Errorf("should be true or false")
+
+	case typeDuration:
+		if d, ok := value.(time.Duration); ok {
+			return d, nil
+		}
+		if v.Kind() == reflect.String {
+			if d, err := time.ParseDuration(strings.TrimSpace(v.String())); err == nil {
+				return d, nil
+			}
+		}
+		return nil, fmt.Errorf("should be a duration, for example 1m30s")
+
+	case typeList:
+		switch v.Kind() {
+		case reflect.Slice, reflect.Array:
+			items := make([]string, v.Len())
+			for i := range items {
+				items[i] = fmt.Sprint(v.Index(i).Interface())
+			}
+			return items, nil
+		case reflect.String:
+			return parseList(v.String()), nil
+		}
+		return nil, fmt.Errorf("should be a list")
+
+	case typeMap:
+		switch v.Kind() {
+		case reflect.Map:
+			entries := make(map[string]string, v.Len())
+			iter := v.MapRange()
+			for iter.Next() {
+				entries[fmt.Sprint(iter.Key().Interface())] = fmt.Sprint(iter.Value().Interface())
+			}
+			return entries, nil
+		case reflect.String:
+			return parseMap(v.String())
+		}
+		return nil, fmt.Errorf("should be a map")
+
+	default:
+		switch v.Kind() {
+		case reflect.Map, reflect.Slice, reflect.Array, reflect.Struct:
+			return nil, fmt.Errorf("should be a string")
+		}
+		return fmt.Sprint(value), nil
+	}
+}
+
+// check checks the constraints of the 'value', that is already parsed.
+func (s keySchema) check(value interface{}) error {
+	switch v := value.(type) {
+	case string:
+		if len(s.Enum) > 0 && !containsString(s.Enum, v) {
+			return fmt.Errorf("should be one of: %s", strings.Join(s.Enum, ", "))
+		}
+		if v != "" {
+			return checkFormat(s.Format, v)
+		}
+
+	case []string:
+		for _, item := range v {
+			if len(s.Enum) > 0 && !containsString(s.Enum, item) {
+				return fmt.Errorf("should contain only: %s", strings.Join(s.Enum, ", "))
+			}
+		}
+
+	case int64:
+		return s.checkBounds(float64(v))
+
+	case float64:
+		return s.checkBounds(v)
+
+	case time.Duration:
+		if s.Positive && v <= 0 {
+			return fmt.Errorf("should be greater than 0s")
+		}
+	}
+
+	return nil
+}
+
+// checkBounds checks that the 'value' is within the bounds.
+func (s keySchema) checkBounds(value float64) error {
+	if s.Positive && value <= 0 {
+		return fmt.Errorf("should be greater than 0")
+	}
+	if s.Minimum != nil && value < *s.Minimum {
+		return fmt.Errorf("should be at least %v", *s.Minimum)
+	}
+	if s.Maximum != nil && value > *s.Maximum {
+		return fmt.Errorf("should be at most %v", *s.Maximum)
+	}
+	return nil
+}
+
+// checkFormat checks that the non-empty string 'value' has the 'format'.
+func checkFormat(format string, value string) error {
+	switch format {
+	case formatAddress:
+		_, port, err := net.SplitHostPort(value)
+		if err != nil {
+			return fmt.Errorf("should be an address, for example :8080 or 127.0.0.1:8080")
+		}
+		if n, err := strconv.Atoi(port); err != nil || n < 0 || n > 65535 {
+			return fmt.Errorf("should have a port between 0 and 65535")
+		}
+
+	case formatUrl:
+		u, err := url.Parse(value)
+		if err != nil || u.Scheme == "" || u.Host == "" {
+			return fmt.Errorf("should be an absolute URL, for example http://host:9091")
+		}
+
+	case formatPath:
+		if !strings.HasPrefix(value, "/") {
+			return fmt.Errorf("should start with /")
+		}
+	}
+
+	return nil
+}
+
+// jsonSchema returns the JSON Schema of the configuration file with
+// the descriptions and the defaults of the 'options'.
+func jsonSchema(options []option) map[string]interface{} {
+	root := map[string]interface{}{
+		"$schema":              "https://json-schema.org/draft/2020-12/schema",
+		"title":                serviceName + " configuration",
+		"type":                 "object",
+		"properties":           map[string]interface{}{},
+		"additionalProperties": false,
+	}
+
+	for _, o := range options {
+		property := map[string]interface{}{
+			"description": o.Description,
+		}
+
+		switch o.Type {
+		case typeDuration:
+			property["type"] = "string"
+			property["pattern"] = durationPattern
+		case typeList:
+			items := map[string]interface{}{"type": "string"}
+			if len(o.Enum) > 0 {
+				items["enum"] = o.Enum
+			}
+			property["type"] = "array"
+			property["items"] = items
+		case typeMap:
+			property["type"] = "object"
+			property["additionalProperties"] = map[string]interface{}{"type": "string"}
+		default:
+			property["type"] = string(o.Type)
+		}
+
+		if len(o.Enum) > 0 && o.Type != typeList {
+			property["enum"] = o.Enum
+		}
+		if o.Minimum != nil {
+			property["minimum"] = *o.Minimum
+		}
+		if o.Maximum != nil {
+			property["maximum"] = *o.Maximum
+		}
+		if o.Positive && o.Type != typeDuration {
+			property["exclusiveMinimum"] = 0
+		}
+		if o.Format == formatUrl {
+			property["format"] = "uri"
+		}
+
+		if o.Secret {
+			property["writeOnly"] = true
+		} else if d, ok := o.Default.(time.Duration); ok {
+			property["default"] = d.String()
+		} else {
+			property["default"] = o.Default
+		}
+
+		// Nest the property by the parts of the key
+		parts := strings.Split(o.Key, ".")
+		parent := root
+		for _, part := range parts[:len(parts)-1] {
+			properties := parent["properties"].(map[string]interface{})
+			child, ok := properties[part].(map[string]interface{})
+			if !ok {
+				child = map[string]interface{}{
+					"type":                 "object",
+					"properties":           map[string]interface{}{},
+					"additionalProperties": false,
+				}
+				properties[part] = child
+			}
+			parent = child
+		}
+		parent["properties"].(map[string]interface{})[parts[len(parts)-1]] = property
+	}
+
+	return root
+}
+
+// parseList parses the comma separated list.
+func parseList(s string) []string {
+	items := []string{}
+	for _, item := range strings.Split(s, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+// parseMap parses the comma separated key=value pairs.
+func parseMap(s string) (map[string]string, error) {
+	entries := map[string]string{}
+	for _, item := range parseList(s) {
+		key, value, ok := strings.Cut(item, "=")
+		if !ok {
+			return nil, fmt.Errorf("should be a map of key=value pairs")
+		}
+		entries[strings.TrimSpace(key)] = strings.TrimSpace(value)
+	}
+	return entries, nil
+}
+
+// containsString returns true if the 'values' contain the 'value'.
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,111 @@
+package cli
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/spf13/pflag"
+	"github.com/spf13/viper"
+)
+
+const (
+
+	// secretFileScheme prefixes the secret values, that are read from the files,
+	// for example "file:///var/run/secrets/gogin/admin-token"
+	secretFileScheme = "file://"
+
+	// envFileSuffix is the suffix of the environment variables with the path of the
+	// secret file, for example ADMIN_TOKEN_FILE=/var/run/secrets/gogin/admin-token
+	envFileSuffix = "_FILE"
+)
+
+// bindSecretFiles binds the secret options to the files of their '*_FILE'
+// environment variables. The secrets, that are set by the 'flags' or by the
+// environment variables themselves, take precedence over the files.
+func bindSecretFiles(flags *pflag.FlagSet) {
+	for _, o := range configOptions() {
+		if !o.Secret {
+			continue
+		}
+
+		names := envKeys[o.Key]
+
+		if flag := flags.Lookup(o.Key); flag != nil && flag.Changed {
+			continue
+		}
+		if envSet(names) {
+			continue
+		}
+
+		for _, name := range envFileNames(names) {
+			if path := os.Getenv(name); path != "" {
+				viper.Set(o.Key, secretFileScheme+path)
+				break
+			}
+		}
+	}
+}
+
+// envFileNames returns the '*_FILE' environment variables with the paths
+// of the secret files for the environment variables 'names' of the secret.
+func envFileNames(names []string) []string {
+	files := make([]string, 0, len(names))
+	for _, name := range names {
+		files = append(files, name+envFileSuffix)
+	}
+	return files
+}
+
+// envSet returns true if any of the environment variables 'names' is set.
+func envSet(names []string) bool {
+	for _, name := range names {
+		if os.Getenv(name) != "" {
+			return true
+		}
+	}
+	return false
+}
+
+// secretValue returns the value of the secret 'key', that is read
+// from the file if the value is the "file://" reference.
+func secretValue(key string) (string, error) {
+	value := viper.GetString(key)
+	if !strings.HasPrefix(value, secretFileScheme) {
+		return value, nil
+	}
+
+	content, err := os.ReadFile(strings.TrimPrefix(value, secretFileScheme))
+	if err != nil {
+		return "", fmt.Errorf("failed to read the secret file: %w", err)
+	}
+
+	// The editors and 'echo' end the files with the new line
+	return strings.TrimRight(string(content), "\r\n"), nil
+}
+
+// secretFiles returns the sorted paths of the files the secrets are read from.
+func secretFiles() []string {
+	var files []string
+	for key, s := range configSchema() {
+		if !s.Secret {
+			continue
+		}
+		if value := viper.GetString(key); strings.HasPrefix(value, secretFileScheme) {
+			files = append(files, filepath.Clean(strings.TrimPrefix(value, secretFileScheme)))
+		}
+	}
+
+	sort.Strings(files)
+	return files
+}
+
+// secretSettings returns the values of the secret keys in the 'config',
+// that are read from the files once the config is created.
+func secretSettings(config appConfig) map[string]interface{} {
+	return map[string]interface{}{
+		adminToken: config.Admin.Token,
+	}
+}
@@ -0,0 +1,153 @@
+package cli
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/require"
+
+	"git.lothric.net/examples/go/gogin/internal/pkg/admin"
+	"git.lothric.net/examples/go/gogin/internal/pkg/logger"
+	"git.lothric.net/examples/go/gogin/internal/pkg/metrics"
+)
+
+func TestSecrets(t *testing.T) {
+	for scenario, fn := range map[string]func(t *testing.T, file string){
+		"reads file reference":         testReadsFileReference,
+		"binds file env":               testBindsFileEnv,
+		"prefers env over file env":    testPrefersEnvOverFileEnv,
+		"prefers flag over file env":   testPrefersFlagOverFileEnv,
+		"rejects unreadable file":      testRejectsUnreadableFile,
+		"redacts file reference":       testRedactsFileReference,
+		"reloads changed secret file":  testReloadsChangedSecretFile,
+		"ignores non-secret reference": testIgnoresNonSecretReference,
+	} {
+		t.Run(scenario, func(t *testing.T) {
+			viper.Reset()
+			defer viper.Reset()
+
+			file := filepath.Join(t.TempDir(), "admin-token")
+			require.NoError(t, os.WriteFile(file, []byte("s3cr3t\n"), 0600))
+
+			fn(t, file)
+		})
+	}
+}
+
+func testReadsFileReference(t *testing.T, file string) {
+	newFlags(t)
+	viper.Set(adminToken, secretFileScheme+file)
+
+	var config appConfig
+	require.NoError(t, createConfig(&config))
+	require.Equal(t, "s3cr3t", config.Admin.Token)
+	require.Equal(t, []string{file}, secretFiles())
+}
+
+func testBindsFileEnv(t *testing.T, file string) {
+	t.Setenv("ADMIN_TOKEN_FILE", file)
+	flags := newFlags(t)
+	require.NoError(t, (&cli{}).bindEnv())
+	bindSecretFiles(flags)
+
+	value, err := secretValue(adminToken)
+	require.NoError(t, err)
+	require.Equal(t, "s3cr3t", value)
+	require.Equal(t, sourceEnvFile, keySource(flags, adminToken))
+
+	// The '*_FILE' variables are not bound to the key themselves
+	require.Equal(t, []string{"ADMIN_TOKEN"}, envKeys[adminToken])
+}
+
+func testPrefersEnvOverFileEnv(t *testing.T, file string) {
+	t.Setenv("ADMIN_TOKEN_FILE", file)
+	t.Setenv("ADMIN_TOKEN", "from-env")
+	flags := newFlags(t)
+	require.NoError(t, (&cli{}).bindEnv())
+	bindSecretFiles(flags)
+
+	value, err := secretValue(adminToken)
+	require.NoError(t, err)
+	require.Equal(t, "from-env", value)
+}
+
+func testPrefersFlagOverFileEnv(t *testing.T, file string) {
+	t.Setenv("ADMIN_TOKEN_FILE", file)
+	flags := newFlags(t)
+	require.NoError(t, flags.Set(adminToken, "from-flag"))
+	require.NoError(t, (&cli{}).bindEnv())
+	bindSecretFiles(flags)
+
+	value, err := secretValue(adminToken)
+	require.NoError(t, err)
+	require.Equal(t, "from-flag", value)
+}
+
+func testRejectsUnreadableFile(t *testing.T, file string) {
+	newFlags(t)
+	viper.Set(adminToken, secretFileScheme+file+".missing")
+
+	require.Equal(t, []string{adminToken}, validationErrs(t))
+
+	var config appConfig
+	require.Error(t, createConfig(&config))
+}
+
+func testRedactsFileReference(t *testing.T, file string) {
+	newFlags(t)
+	viper.Set(adminToken, secretFileScheme+file)
+
+	require.Equal(t, admin.Redacted, formatValue(configSchema()[adminToken], viper.Get(adminToken)))
+}
+
+func testReloadsChangedSecretFile(t *testing.T, file string) {
+	newFlags(t)
+	viper.Set(adminToken, secretFileScheme+file)
+
+	l, _ := logger.NewNullLogger()
+	registry, err := metrics.NewRegistry(metrics.Config{})
+	require.NoError(t, err)
+
+	r, err := newConfigReloader(l, configSettings(), registry)
+	require.NoError(t, err)
+
+	var mu sync.Mutex
+	var tokens []string
+	r.Subscribe("admin", []string{adminToken}, nil, func(c appConfig) error {
+		mu.Lock()
+		defer mu.Unlock()
+		tokens = append(tokens, c.Admin.Token)
+		return nil
+	})
+
+	done := make(chan error, 1)
+	go func() { done <- r.Run() }()
+	defer func() {
+		require.NoError(t, r.Stop(context.Background()))
+		require.NoError(t, <-done)
+	}()
+
+	// The file is written until the watcher has started and found the change
+	require.Eventually(t, func() bool {
+		if err := os.WriteFile(file, []byte("rotated\n"), 0600); err != nil {
+			return false
+		}
+
+		mu.Lock()
+		defer mu.Unlock()
+		return len(tokens) > 0 && tokens[len(tokens)-1] == "rotated"
+	}, 2*time.Second, 50*time.Millisecond)
+}
+
+func testIgnoresNonSecretReference(t *testing.T, file string) {
+	newFlags(t)
+	viper.Set(ginMode, secretFileScheme+file)
+
+	require.Empty(t, secretFiles())
+	require.Equal(t, []string{ginMode}, validationErrs(t))
+}
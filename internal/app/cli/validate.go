@@ -0,0 +1,123 @@
+package cli
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/spf13/viper"
+)
+
+// validationError is the invalid value of the configuration key.
+type validationError struct {
+	key    string
+	value  interface{}
+	reason string
+}
+
+// Error returns the human-readable error, for example:
+// http.port: "80a" should be an integer
+func (e validationError) Error() string {
+	if e.value == nil {
+		return fmt.Sprintf("%s: %s", e.key, e.reason)
+	}
+	return fmt.Sprintf("%s: %q %s", e.key, fmt.Sprint(e.value), e.reason)
+}
+
+// validationErrors are all the invalid values of the configuration.
+type validationErrors []validationError
+
+// Error returns the list of the invalid values, one per line.
+func (e validationErrors) Error() string {
+	lines := make([]string, 0, len(e)+1)
+	lines = append(lines, fmt.Sprintf("invalid configuration, %d error(s):", len(e)))
+	for _, err := range e {
+		lines = append(lines, "  - "+err.Error())
+	}
+	return strings.Join(lines, "\n")
+}
+
+// validateConfig validates the values of all the configuration keys, that
+// are loaded by viper, against their schemas, and rejects the unknown keys
+// of the configuration file. All the invalid values are reported at once.
+func validateConfig() error {
+	schema := configSchema()
+
+	var errs validationErrors
+	for key, s := range schema {
+		raw := viper.Get(key)
+		if raw == nil {
+			continue
+		}
+
+		value, err := s.parse(raw)
+		if err == nil {
+			err = s.check(value)
+		}
+		if err == nil && s.Secret {
+			_, err = secretValue(key)
+		}
+
+		if err != nil {
+			if s.Secret {
+				raw = nil
+			}
+			errs = append(errs, validationError{key: key, value: raw, reason: err.Error()})
+		}
+	}
+
+	for _, key := range unknownKeys(schema) {
+		errs = append(errs, validationError{key: key, reason: "unknown configuration key"})
+	}
+
+	// The keys, that depend on each other, are checked once they are all valid
+	if len(errs) == 0 {
+		errs = dependentErrors()
+	}
+
+	if len(errs) == 0 {
+		return nil
+	}
+
+	sort.Slice(errs, func(i, j int) bool { return errs[i].key < errs[j].key })
+	return errs
+}
+
+// dependentErrors checks the keys, that depend on each other,
+// for example the log file output requires the log file path.
+func dependentErrors() validationErrors {
+	var errs validationErrors
+	for _, sink := range createLogSinks() {
+		if err := sink.Validate(); err != nil {
+			errs = append(errs, validationError{key: logOutputs, reason: fmt.Sprintf("%s output: %s", sink.Type, err)})
+		}
+	}
+	if err := createLogRedact().Validate(); err != nil {
+		errs = append(errs, validationError{key: logRedactPatterns, reason: err.Error()})
+	}
+	return errs
+}
+
+// unknownKeys returns the keys of the configuration file that have no schema.
+// The entries of the map keys, for example "metrics.buckets.<metric>", are known.
+func unknownKeys(schema map[string]keySchema) []string {
+	var unknown []string
+	for _, key := range viper.AllKeys() {
+		if _, ok := schema[key]; ok || key == configFile {
+			continue
+		}
+
+		known := false
+		for k, s := range schema {
+			if s.Type == typeMap && strings.HasPrefix(key, k+".") {
+				known = true
+				break
+			}
+		}
+
+		if !known {
+			unknown = append(unknown, key)
+		}
+	}
+	return unknown
+}
@@ -0,0 +1,301 @@
+package cli
+
+import (
+	"testing"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/require"
+)
+
+func TestValidateConfig(t *testing.T) {
+	for scenario, fn := range map[string]func(t *testing.T){
+		"accepts defaults":                 testAcceptsDefaults,
+		"accepts typed strings":            testAcceptsTypedStrings,
+		"rejects non-numeric port":         testRejectsNonNumericPort,
+		"rejects port out of range":        testRejectsPortOutOfRange,
+		"rejects unknown enum value":       testRejectsUnknownEnumValue,
+		"rejects invalid duration":         testRejectsInvalidDuration,
+		"rejects non-positive":             testRejectsNonPositive,
+		"rejects invalid formats":          testRejectsInvalidFormats,
+		"rejects unknown keys":             testRejectsUnknownKeys,
+		"accepts map entries":              testAcceptsMapEntries,
+		"aggregates all errors":            testAggregatesAllErrors,
+		"hides invalid secret values":      testHidesInvalidSecretValues,
+		"schema covers all flags":          testSchemaCoversAllFlags,
+		"json schema nests keys":           testJsonSchemaNestsKeys,
+		"formats values with secrets":      testFormatsValuesWithSecrets,
+		"reports flag source":              testReportsFlagSource,
+		"rejects unknown log output":       testRejectsUnknownLogOutput,
+		"rejects file output without path": testRejectsFileOutputWithoutPath,
+		"rejects invalid redact pattern":   testRejectsInvalidRedactPattern,
+		"binds environment":                testBindsEnvironment,
+		"prefers prefixed environment":     testPrefersPrefixedEnvironment,
+		"parses environment lists":         testParsesEnvironmentLists,
+		"generates flag usage":             testGeneratesFlagUsage,
+		"generates docs":                   testGeneratesDocs,
+	} {
+		t.Run(scenario, func(t *testing.T) {
+			viper.Reset()
+			defer viper.Reset()
+
+			fn(t)
+		})
+	}
+}
+
+// newFlags creates the command with all the configuration flags bound to viper.
+func newFlags(t *testing.T) *pflag.FlagSet {
+	cmd := &cobra.Command{Use: serviceName}
+	require.NoError(t, setupFlags(cmd))
+	return cmd.PersistentFlags()
+}
+
+// validationErrs validates the configuration and returns the keys of the invalid values.
+func validationErrs(t *testing.T) []string {
+	err := validateConfig()
+	if err == nil {
+		return nil
+	}
+
+	var errs validationErrors
+	require.ErrorAs(t, err, &errs)
+
+	keys := make([]string, len(errs))
+	for i, e := range errs {
+		keys[i] = e.key
+	}
+	return keys
+}
+
+func testAcceptsDefaults(t *testing.T) {
+	newFlags(t)
+	require.NoError(t, validateConfig())
+}
+
+func testAcceptsTypedStrings(t *testing.T) {
+	newFlags(t)
+	viper.Set(httpPort, "9090")
+	viper.Set(gistsSweeperBatchSize, "50")
+	viper.Set(tracingSampleRatio, "0.5")
+	viper.Set(reloadWatch, "false")
+	viper.Set(sloWindow, "2h")
+	viper.Set(tlsListenerHttp+"."+tlsCipherSuites, "TLS_ECDHE_ECDSA_WITH_AES_128_GCM_SHA256,TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256")
+
+	require.NoError(t, validateConfig())
+}
+
+func testRejectsNonNumericPort(t *testing.T) {
+	newFlags(t)
+	viper.Set(httpPort, "80a")
+
+	err := validateConfig()
+	require.EqualError(t, err, "invalid configuration, 1 error(s):\n  - http.port: \"80a\" should be an integer")
+}
+
+func testRejectsPortOutOfRange(t *testing.T) {
+	newFlags(t)
+	viper.Set(httpPort, 70000)
+
+	require.Equal(t, []string{httpPort}, validationErrs(t))
+}
+
+func testRejectsUnknownEnumValue(t *testing.T) {
+	newFlags(t)
+	viper.Set(ginMode, "prod")
+	viper.Set(logFormatter, "xml")
+
+	require.Equal(t, []string{ginMode, logFormatter}, validationErrs(t))
+}
+
+func testRejectsInvalidDuration(t *testing.T) {
+	newFlags(t)
+	viper.Set(statusCheckInterval, "10")
+
+	require.Equal(t, []string{statusCheckInterval}, validationErrs(t))
+}
+
+func testRejectsNonPositive(t *testing.T) {
+	newFlags(t)
+	viper.Set(shutdownDrainTimeout, "0s")
+	viper.Set(gistsTrashBatchSize, 0)
+
+	require.Equal(t, []string{gistsTrashBatchSize, shutdownDrainTimeout}, validationErrs(t))
+}
+
+func testRejectsInvalidFormats(t *testing.T) {
+	newFlags(t)
+	viper.Set(statusRpcAddr, "8400")
+	viper.Set(metricsPushUrl, "pushgateway")
+	viper.Set(metricsPrometheusPath, "metrics")
+
+	require.Equal(t, []string{metricsPrometheusPath, metricsPushUrl, statusRpcAddr}, validationErrs(t))
+}
+
+func testRejectsUnknownKeys(t *testing.T) {
+	newFlags(t)
+	viper.Set("log.levle", "debug")
+
+	require.Equal(t, []string{"log.levle"}, validationErrs(t))
+}
+
+func testAcceptsMapEntries(t *testing.T) {
+	newFlags(t)
+	viper.Set(metricsBuckets, map[string]interface{}{"request_durations_histogram_seconds": "exponential:0.005:2:12"})
+
+	require.NoError(t, validateConfig())
+}
+
+func testAggregatesAllErrors(t *testing.T) {
+	newFlags(t)
+	viper.Set(httpPort, "abc")
+	viper.Set(logLevel, "verbose")
+	viper.Set(secretsPolicy, "ignore")
+
+	require.Equal(t, []string{httpPort, logLevel, secretsPolicy}, validationErrs(t))
+}
+
+func testHidesInvalidSecretValues(t *testing.T) {
+	newFlags(t)
+	viper.Set(adminToken, []string{"s3cr3t"})
+
+	err := validateConfig()
+	require.Error(t, err)
+	require.NotContains(t, err.Error(), "s3cr3t")
+}
+
+func testSchemaCoversAllFlags(t *testing.T) {
+	flags := newFlags(t)
+	schema := configSchema()
+
+	flags.VisitAll(func(flag *pflag.Flag) {
+		if flag.Name == configFile {
+			return
+		}
+		require.Contains(t, schema, flag.Name)
+	})
+
+	for key := range schema {
+		require.NotNil(t, flags.Lookup(key), key)
+	}
+}
+
+func testJsonSchemaNestsKeys(t *testing.T) {
+	schema := jsonSchema(configOptions())
+
+	http := schema["properties"].(map[string]interface{})["http"].(map[string]interface{})
+	port := http["properties"].(map[string]interface{})["port"].(map[string]interface{})
+
+	require.Equal(t, "integer", port["type"])
+	require.Equal(t, 8080, port["default"])
+	require.Equal(t, 1.0, port["minimum"])
+	require.Equal(t, 65535.0, port["maximum"])
+	require.Equal(t, "HTTP API port.", port["description"])
+
+	admin := schema["properties"].(map[string]interface{})["admin"].(map[string]interface{})
+	token := admin["properties"].(map[string]interface{})["token"].(map[string]interface{})
+	require.NotContains(t, token, "default")
+	require.Equal(t, true, token["writeOnly"])
+}
+
+func testFormatsValuesWithSecrets(t *testing.T) {
+	schema := configSchema()
+
+	require.Equal(t, "[REDACTED]", formatValue(schema[adminToken], "s3cr3t"))
+	require.Equal(t, "", formatValue(schema[adminToken], ""))
+	require.Equal(t, "http://[REDACTED]@gw:9091", formatValue(schema[metricsPushUrl], "http://user:pass@gw:9091"))
+	require.Equal(t, "1m30s", formatValue(schema[sloWindow], "90s"))
+	require.Equal(t, "a=1,b=2", formatValue(schema[sloAvailability], map[string]interface{}{"b": "2", "a": "1"}))
+}
+
+func testReportsFlagSource(t *testing.T) {
+	flags := newFlags(t)
+	require.NoError(t, flags.Set(httpPort, "9090"))
+
+	require.Equal(t, sourceFlag, keySource(flags, httpPort))
+	require.Equal(t, sourceDefault, keySource(flags, ginMode))
+}
+
+func testBindsEnvironment(t *testing.T) {
+	t.Setenv("NODE_NAME", "node-1")
+	t.Setenv("HTTP_GIN_MODE", "debug")
+	flags := newFlags(t)
+	require.NoError(t, (&cli{}).bindEnv())
+
+	require.Equal(t, "node-1", viper.GetString(nodeName))
+	require.Equal(t, "debug", viper.GetString(ginMode))
+	require.Equal(t, sourceEnv, keySource(flags, nodeName))
+	require.Equal(t, sourceDefault, keySource(flags, httpPort))
+}
+
+func testPrefersPrefixedEnvironment(t *testing.T) {
+	t.Setenv("ENV_PREFIX", "gogin")
+	t.Setenv("GOGIN_HTTP_PORT", "9090")
+	t.Setenv("HTTP_PORT", "9091")
+	t.Setenv("LOG_LEVEL", "debug")
+	newFlags(t)
+	require.NoError(t, (&cli{}).bindEnv())
+
+	require.Equal(t, []string{"GOGIN_HTTP_PORT", "HTTP_PORT"}, envKeys[httpPort])
+	require.Equal(t, 9090, viper.GetInt(httpPort))
+	require.Equal(t, "debug", viper.GetString(logLevel))
+}
+
+func testParsesEnvironmentLists(t *testing.T) {
+	t.Setenv("SLO_AVAILABILITY", "/gists=99.9, /health=99")
+	t.Setenv("HTTP_TLS_CIPHER_SUITES", "TLS_AES_128_GCM_SHA256, TLS_AES_256_GCM_SHA384")
+	newFlags(t)
+	require.NoError(t, (&cli{}).bindEnv())
+
+	require.NoError(t, validateConfig())
+	require.Equal(t, map[string]string{"/gists": "99.9", "/health": "99"}, mapValue(sloAvailability))
+	require.Equal(t, []string{"TLS_AES_128_GCM_SHA256", "TLS_AES_256_GCM_SHA384"}, listValue(tlsListenerHttp+"."+tlsCipherSuites))
+}
+
+func testGeneratesFlagUsage(t *testing.T) {
+	flags := newFlags(t)
+
+	flag := flags.Lookup(httpPort)
+	require.Equal(t, "HTTP API port. [$HTTP_PORT]", flag.Usage)
+	require.Equal(t, "8080", flag.DefValue)
+	require.Equal(t, "1s", flags.Lookup(statusCheckTimeout).DefValue)
+}
+
+func testGeneratesDocs(t *testing.T) {
+	reference := docs(configOptions())
+
+	require.Contains(t, reference, "| `--http.port` | `HTTP_PORT` | integer | `8080` | HTTP API port. |")
+	require.Contains(t, reference, "| `--admin.token` | `ADMIN_TOKEN` | string |  |")
+	for _, o := range configOptions() {
+		require.Contains(t, reference, "`--"+o.Key+"`")
+	}
+}
+
+func testRejectsUnknownLogOutput(t *testing.T) {
+	newFlags(t)
+	viper.Set(logOutputs, "stdout,kafka")
+
+	require.Equal(t, []string{logOutputs}, validationErrs(t))
+}
+
+func testRejectsFileOutputWithoutPath(t *testing.T) {
+	newFlags(t)
+	viper.Set(logOutputs, []string{"stdout", "file"})
+
+	err := validateConfig()
+	require.EqualError(t, err, "invalid configuration, 1 error(s):\n  - log.outputs: file output: no log file path provided")
+
+	viper.Set(logFilePath, "/var/log/gogin/gogin.log")
+	require.NoError(t, validateConfig())
+}
+
+func testRejectsInvalidRedactPattern(t *testing.T) {
+	newFlags(t)
+	viper.Set(logRedactPatterns, map[string]string{"github-token": "ghp_[A-Za-z0-9"})
+
+	require.Equal(t, []string{logRedactPatterns}, validationErrs(t))
+
+	viper.Set(logRedactPatterns, "github-token=ghp_[A-Za-z0-9]{36}")
+	require.NoError(t, validateConfig())
+}
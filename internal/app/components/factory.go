@@ -3,42 +3,139 @@ package components
 import (
 	"errors"
 
+	"go.opentelemetry.io/otel/trace"
+
+	"git.lothric.net/examples/go/gogin/internal/app/api/middleware"
 	"git.lothric.net/examples/go/gogin/internal/app/api/v1/handlers"
 	"git.lothric.net/examples/go/gogin/internal/app/logic"
+	"git.lothric.net/examples/go/gogin/internal/app/storage"
 	"git.lothric.net/examples/go/gogin/internal/pkg/logger"
+	"git.lothric.net/examples/go/gogin/internal/pkg/markdown"
 	"git.lothric.net/examples/go/gogin/internal/pkg/metrics"
+	"git.lothric.net/examples/go/gogin/internal/pkg/secrets"
+	"git.lothric.net/examples/go/gogin/internal/pkg/slo"
+	"git.lothric.net/examples/go/gogin/internal/pkg/status"
+	"git.lothric.net/examples/go/gogin/internal/pkg/tracing"
 )
 
 var (
 	// ErrNoLoggerProvided happens when logger is not provided.
 	ErrNoLoggerProvided = errors.New("no logger provided")
+
+	// ErrNoMetricsProvided happens when metrics registry is not provided.
+	ErrNoMetricsProvided = errors.New("no metrics registry provided")
+
+	// ErrNoTracingProvided happens when tracer provider is not provided.
+	ErrNoTracingProvided = errors.New("no tracer provider provided")
+)
+
+const (
+
+	// Names of the traced components
+	tracerApi     = "gogin/api"
+	tracerLogic   = "gogin/logic"
+	tracerStorage = "gogin/storage"
+
+	// Names of the health checked components,
+	// they are the service names of the gRPC health server
+	healthGists       = "storage.gists"
+	healthCollections = "storage.collections"
 )
 
+// Config defines the configuration of the components
+// that are created by the component factory.
+type Config struct {
+
+	// Secrets is the configuration of the gists secret scanner.
+	Secrets secrets.Config
+
+	// Sweeper is the configuration of the expired gists sweeper.
+	Sweeper logic.SweeperConfig
+
+	// Trash is the configuration of the gists trash.
+	Trash logic.TrashConfig
+
+	// Slo is the configuration of the service level objectives.
+	Slo slo.Config
+}
+
 // componentFactory is a factory that creates components that are required
 // for construction of API Handlers.
 type componentFactory struct {
-	log logger.Log
+	log         logger.Log
+	config      Config
+	metrics     *metrics.Registry
+	tracing     *tracing.Provider
+	gists       logic.GistsRepository
+	collections logic.CollectionsRepository
+	checkers    map[string]status.Checker
+	sweeper     *logic.GistsSweeper
+	slo         *slo.Tracker
 }
 
 // NewComponentFactory creates a new instance of the component factory.
 func NewComponentFactory(
 	log logger.Log,
+	config Config,
+	registry *metrics.Registry,
+	provider *tracing.Provider,
 ) (*componentFactory, error) {
 	if log == nil {
 		return nil, ErrNoLoggerProvided
 	}
 
+	if registry == nil {
+		return nil, ErrNoMetricsProvided
+	}
+
+	if provider == nil {
+		return nil, ErrNoTracingProvided
+	}
+
+	// The storage is shared between all components
+	memoryGists, err := storage.NewMemoryGists(log)
+	if err != nil {
+		return nil, err
+	}
+
+	gists, err := storage.NewTracedGists(memoryGists, provider.Tracer(tracerStorage))
+	if err != nil {
+		return nil, err
+	}
+
+	collections, err := storage.NewMemoryCollections(log)
+	if err != nil {
+		return nil, err
+	}
+
 	return &componentFactory{
-		log: log,
+		log:         log,
+		config:      config,
+		metrics:     registry,
+		tracing:     provider,
+		gists:       gists,
+		collections: collections,
+		checkers: map[string]status.Checker{
+			healthGists:       memoryGists,
+			healthCollections: collections,
+		},
 	}, nil
 }
 
 // CreateApiMetricsReporter create a new API Metrics Reporter.
-func (f *componentFactory) CreateApiMetricsReporter() (handlers.ApiMetricsReporter, error) {
+func (f *componentFactory) CreateApiMetricsReporter() (middleware.ApiMetricsReporter, error) {
 	log := f.log.WithField(logger.FieldFunction, "CreateApiMetricsReporter")
 	log.Info("Creating Api Metrics Reporter")
 
-	return metrics.NewReporter(log)
+	return metrics.NewReporter(log, f.metrics)
+}
+
+// CreateApiTracer creates a tracer of the HTTP API requests.
+func (f *componentFactory) CreateApiTracer() (trace.Tracer, error) {
+	log := f.log.WithField(logger.FieldFunction, "CreateApiTracer")
+	log.Info("Creating Api Tracer")
+
+	return f.tracing.Tracer(tracerApi), nil
 }
 
 // CreateGistsLogic creates a business logic for Gists.
@@ -46,11 +143,110 @@ func (f *componentFactory) CreateGistsLogic() (handlers.GistsLogic, error) {
 	log := f.log.WithField(logger.FieldFunction, "CreateGistsLogic")
 	log.Info("Creating Gists logic")
 
-	reporter, err := metrics.NewReporter(log)
+	reporter, err := metrics.NewReporter(log, f.metrics)
 	if err != nil {
 		log.Error(err, "Failed to create business logic Metrics Reporter")
 		return nil, err
 	}
 
-	return logic.NewGistsLogic(log, reporter)
+	renderer, err := markdown.NewRenderer()
+	if err != nil {
+		log.Error(err, "Failed to create markdown renderer")
+		return nil, err
+	}
+
+	scanner, err := secrets.NewScanner(f.config.Secrets)
+	if err != nil {
+		log.Error(err, "Failed to create secret scanner")
+		return nil, err
+	}
+
+	return logic.NewGistsLogic(log, reporter, f.tracing.Tracer(tracerLogic), f.gists, renderer, scanner)
+}
+
+// CreateCollectionsLogic creates a business logic for gist Collections.
+func (f *componentFactory) CreateCollectionsLogic() (handlers.CollectionsLogic, error) {
+	log := f.log.WithField(logger.FieldFunction, "CreateCollectionsLogic")
+	log.Info("Creating Collections logic")
+
+	return logic.NewCollectionsLogic(log, f.collections, f.gists)
+}
+
+// CreateGistsSweeper creates a sweeper of the expired gists.
+//
+// The sweeper is created only once and the same instance is
+// returned on consecutive calls, so the scheduled sweeps and
+// the sweeps requested via API are never executed concurrently.
+func (f *componentFactory) CreateGistsSweeper() (*logic.GistsSweeper, error) {
+	log := f.log.WithField(logger.FieldFunction, "CreateGistsSweeper")
+
+	if f.sweeper != nil {
+		return f.sweeper, nil
+	}
+	log.Info("Creating Gists sweeper")
+
+	reporter, err := metrics.NewReporter(log, f.metrics)
+	if err != nil {
+		log.Error(err, "Failed to create sweeper Metrics Reporter")
+		return nil, err
+	}
+
+	sweeper, err := logic.NewGistsSweeper(log, reporter, f.gists, f.config.Sweeper)
+	if err != nil {
+		log.Error(err, "Failed to create Gists sweeper")
+		return nil, err
+	}
+
+	f.sweeper = sweeper
+	return sweeper, nil
+}
+
+// CreateTrashPurger creates a purger of the trashed gists.
+func (f *componentFactory) CreateTrashPurger() (*logic.TrashPurger, error) {
+	log := f.log.WithField(logger.FieldFunction, "CreateTrashPurger")
+	log.Info("Creating Trash purger")
+
+	reporter, err := metrics.NewReporter(log, f.metrics)
+	if err != nil {
+		log.Error(err, "Failed to create purger Metrics Reporter")
+		return nil, err
+	}
+
+	return logic.NewTrashPurger(log, reporter, f.gists, f.config.Trash)
+}
+
+// CreateSloTracker creates a tracker of the service level objectives.
+//
+// The tracker is created only once and the same instance is
+// returned on consecutive calls, because its metrics could be
+// registered only once.
+func (f *componentFactory) CreateSloTracker() (*slo.Tracker, error) {
+	log := f.log.WithField(logger.FieldFunction, "CreateSloTracker")
+
+	if f.slo != nil {
+		return f.slo, nil
+	}
+	log.Info("Creating SLO tracker")
+
+	tracker, err := slo.NewTracker(log, f.config.Slo, f.metrics)
+	if err != nil {
+		log.Error(err, "Failed to create SLO tracker")
+		return nil, err
+	}
+
+	f.slo = tracker
+	return tracker, nil
+}
+
+// CreateHealthCheckers creates the health checkers
+// of the components by the health service names.
+func (f *componentFactory) CreateHealthCheckers() (map[string]status.Checker, error) {
+	log := f.log.WithField(logger.FieldFunction, "CreateHealthCheckers")
+	log.Info("Creating health checkers")
+
+	checkers := make(map[string]status.Checker, len(f.checkers))
+	for service, checker := range f.checkers {
+		checkers[service] = checker
+	}
+	return checkers, nil
 }
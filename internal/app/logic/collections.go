@@ -0,0 +1,332 @@
+package logic
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+
+	"git.lothric.net/examples/go/gogin/internal/pkg/logger"
+)
+
+var (
+	// ErrNoCollectionsRepositoryProvided happens when collections repository is not provided.
+	ErrNoCollectionsRepositoryProvided = errors.New("no collections repository provided")
+
+	// ErrCollectionNotFound happens when the requested collection doesn't exist.
+	ErrCollectionNotFound = errors.New("collection not found")
+
+	// ErrDuplicateCollectionGist happens when the same gist
+	// is listed more than once in the collection.
+	ErrDuplicateCollectionGist = errors.New("duplicate collection gist")
+
+	// ErrCollectionGistNotFound happens when the gist is not a member of the collection.
+	ErrCollectionGistNotFound = errors.New("collection gist not found")
+)
+
+// Collection is a curated and ordered group of gists.
+type Collection struct {
+	Id          string
+	Name        string
+	Description string
+
+	// GistIds are the ids of the member gists in the collection order.
+	GistIds []string
+
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
+
+// CollectionsRepository defines a storage that persists the collections.
+type CollectionsRepository interface {
+
+	// List returns all stored collections.
+	List(ctx context.Context) ([]Collection, error)
+
+	// Get returns the collection with the specified 'id'
+	// or ErrCollectionNotFound if the collection doesn't exist.
+	Get(ctx context.Context, id string) (Collection, error)
+
+	// Save creates a new collection or replaces the existing one.
+	Save(ctx context.Context, collection Collection) error
+
+	// Delete removes the collection with the specified 'id'
+	// or returns ErrCollectionNotFound if the collection doesn't exist.
+	Delete(ctx context.Context, id string) error
+}
+
+// CollectionsLogic implements business rules for the gist Collections.
+//
+// Collections follow the same visibility rules as the gists,
+// so the expired and trashed gists are never listed in a collection.
+type CollectionsLogic struct {
+	log         logger.Log
+	collections CollectionsRepository
+	gists       GistsRepository
+	now         func() time.Time
+
+	// mu serializes the collection modifications,
+	// so the concurrent changes of membership are not lost.
+	mu sync.Mutex
+}
+
+// NewCollectionsLogic creates a new instance of CollectionsLogic that
+// defines business rules and logic to handle them.
+func NewCollectionsLogic(
+	log logger.Log,
+	collections CollectionsRepository,
+	gists GistsRepository,
+) (*CollectionsLogic, error) {
+
+	if log == nil {
+		return nil, ErrNoLoggerProvided
+	}
+
+	if collections == nil {
+		return nil, ErrNoCollectionsRepositoryProvided
+	}
+
+	if gists == nil {
+		return nil, ErrNoRepositoryProvided
+	}
+
+	return &CollectionsLogic{
+		log:         log,
+		collections: collections,
+		gists:       gists,
+		now:         time.Now,
+	}, nil
+}
+
+// GetCollections returns all the Collections.
+func (c *CollectionsLogic) GetCollections(ctx context.Context) ([]Collection, error) {
+	log := logger.FromContext(c.log, ctx, "GetCollections")
+	log.Info("Handling GetCollections")
+
+	collections, err := c.collections.List(ctx)
+	if err != nil {
+		log.Error(err, "Failed to list collections")
+		return nil, err
+	}
+
+	return collections, nil
+}
+
+// GetCollection returns the Collection with the specified 'id'.
+func (c *CollectionsLogic) GetCollection(ctx context.Context, id string) (Collection, error) {
+	log := logger.FromContext(c.log, ctx, "GetCollection")
+	log.Info("Handling GetCollection")
+
+	return c.collections.Get(ctx, id)
+}
+
+// CreateCollection stores a new empty Collection and assigns a unique id to it.
+func (c *CollectionsLogic) CreateCollection(ctx context.Context, collection Collection) (Collection, error) {
+	log := logger.FromContext(c.log, ctx, "CreateCollection")
+	log.Info("Handling CreateCollection")
+
+	now := c.now()
+	collection.Id = uuid.NewString()
+	collection.GistIds = []string{}
+	collection.CreatedAt = now
+	collection.UpdatedAt = now
+
+	if err := c.collections.Save(ctx, collection); err != nil {
+		log.Error(err, "Failed to save a new collection")
+		return Collection{}, err
+	}
+
+	return collection, nil
+}
+
+// RenameCollection changes the name of the Collection with the specified 'id'.
+func (c *CollectionsLogic) RenameCollection(ctx context.Context, id string, name string) (Collection, error) {
+	log := logger.FromContext(c.log, ctx, "RenameCollection")
+	log.Info("Handling RenameCollection")
+
+	return c.modify(ctx, id, func(collection *Collection) error {
+		collection.Name = name
+		return nil
+	})
+}
+
+// DeleteCollection deletes the Collection with the specified 'id'.
+// The member gists are not affected.
+func (c *CollectionsLogic) DeleteCollection(ctx context.Context, id string) error {
+	log := logger.FromContext(c.log, ctx, "DeleteCollection")
+	log.Info("Handling DeleteCollection")
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.collections.Delete(ctx, id)
+}
+
+// GetCollectionGists returns up to 'limit' visible member Gists of the Collection
+// with the specified 'id' in the collection order, skipping the first 'offset'
+// visible Gists. The zero 'limit' means no limit.
+func (c *CollectionsLogic) GetCollectionGists(ctx context.Context, id string, offset int, limit int) ([]Gist, error) {
+	log := logger.FromContext(c.log, ctx, "GetCollectionGists")
+	log.Info("Handling GetCollectionGists")
+
+	collection, err := c.collections.Get(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	now := c.now()
+	gists := make([]Gist, 0)
+	for _, gistId := range collection.GistIds {
+		// The gists after the page are not loaded at all
+		if limit > 0 && len(gists) == limit {
+			break
+		}
+
+		gist, err := c.gists.Get(ctx, gistId)
+		switch {
+		case errors.Is(err, ErrGistNotFound):
+			// The purged gists are skipped
+			continue
+		case err != nil:
+			log.Error(err, "Failed to get the collection gist")
+			return nil, err
+		}
+
+		if !gist.IsVisible(now) {
+			continue
+		}
+		if offset > 0 {
+			offset--
+			continue
+		}
+		gists = append(gists, gist)
+	}
+
+	return gists, nil
+}
+
+// AddCollectionGist adds the Gist to the Collection with the specified 'id'
+// at the 'position', or at the end if the 'position' is negative or out of range.
+// If the Gist is already a member, it is moved to the 'position'.
+func (c *CollectionsLogic) AddCollectionGist(
+	ctx context.Context,
+	id string,
+	gistId string,
+	position int,
+) (Collection, error) {
+	log := logger.FromContext(c.log, ctx, "AddCollectionGist")
+	log.Info("Handling AddCollectionGist")
+
+	if err := c.ensureVisible(ctx, gistId); err != nil {
+		return Collection{}, err
+	}
+
+	return c.modify(ctx, id, func(collection *Collection) error {
+		gistIds := without(collection.GistIds, gistId)
+		if position < 0 || position > len(gistIds) {
+			position = len(gistIds)
+		}
+
+		gistIds = append(gistIds, "")
+		copy(gistIds[position+1:], gistIds[position:])
+		gistIds[position] = gistId
+
+		collection.GistIds = gistIds
+		return nil
+	})
+}
+
+// RemoveCollectionGist removes the Gist from the Collection with the specified 'id'.
+func (c *CollectionsLogic) RemoveCollectionGist(ctx context.Context, id string, gistId string) (Collection, error) {
+	log := logger.FromContext(c.log, ctx, "RemoveCollectionGist")
+	log.Info("Handling RemoveCollectionGist")
+
+	return c.modify(ctx, id, func(collection *Collection) error {
+		gistIds := without(collection.GistIds, gistId)
+		if len(gistIds) == len(collection.GistIds) {
+			return ErrCollectionGistNotFound
+		}
+
+		collection.GistIds = gistIds
+		return nil
+	})
+}
+
+// SetCollectionGists replaces the members of the Collection with
+// the specified 'id' with the 'gistIds' in the provided order.
+func (c *CollectionsLogic) SetCollectionGists(ctx context.Context, id string, gistIds []string) (Collection, error) {
+	log := logger.FromContext(c.log, ctx, "SetCollectionGists")
+	log.Info("Handling SetCollectionGists")
+
+	seen := make(map[string]bool, len(gistIds))
+	for _, gistId := range gistIds {
+		if seen[gistId] {
+			return Collection{}, ErrDuplicateCollectionGist
+		}
+		seen[gistId] = true
+
+		if err := c.ensureVisible(ctx, gistId); err != nil {
+			return Collection{}, err
+		}
+	}
+
+	return c.modify(ctx, id, func(collection *Collection) error {
+		collection.GistIds = append([]string{}, gistIds...)
+		return nil
+	})
+}
+
+// modify applies the 'change' to the Collection with the specified 'id' and saves it.
+func (c *CollectionsLogic) modify(
+	ctx context.Context,
+	id string,
+	change func(collection *Collection) error,
+) (Collection, error) {
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	collection, err := c.collections.Get(ctx, id)
+	if err != nil {
+		return Collection{}, err
+	}
+
+	if err := change(&collection); err != nil {
+		return Collection{}, err
+	}
+
+	collection.UpdatedAt = c.now()
+	if err := c.collections.Save(ctx, collection); err != nil {
+		return Collection{}, err
+	}
+
+	return collection, nil
+}
+
+// ensureVisible returns ErrGistNotFound if the Gist
+// with the specified 'id' doesn't exist or is not visible.
+func (c *CollectionsLogic) ensureVisible(ctx context.Context, gistId string) error {
+	gist, err := c.gists.Get(ctx, gistId)
+	if err != nil {
+		return err
+	}
+
+	if !gist.IsVisible(c.now()) {
+		return ErrGistNotFound
+	}
+
+	return nil
+}
+
+// without returns a copy of 'ids' without the 'id'.
+func without(ids []string, id string) []string {
+	result := make([]string, 0, len(ids))
+	for _, i := range ids {
+		if i != id {
+			result = append(result, i)
+		}
+	}
+	return result
+}
@@ -0,0 +1,309 @@
+package logic
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"git.lothric.net/examples/go/gogin/internal/pkg/logger"
+)
+
+func TestCollectionsLogic(t *testing.T) {
+	for scenario, fn := range map[string]func(
+		t *testing.T,
+		c *CollectionsLogic,
+		r *repositoryMock,
+	){
+		"adds gists in order":                     testAddsGistsInOrder,
+		"moves existing gist to new position":     testMovesExistingGist,
+		"removes gist from collection":            testRemovesGistFromCollection,
+		"reorders collection gists":               testReordersCollectionGists,
+		"rejects duplicate gists on reorder":      testRejectsDuplicateGists,
+		"hides expired and trashed gists":         testHidesInvisibleCollectionGists,
+		"pages collection gists":                  testPagesCollectionGists,
+		"fails to add invisible gist":             testFailsToAddInvisibleGist,
+		"renames and deletes collection":          testRenamesAndDeletesCollection,
+		"fails to modify non existent collection": testFailsToModifyNonExistentCollection,
+	} {
+		t.Run(scenario, func(t *testing.T) {
+			log, _ := logger.NewNullLogger()
+			repository := &repositoryMock{gists: map[string]Gist{
+				"one":   {Id: "one", Name: "One", Language: "go"},
+				"two":   {Id: "two", Name: "Two", Language: "go"},
+				"three": {Id: "three", Name: "Three", Language: "go"},
+			}}
+
+			collections, err := NewCollectionsLogic(
+				log,
+				&collectionsMock{collections: map[string]Collection{}},
+				repository,
+			)
+			require.NoError(t, err)
+
+			fn(t, collections, repository)
+		})
+	}
+}
+
+type collectionsMock struct {
+	collections map[string]Collection
+}
+
+func (r *collectionsMock) List(ctx context.Context) ([]Collection, error) {
+	result := make([]Collection, 0, len(r.collections))
+	for _, collection := range r.collections {
+		result = append(result, collection)
+	}
+	return result, nil
+}
+
+func (r *collectionsMock) Get(ctx context.Context, id string) (Collection, error) {
+	collection, ok := r.collections[id]
+	if !ok {
+		return Collection{}, ErrCollectionNotFound
+	}
+	return collection, nil
+}
+
+func (r *collectionsMock) Save(ctx context.Context, collection Collection) error {
+	r.collections[collection.Id] = collection
+	return nil
+}
+
+func (r *collectionsMock) Delete(ctx context.Context, id string) error {
+	if _, ok := r.collections[id]; !ok {
+		return ErrCollectionNotFound
+	}
+	delete(r.collections, id)
+	return nil
+}
+
+func newCollection(t *testing.T, c *CollectionsLogic, gistIds ...string) Collection {
+	collection, err := c.CreateCollection(context.Background(), Collection{Name: "Favorites"})
+	require.NoError(t, err)
+
+	for _, gistId := range gistIds {
+		collection, err = c.AddCollectionGist(context.Background(), collection.Id, gistId, -1)
+		require.NoError(t, err)
+	}
+	return collection
+}
+
+func testAddsGistsInOrder(
+	t *testing.T,
+	c *CollectionsLogic,
+	r *repositoryMock,
+) {
+
+	collection := newCollection(t, c, "one", "two")
+
+	collection, err := c.AddCollectionGist(context.Background(), collection.Id, "three", 0)
+	require.NoError(t, err)
+	require.Equal(t, []string{"three", "one", "two"}, collection.GistIds)
+
+	gists, err := c.GetCollectionGists(context.Background(), collection.Id, 0, 0)
+	require.NoError(t, err)
+	require.Len(t, gists, 3)
+	require.Equal(t, "three", gists[0].Id)
+	require.Equal(t, "one", gists[1].Id)
+	require.Equal(t, "two", gists[2].Id)
+}
+
+func testMovesExistingGist(
+	t *testing.T,
+	c *CollectionsLogic,
+	r *repositoryMock,
+) {
+
+	collection := newCollection(t, c, "one", "two", "three")
+
+	collection, err := c.AddCollectionGist(context.Background(), collection.Id, "one", 1)
+	require.NoError(t, err)
+	require.Equal(t, []string{"two", "one", "three"}, collection.GistIds)
+
+	collection, err = c.AddCollectionGist(context.Background(), collection.Id, "two", 100)
+	require.NoError(t, err)
+	require.Equal(t, []string{"one", "three", "two"}, collection.GistIds)
+}
+
+func testRemovesGistFromCollection(
+	t *testing.T,
+	c *CollectionsLogic,
+	r *repositoryMock,
+) {
+
+	collection := newCollection(t, c, "one", "two")
+
+	collection, err := c.RemoveCollectionGist(context.Background(), collection.Id, "one")
+	require.NoError(t, err)
+	require.Equal(t, []string{"two"}, collection.GistIds)
+
+	_, err = c.RemoveCollectionGist(context.Background(), collection.Id, "one")
+	require.Equal(t, ErrCollectionGistNotFound, err)
+
+	// The gist itself is not deleted
+	require.Contains(t, r.gists, "one")
+}
+
+func testReordersCollectionGists(
+	t *testing.T,
+	c *CollectionsLogic,
+	r *repositoryMock,
+) {
+
+	collection := newCollection(t, c, "one", "two")
+
+	collection, err := c.SetCollectionGists(context.Background(), collection.Id, []string{"three", "one"})
+	require.NoError(t, err)
+	require.Equal(t, []string{"three", "one"}, collection.GistIds)
+
+	collection, err = c.GetCollection(context.Background(), collection.Id)
+	require.NoError(t, err)
+	require.Equal(t, []string{"three", "one"}, collection.GistIds)
+}
+
+func testRejectsDuplicateGists(
+	t *testing.T,
+	c *CollectionsLogic,
+	r *repositoryMock,
+) {
+
+	collection := newCollection(t, c, "one", "two")
+
+	_, err := c.SetCollectionGists(context.Background(), collection.Id, []string{"two", "one", "two"})
+	require.Equal(t, ErrDuplicateCollectionGist, err)
+
+	collection, err = c.GetCollection(context.Background(), collection.Id)
+	require.NoError(t, err)
+	require.Equal(t, []string{"one", "two"}, collection.GistIds)
+}
+
+func testHidesInvisibleCollectionGists(
+	t *testing.T,
+	c *CollectionsLogic,
+	r *repositoryMock,
+) {
+
+	collection := newCollection(t, c, "one", "two", "three")
+
+	expired := r.gists["one"]
+	expired.ExpiresAt = time.Now().Add(-time.Minute)
+	r.gists["one"] = expired
+
+	trashed := r.gists["two"]
+	trashed.DeletedAt = time.Now()
+	r.gists["two"] = trashed
+
+	gists, err := c.GetCollectionGists(context.Background(), collection.Id, 0, 0)
+	require.NoError(t, err)
+	require.Len(t, gists, 1)
+	require.Equal(t, "three", gists[0].Id)
+
+	// The restored gist is back in its place
+	trashed.DeletedAt = time.Time{}
+	r.gists["two"] = trashed
+
+	gists, err = c.GetCollectionGists(context.Background(), collection.Id, 0, 0)
+	require.NoError(t, err)
+	require.Len(t, gists, 2)
+	require.Equal(t, "two", gists[0].Id)
+	require.Equal(t, "three", gists[1].Id)
+}
+
+func testPagesCollectionGists(
+	t *testing.T,
+	c *CollectionsLogic,
+	r *repositoryMock,
+) {
+
+	collection := newCollection(t, c, "one", "two", "three")
+
+	gists, err := c.GetCollectionGists(context.Background(), collection.Id, 0, 2)
+	require.NoError(t, err)
+	require.Len(t, gists, 2)
+	require.Equal(t, "one", gists[0].Id)
+	require.Equal(t, "two", gists[1].Id)
+
+	// The offset counts the visible gists only
+	trashed := r.gists["one"]
+	trashed.DeletedAt = time.Now()
+	r.gists["one"] = trashed
+
+	gists, err = c.GetCollectionGists(context.Background(), collection.Id, 1, 2)
+	require.NoError(t, err)
+	require.Len(t, gists, 1)
+	require.Equal(t, "three", gists[0].Id)
+
+	gists, err = c.GetCollectionGists(context.Background(), collection.Id, 5, 2)
+	require.NoError(t, err)
+	require.Empty(t, gists)
+}
+
+func testFailsToAddInvisibleGist(
+	t *testing.T,
+	c *CollectionsLogic,
+	r *repositoryMock,
+) {
+
+	collection := newCollection(t, c)
+
+	trashed := r.gists["one"]
+	trashed.DeletedAt = time.Now()
+	r.gists["one"] = trashed
+
+	_, err := c.AddCollectionGist(context.Background(), collection.Id, "one", -1)
+	require.Equal(t, ErrGistNotFound, err)
+
+	_, err = c.AddCollectionGist(context.Background(), collection.Id, "unknown", -1)
+	require.Equal(t, ErrGistNotFound, err)
+
+	_, err = c.SetCollectionGists(context.Background(), collection.Id, []string{"one"})
+	require.Equal(t, ErrGistNotFound, err)
+}
+
+func testRenamesAndDeletesCollection(
+	t *testing.T,
+	c *CollectionsLogic,
+	r *repositoryMock,
+) {
+
+	collection := newCollection(t, c, "one")
+
+	renamed, err := c.RenameCollection(context.Background(), collection.Id, "Snippets")
+	require.NoError(t, err)
+	require.Equal(t, "Snippets", renamed.Name)
+	require.Equal(t, []string{"one"}, renamed.GistIds)
+
+	require.NoError(t, c.DeleteCollection(context.Background(), collection.Id))
+
+	collections, err := c.GetCollections(context.Background())
+	require.NoError(t, err)
+	require.Empty(t, collections)
+
+	// The member gists are not deleted
+	require.Contains(t, r.gists, "one")
+}
+
+func testFailsToModifyNonExistentCollection(
+	t *testing.T,
+	c *CollectionsLogic,
+	r *repositoryMock,
+) {
+
+	_, err := c.GetCollection(context.Background(), "unknown")
+	require.Equal(t, ErrCollectionNotFound, err)
+
+	_, err = c.GetCollectionGists(context.Background(), "unknown", 0, 0)
+	require.Equal(t, ErrCollectionNotFound, err)
+
+	_, err = c.RenameCollection(context.Background(), "unknown", "Snippets")
+	require.Equal(t, ErrCollectionNotFound, err)
+
+	_, err = c.AddCollectionGist(context.Background(), "unknown", "one", -1)
+	require.Equal(t, ErrCollectionNotFound, err)
+
+	err = c.DeleteCollection(context.Background(), "unknown")
+	require.Equal(t, ErrCollectionNotFound, err)
+}
@@ -3,8 +3,15 @@ package logic
 import (
 	"context"
 	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"go.opentelemetry.io/otel/trace"
 
 	"git.lothric.net/examples/go/gogin/internal/pkg/logger"
+	"git.lothric.net/examples/go/gogin/internal/pkg/secrets"
 )
 
 var (
@@ -13,18 +20,250 @@ var (
 
 	// ErrNoReporterProvided happens when metrics reporter is not provided.
 	ErrNoReporterProvided = errors.New("no metrics reporter provided")
+
+	// ErrNoTracerProvided happens when tracer is not provided.
+	ErrNoTracerProvided = errors.New("no tracer provided")
+
+	// ErrNoRepositoryProvided happens when gists repository is not provided.
+	ErrNoRepositoryProvided = errors.New("no gists repository provided")
+
+	// ErrNoRendererProvided happens when markdown renderer is not provided.
+	ErrNoRendererProvided = errors.New("no markdown renderer provided")
+
+	// ErrNoScannerProvided happens when secret scanner is not provided.
+	ErrNoScannerProvided = errors.New("no secret scanner provided")
+
+	// ErrGistNotFound happens when the requested gist doesn't exist.
+	ErrGistNotFound = errors.New("gist not found")
+
+	// ErrGistNotRenderable happens when the gist content could not
+	// be rendered, because it is not written in a markup language.
+	ErrGistNotRenderable = errors.New("gist not renderable")
+
+	// ErrInvalidExpiration happens when the gist expiration time is in the past.
+	ErrInvalidExpiration = errors.New("invalid gist expiration")
+
+	// ErrGistTrashed happens when the gist could not be replaced,
+	// because it is in the trash and should be restored first.
+	ErrGistTrashed = errors.New("gist is in the trash")
+
+	// ErrSecretDetected happens when the gist contains secrets
+	// and the secrets policy rejects such gists.
+	ErrSecretDetected = errors.New("secret detected")
+)
+
+// SecretDetectedError is returned when the gist is rejected,
+// because it contains secrets. The error matches ErrSecretDetected.
+type SecretDetectedError struct {
+
+	// Findings are the secrets that have been detected.
+	Findings []secrets.Finding
+}
+
+// Error formats the detected secrets.
+func (e *SecretDetectedError) Error() string {
+	return fmt.Sprintf("%s: %s", ErrSecretDetected, e.Detail())
+}
+
+// Is reports whether the error matches the 'target'.
+func (e *SecretDetectedError) Is(target error) bool {
+	return target == ErrSecretDetected
+}
+
+// Detail lists the lines and the rules of the detected secrets.
+func (e *SecretDetectedError) Detail() string {
+	details := make([]string, 0, len(e.Findings))
+	for _, f := range e.Findings {
+		details = append(details, f.String())
+	}
+	return strings.Join(details, "; ")
+}
+
+// Gist is a source code snippet that is stored in the service.
+type Gist struct {
+	Id          string
+	Name        string
+	Description string
+	Language    string
+	Code        string
+	CreatedAt   time.Time
+	UpdatedAt   time.Time
+	AccessedAt  time.Time
+
+	// ExpiresAt is the time when the gist expires,
+	// the zero time means the gist never expires.
+	ExpiresAt time.Time
+
+	// Ttl is the time to live of the created or replaced gist, that
+	// sets the ExpiresAt once the gist is saved, it is not stored.
+	Ttl time.Duration
+
+	// DeletedAt is the time when the gist has been moved to the trash,
+	// the zero time means the gist is not in the trash.
+	DeletedAt time.Time
+}
+
+// IsExpired reports whether the gist has expired by the time 'at'.
+func (g Gist) IsExpired(at time.Time) bool {
+	return !g.ExpiresAt.IsZero() && !g.ExpiresAt.After(at)
+}
+
+// IsTrashed reports whether the gist has been moved to the trash.
+func (g Gist) IsTrashed() bool {
+	return !g.DeletedAt.IsZero()
+}
+
+// IsVisible reports whether the gist is visible at the time 'at',
+// so it is neither expired nor moved to the trash.
+func (g Gist) IsVisible(at time.Time) bool {
+	return !g.IsExpired(at) && !g.IsTrashed()
+}
+
+// expire sets the expiration time of the 'gist' by its time to live at the time 'now',
+// or returns ErrInvalidExpiration if the expiration is invalid or is in the past.
+func expire(gist Gist, now time.Time) (Gist, error) {
+	switch {
+	case gist.Ttl < 0 || gist.Ttl > 0 && !gist.ExpiresAt.IsZero():
+		return Gist{}, ErrInvalidExpiration
+
+	case gist.Ttl > 0:
+		gist.ExpiresAt = now.Add(gist.Ttl)
+		gist.Ttl = 0
+	}
+
+	if gist.IsExpired(now) {
+		return Gist{}, ErrInvalidExpiration
+	}
+	return gist, nil
+}
+
+// Storage operations that are reported on failures.
+const (
+	storageList        = "list"
+	storageGet         = "get"
+	storageSave        = "save"
+	storageReplace     = "replace"
+	storageTouch       = "touch"
+	storageTrash       = "trash"
+	storageRestore     = "restore"
+	storageDelete      = "delete"
+	storageListExpired = "list_expired"
+	storageListTrashed = "list_trashed"
 )
 
 // MetricsReporter defines a metrics reporter that is used
 // to collect and report usage metrics.
 type MetricsReporter interface {
-	// NOTE: define me
+
+	// GistCreated tracks a new gist written in the 'language' with the code of 'size' bytes.
+	GistCreated(language string, size int)
+
+	// GistUpdated tracks an updated gist written in the 'language' with the code of 'size' bytes.
+	GistUpdated(language string, size int)
+
+	// GistDeleted tracks a gist written in the 'language' moved to the trash.
+	GistDeleted(language string)
+
+	// GistsSearched tracks how long it took to search the gists.
+	GistsSearched(ctx context.Context, duration time.Duration)
+
+	// StorageFailed tracks a failed storage 'operation'.
+	StorageFailed(operation string)
+
+	// SecretDetected tracks a secret detected by the 'rule'.
+	SecretDetected(rule string)
+
+	// GistsSwept tracks the expired gists deleted by the sweeper.
+	GistsSwept(count int)
+
+	// GistsPurged tracks the trashed gists permanently deleted.
+	GistsPurged(count int)
+}
+
+// GistsRepository defines a storage that persists the gists.
+type GistsRepository interface {
+
+	// List returns all stored gists that are written
+	// using the 'language', or all gists if the 'language' is empty.
+	List(ctx context.Context, language string) ([]Gist, error)
+
+	// Get returns the gist with the specified 'id'
+	// or ErrGistNotFound if the gist doesn't exist.
+	Get(ctx context.Context, id string) (Gist, error)
+
+	// Save creates a new gist or replaces the existing one.
+	Save(ctx context.Context, gist Gist) error
+
+	// Replace atomically replaces the gist with the specified 'id' by the gist
+	// returned from 'replace', that is called with the existing gist, if any,
+	// and returns the saved gist. The error returned from 'replace' aborts
+	// the replacement and is returned as is.
+	Replace(ctx context.Context, id string, replace func(existing Gist, found bool) (Gist, error)) (Gist, error)
+
+	// Touch atomically updates the access time of the gist with the specified 'id'
+	// to 'at' and returns the updated gist, if the gist is visible at the time 'at',
+	// or returns ErrGistNotFound otherwise. The rest of the gist is left intact.
+	Touch(ctx context.Context, id string, at time.Time) (Gist, error)
+
+	// Trash atomically moves the gist with the specified 'id' to the trash at the
+	// time 'at' and returns the trashed gist, if the gist is visible at the time 'at',
+	// or returns ErrGistNotFound otherwise. The rest of the gist is left intact.
+	Trash(ctx context.Context, id string, at time.Time) (Gist, error)
+
+	// Restore atomically restores the gist with the specified 'id' from the trash
+	// and returns the restored gist, if the gist is in the trash and is not expired
+	// at the time 'at', or returns ErrGistNotFound otherwise.
+	Restore(ctx context.Context, id string, at time.Time) (Gist, error)
+
+	// Delete removes the gist with the specified 'id'
+	// or returns ErrGistNotFound if the gist doesn't exist.
+	Delete(ctx context.Context, id string) error
+
+	// DeleteIf atomically removes the gist with the specified 'id', if the
+	// gist still satisfies the 'condition', or returns ErrGistNotFound if
+	// the gist doesn't exist or doesn't satisfy the 'condition' anymore.
+	DeleteIf(ctx context.Context, id string, condition func(Gist) bool) error
+
+	// ListExpired returns up to 'limit' gists that have expired by the time 'at',
+	// ordered by the expiration time. The zero 'limit' means no limit.
+	ListExpired(ctx context.Context, at time.Time, limit int) ([]Gist, error)
+
+	// ListTrashed returns up to 'limit' gists that have been moved to the trash
+	// before the time 'before', ordered by the deletion time. The zero 'before'
+	// means all the trashed gists, and the zero 'limit' means no limit.
+	ListTrashed(ctx context.Context, before time.Time, limit int) ([]Gist, error)
+}
+
+// MarkdownRenderer renders markdown documents to a safe HTML page.
+type MarkdownRenderer interface {
+
+	// RenderPage renders the markdown 'source' to a standalone HTML page
+	// with the provided 'title' and the table of contents.
+	RenderPage(title string, source []byte) ([]byte, error)
+}
+
+// SecretScanner detects credentials and other secrets in the gists.
+type SecretScanner interface {
+
+	// Policy returns how the detected secrets should be handled.
+	Policy() secrets.Policy
+
+	// Scan detects secrets in the 'content'.
+	Scan(content string) []secrets.Finding
+
+	// Redact replaces the 'findings' in the 'content' with a placeholder.
+	Redact(content string, findings []secrets.Finding) string
 }
 
 // GistsLogic implements business rules for the Gists.
 type GistsLogic struct {
-	log      logger.Log
-	reporter MetricsReporter
+	log        logger.Log
+	reporter   MetricsReporter
+	tracer     trace.Tracer
+	repository GistsRepository
+	renderer   MarkdownRenderer
+	scanner    SecretScanner
+	now        func() time.Time
 }
 
 // NewGistsLogic creates a new instance of GistsLogic that
@@ -32,6 +271,10 @@ type GistsLogic struct {
 func NewGistsLogic(
 	log logger.Log,
 	reporter MetricsReporter,
+	tracer trace.Tracer,
+	repository GistsRepository,
+	renderer MarkdownRenderer,
+	scanner SecretScanner,
 ) (*GistsLogic, error) {
 
 	if log == nil {
@@ -42,18 +285,349 @@ func NewGistsLogic(
 		return nil, ErrNoReporterProvided
 	}
 
+	if tracer == nil {
+		return nil, ErrNoTracerProvided
+	}
+
+	if repository == nil {
+		return nil, ErrNoRepositoryProvided
+	}
+
+	if renderer == nil {
+		return nil, ErrNoRendererProvided
+	}
+
+	if scanner == nil {
+		return nil, ErrNoScannerProvided
+	}
+
 	return &GistsLogic{
-		log:      log,
-		reporter: reporter,
+		log:        log,
+		reporter:   reporter,
+		tracer:     tracer,
+		repository: repository,
+		renderer:   renderer,
+		scanner:    scanner,
+		now:        time.Now,
 	}, nil
 }
 
 // GetGists returns the filtered list of Gists for the specified 'language'.
-func (g *GistsLogic) GetGists(ctx context.Context, language string) error {
+func (g *GistsLogic) GetGists(ctx context.Context, language string) ([]Gist, error) {
+	ctx, span := g.tracer.Start(ctx, "GistsLogic.GetGists")
+	defer span.End()
+
 	log := logger.FromContext(g.log, ctx, "GetGists")
 	log.Info("Handling GetGists")
 
-	// NOTE: implement me
+	start := g.now()
+	defer func() {
+		g.reporter.GistsSearched(ctx, g.now().Sub(start))
+	}()
+
+	gists, err := g.repository.List(ctx, language)
+	if err != nil {
+		log.Error(err, "Failed to list gists")
+		return nil, storageFailed(g.reporter, storageList, err)
+	}
+
+	// Expired gists are hidden until the sweeper deletes them,
+	// trashed gists are hidden until they are restored.
+	now := g.now()
+	active := gists[:0]
+	for _, gist := range gists {
+		if gist.IsVisible(now) {
+			active = append(active, gist)
+		}
+	}
+
+	return active, nil
+}
 
+// GetGist returns the Gist with the specified 'id'.
+// Every direct access to the gist updates its last access time.
+func (g *GistsLogic) GetGist(ctx context.Context, id string) (Gist, error) {
+	ctx, span := g.tracer.Start(ctx, "GistsLogic.GetGist")
+	defer span.End()
+
+	log := logger.FromContext(g.log, ctx, "GetGist")
+	log.Info("Handling GetGist")
+
+	// The access time is updated in place, so the concurrent
+	// changes of the gist are neither lost nor reverted
+	gist, err := g.repository.Touch(ctx, id, g.now())
+	if err != nil {
+		if !errors.Is(err, ErrGistNotFound) {
+			log.Error(err, "Failed to update gist access time")
+		}
+		return Gist{}, storageFailed(g.reporter, storageTouch, err)
+	}
+
+	return gist, nil
+}
+
+// CreateGist stores a new Gist and assigns a unique id to it.
+func (g *GistsLogic) CreateGist(ctx context.Context, gist Gist) (Gist, error) {
+	ctx, span := g.tracer.Start(ctx, "GistsLogic.CreateGist")
+	defer span.End()
+
+	log := logger.FromContext(g.log, ctx, "CreateGist")
+	log.Info("Handling CreateGist")
+
+	gist, err := g.scanSecrets(log, gist)
+	if err != nil {
+		return Gist{}, err
+	}
+
+	now := g.now()
+	gist, err = expire(gist, now)
+	if err != nil {
+		return Gist{}, err
+	}
+
+	gist.Id = uuid.NewString()
+	gist.CreatedAt = now
+	gist.UpdatedAt = now
+	gist.AccessedAt = time.Time{}
+
+	if err := g.repository.Save(ctx, gist); err != nil {
+		log.Error(err, "Failed to save a new gist")
+		return Gist{}, storageFailed(g.reporter, storageSave, err)
+	}
+
+	g.reporter.GistCreated(gist.Language, len(gist.Code))
+	return gist, nil
+}
+
+// UpdateGist replaces the Gist with the specified 'id',
+// or creates a new one if the Gist doesn't exist yet.
+// The Gist in the trash is not replaced, until it is restored.
+func (g *GistsLogic) UpdateGist(ctx context.Context, id string, gist Gist) (Gist, error) {
+	ctx, span := g.tracer.Start(ctx, "GistsLogic.UpdateGist")
+	defer span.End()
+
+	log := logger.FromContext(g.log, ctx, "UpdateGist")
+	log.Info("Handling UpdateGist")
+
+	gist, err := g.scanSecrets(log, gist)
+	if err != nil {
+		return Gist{}, err
+	}
+
+	now := g.now()
+	gist, err = expire(gist, now)
+	if err != nil {
+		return Gist{}, err
+	}
+
+	gist.Id = id
+	gist.CreatedAt = now
+	gist.UpdatedAt = now
+	gist.AccessedAt = time.Time{}
+
+	// The expired gist is replaced as if it doesn't exist, while the trashed
+	// one should be restored first, so it isn't overwritten by accident
+	replaced := false
+	gist, err = g.repository.Replace(ctx, id, func(existing Gist, found bool) (Gist, error) {
+		result := gist
+		replaced = false
+		switch {
+		case !found || existing.IsExpired(now):
+		case existing.IsTrashed():
+			return Gist{}, ErrGistTrashed
+		default:
+			result.CreatedAt = existing.CreatedAt
+			result.AccessedAt = existing.AccessedAt
+			replaced = true
+		}
+		return result, nil
+	})
+	if errors.Is(err, ErrGistTrashed) {
+		return Gist{}, err
+	}
+	if err != nil {
+		log.Error(err, "Failed to replace the gist")
+		return Gist{}, storageFailed(g.reporter, storageReplace, err)
+	}
+
+	if replaced {
+		g.reporter.GistUpdated(gist.Language, len(gist.Code))
+	} else {
+		g.reporter.GistCreated(gist.Language, len(gist.Code))
+	}
+
+	return gist, nil
+}
+
+// DeleteGist moves the Gist with the specified 'id' to the trash.
+// The trashed Gist could be restored, until it is purged.
+func (g *GistsLogic) DeleteGist(ctx context.Context, id string) error {
+	ctx, span := g.tracer.Start(ctx, "GistsLogic.DeleteGist")
+	defer span.End()
+
+	log := logger.FromContext(g.log, ctx, "DeleteGist")
+	log.Info("Handling DeleteGist")
+
+	// The gist is moved in place, so the concurrent changes
+	// of the gist are kept, and the purged gist doesn't come back
+	gist, err := g.repository.Trash(ctx, id, g.now())
+	if err != nil {
+		if !errors.Is(err, ErrGistNotFound) {
+			log.Error(err, "Failed to move the gist to the trash")
+		}
+		return storageFailed(g.reporter, storageTrash, err)
+	}
+
+	g.reporter.GistDeleted(gist.Language)
+	return nil
+}
+
+// GetTrash returns the Gists in the trash, the most recently deleted first.
+func (g *GistsLogic) GetTrash(ctx context.Context) ([]Gist, error) {
+	ctx, span := g.tracer.Start(ctx, "GistsLogic.GetTrash")
+	defer span.End()
+
+	log := logger.FromContext(g.log, ctx, "GetTrash")
+	log.Info("Handling GetTrash")
+
+	// All the trashed gists are listed, including the ones
+	// deleted at this very moment, regardless of the retention
+	now := g.now()
+	trashed, err := g.repository.ListTrashed(ctx, time.Time{}, 0)
+	if err != nil {
+		log.Error(err, "Failed to list trashed gists")
+		return nil, storageFailed(g.reporter, storageListTrashed, err)
+	}
+
+	gists := make([]Gist, 0, len(trashed))
+	for i := len(trashed) - 1; i >= 0; i-- {
+		if !trashed[i].IsExpired(now) {
+			gists = append(gists, trashed[i])
+		}
+	}
+
+	return gists, nil
+}
+
+// RestoreGist restores the Gist with the specified 'id' from the trash.
+func (g *GistsLogic) RestoreGist(ctx context.Context, id string) (Gist, error) {
+	ctx, span := g.tracer.Start(ctx, "GistsLogic.RestoreGist")
+	defer span.End()
+
+	log := logger.FromContext(g.log, ctx, "RestoreGist")
+	log.Info("Handling RestoreGist")
+
+	gist, err := g.repository.Restore(ctx, id, g.now())
+	if err != nil {
+		if !errors.Is(err, ErrGistNotFound) {
+			log.Error(err, "Failed to restore the gist")
+		}
+		return Gist{}, storageFailed(g.reporter, storageRestore, err)
+	}
+
+	return gist, nil
+}
+
+// PurgeGist permanently deletes the Gist with the specified 'id' from the trash.
+func (g *GistsLogic) PurgeGist(ctx context.Context, id string) error {
+	ctx, span := g.tracer.Start(ctx, "GistsLogic.PurgeGist")
+	defer span.End()
+
+	log := logger.FromContext(g.log, ctx, "PurgeGist")
+	log.Info("Handling PurgeGist")
+
+	// The gist restored concurrently is kept
+	now := g.now()
+	err := g.repository.DeleteIf(ctx, id, func(gist Gist) bool {
+		return gist.IsTrashed() && !gist.IsExpired(now)
+	})
+	if err != nil {
+		if !errors.Is(err, ErrGistNotFound) {
+			log.Error(err, "Failed to purge the gist")
+		}
+		return storageFailed(g.reporter, storageDelete, err)
+	}
+
+	g.reporter.GistsPurged(1)
 	return nil
 }
+
+// RenderGist renders the markdown Gist with the specified 'id'
+// to a sanitized standalone HTML page.
+//
+// Only the gists written in markdown could be rendered,
+// for all other gists ErrGistNotRenderable is returned.
+func (g *GistsLogic) RenderGist(ctx context.Context, id string) ([]byte, error) {
+	ctx, span := g.tracer.Start(ctx, "GistsLogic.RenderGist")
+	defer span.End()
+
+	log := logger.FromContext(g.log, ctx, "RenderGist")
+	log.Info("Handling RenderGist")
+
+	gist, err := g.GetGist(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if !IsMarkdown(gist.Language) {
+		return nil, ErrGistNotRenderable
+	}
+
+	page, err := g.renderer.RenderPage(gist.Name, []byte(gist.Code))
+	if err != nil {
+		log.Error(err, "Failed to render the markdown gist")
+		return nil, err
+	}
+
+	return page, nil
+}
+
+// scanSecrets detects secrets in the gist code and applies the secrets policy.
+//
+// Depending on the policy, the gist is either rejected with SecretDetectedError,
+// returned with the secrets redacted, or returned as is with a logged warning.
+func (g *GistsLogic) scanSecrets(log logger.Log, gist Gist) (Gist, error) {
+	findings := g.scanner.Scan(gist.Code)
+	if len(findings) == 0 {
+		return gist, nil
+	}
+
+	for _, f := range findings {
+		g.reporter.SecretDetected(f.Rule)
+	}
+
+	detected := &SecretDetectedError{Findings: findings}
+	switch g.scanner.Policy() {
+	case secrets.PolicyReject:
+		log.Warnf("Rejecting gist with secrets: %s", detected.Detail())
+		return Gist{}, detected
+
+	case secrets.PolicyRedact:
+		log.Warnf("Redacting secrets in gist: %s", detected.Detail())
+		gist.Code = g.scanner.Redact(gist.Code, findings)
+
+	default:
+		log.Warnf("Gist contains secrets: %s", detected.Detail())
+	}
+
+	return gist, nil
+}
+
+// storageFailed reports the failed storage 'operation' and returns the 'err'.
+// The missing gist is an expected outcome and is not reported as a failure.
+func storageFailed(reporter MetricsReporter, operation string, err error) error {
+	if !errors.Is(err, ErrGistNotFound) {
+		reporter.StorageFailed(operation)
+	}
+	return err
+}
+
+// IsMarkdown reports whether the 'language' is a markdown language.
+func IsMarkdown(language string) bool {
+	switch strings.ToLower(language) {
+	case "markdown", "md":
+		return true
+	default:
+		return false
+	}
+}
@@ -0,0 +1,127 @@
+package logic
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"git.lothric.net/examples/go/gogin/internal/pkg/lifecycle"
+	"git.lothric.net/examples/go/gogin/internal/pkg/logger"
+)
+
+var (
+	// ErrInvalidTrashConfig happens when trash retention, interval or batch size is not positive.
+	ErrInvalidTrashConfig = errors.New("invalid trash configuration")
+)
+
+// TrashConfig defines the gists trash configuration.
+type TrashConfig struct {
+
+	// Retention is how long the gists stay in the trash before they are purged.
+	Retention time.Duration
+
+	// Interval is the time between two consecutive purges.
+	Interval time.Duration
+
+	// BatchSize is the maximum number of gists purged at once.
+	BatchSize int
+}
+
+// TrashPurger periodically and permanently deletes the gists,
+// that have been in the trash longer than the retention period.
+type TrashPurger struct {
+	log        logger.Log
+	reporter   MetricsReporter
+	repository GistsRepository
+	config     TrashConfig
+	now        func() time.Time
+
+	*lifecycle.Periodic
+}
+
+// NewTrashPurger creates a new purger of the trashed gists.
+func NewTrashPurger(
+	log logger.Log,
+	reporter MetricsReporter,
+	repository GistsRepository,
+	config TrashConfig,
+) (*TrashPurger, error) {
+
+	if log == nil {
+		return nil, ErrNoLoggerProvided
+	}
+
+	if reporter == nil {
+		return nil, ErrNoReporterProvided
+	}
+
+	if repository == nil {
+		return nil, ErrNoRepositoryProvided
+	}
+
+	if config.Retention <= 0 || config.Interval <= 0 || config.BatchSize <= 0 {
+		return nil, ErrInvalidTrashConfig
+	}
+
+	p := &TrashPurger{
+		log:        log,
+		reporter:   reporter,
+		repository: repository,
+		config:     config,
+		now:        time.Now,
+	}
+
+	// Purge the trash on every interval, until the purger is stopped
+	p.Periodic = lifecycle.NewPeriodic(config.Interval, func(ctx context.Context) {
+		if _, err := p.Purge(ctx); err != nil && ctx.Err() == nil {
+			p.log.Error(err, "Failed to purge the trashed gists")
+		}
+	})
+
+	return p, nil
+}
+
+// Purge permanently deletes in batches all the gists that have been
+// in the trash longer than the retention period and returns them.
+func (p *TrashPurger) Purge(ctx context.Context) ([]Gist, error) {
+	log := logger.FromContext(p.log, ctx, "Purge")
+
+	before := p.now().Add(-p.config.Retention)
+
+	var purged []Gist
+	for {
+		batch, err := p.repository.ListTrashed(ctx, before, p.config.BatchSize)
+		if err != nil {
+			return purged, storageFailed(p.reporter, storageListTrashed, err)
+		}
+
+		// The gist could be restored from the trash since it has been
+		// listed, so it is deleted only if it is still in the trash
+		deleted := 0
+		for _, gist := range batch {
+			err := p.repository.DeleteIf(ctx, gist.Id, func(gist Gist) bool {
+				return gist.IsTrashed() && gist.DeletedAt.Before(before)
+			})
+			if errors.Is(err, ErrGistNotFound) {
+				continue
+			}
+			if err != nil {
+				p.reporter.GistsPurged(deleted)
+				return purged, storageFailed(p.reporter, storageDelete, err)
+			}
+			purged = append(purged, gist)
+			deleted++
+		}
+		p.reporter.GistsPurged(deleted)
+
+		if len(batch) < p.config.BatchSize || ctx.Err() != nil {
+			break
+		}
+	}
+
+	if len(purged) > 0 {
+		log.Infof("Purged %d trashed gists", len(purged))
+	}
+
+	return purged, ctx.Err()
+}
@@ -0,0 +1,82 @@
+package logic
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"git.lothric.net/examples/go/gogin/internal/pkg/logger"
+)
+
+func TestTrashPurger(t *testing.T) {
+	log, _ := logger.NewNullLogger()
+	reporter := &reporterMock{}
+	repository := &repositoryMock{gists: map[string]Gist{}}
+
+	now := time.Now()
+	for i := 0; i < 3; i++ {
+		id := fmt.Sprintf("old-%d", i)
+		repository.gists[id] = Gist{Id: id, DeletedAt: now.Add(-48 * time.Hour)}
+	}
+	repository.gists["recent"] = Gist{Id: "recent", DeletedAt: now.Add(-time.Hour)}
+	repository.gists["active"] = Gist{Id: "active"}
+
+	purger, err := NewTrashPurger(
+		log,
+		reporter,
+		repository,
+		TrashConfig{Retention: 24 * time.Hour, Interval: time.Hour, BatchSize: 2},
+	)
+	require.NoError(t, err)
+
+	purged, err := purger.Purge(context.Background())
+
+	require.NoError(t, err)
+	require.Len(t, purged, 3)
+	require.Equal(t, 3, reporter.purged)
+	require.Len(t, repository.gists, 2)
+	require.Contains(t, repository.gists, "recent")
+	require.Contains(t, repository.gists, "active")
+}
+
+// restoringRepository restores the listed gists from the trash,
+// as if they have been restored after the purger has listed them.
+type restoringRepository struct {
+	*repositoryMock
+}
+
+func (r *restoringRepository) ListTrashed(ctx context.Context, before time.Time, limit int) ([]Gist, error) {
+	gists, err := r.repositoryMock.ListTrashed(ctx, before, limit)
+	for _, gist := range gists {
+		gist.DeletedAt = time.Time{}
+		r.gists[gist.Id] = gist
+	}
+	return gists, err
+}
+
+func TestTrashPurgerKeepsRestoredGists(t *testing.T) {
+	log, _ := logger.NewNullLogger()
+	reporter := &reporterMock{}
+	repository := &repositoryMock{gists: map[string]Gist{
+		"old": {Id: "old", DeletedAt: time.Now().Add(-48 * time.Hour)},
+	}}
+
+	purger, err := NewTrashPurger(
+		log,
+		reporter,
+		&restoringRepository{repository},
+		TrashConfig{Retention: 24 * time.Hour, Interval: time.Hour, BatchSize: 2},
+	)
+	require.NoError(t, err)
+
+	purged, err := purger.Purge(context.Background())
+
+	require.NoError(t, err)
+	require.Empty(t, purged)
+	require.Equal(t, 0, reporter.purged)
+	require.Contains(t, repository.gists, "old")
+	require.False(t, repository.gists["old"].IsTrashed())
+}
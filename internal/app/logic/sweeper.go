@@ -0,0 +1,135 @@
+package logic
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"git.lothric.net/examples/go/gogin/internal/pkg/lifecycle"
+	"git.lothric.net/examples/go/gogin/internal/pkg/logger"
+)
+
+var (
+	// ErrInvalidSweeperConfig happens when sweeper interval or batch size is not positive.
+	ErrInvalidSweeperConfig = errors.New("invalid sweeper configuration")
+)
+
+// SweeperConfig defines the expired gists sweeper configuration.
+type SweeperConfig struct {
+
+	// Interval is the time between two consecutive sweeps.
+	Interval time.Duration
+
+	// BatchSize is the maximum number of gists deleted at once.
+	BatchSize int
+}
+
+// GistsSweeper periodically deletes the expired gists in batches.
+type GistsSweeper struct {
+	log        logger.Log
+	reporter   MetricsReporter
+	repository GistsRepository
+	config     SweeperConfig
+	now        func() time.Time
+
+	// mu serializes sweeps, so the scheduled sweep
+	// and the sweep requested via API don't overlap.
+	mu sync.Mutex
+
+	*lifecycle.Periodic
+}
+
+// NewGistsSweeper creates a new sweeper of the expired gists.
+func NewGistsSweeper(
+	log logger.Log,
+	reporter MetricsReporter,
+	repository GistsRepository,
+	config SweeperConfig,
+) (*GistsSweeper, error) {
+
+	if log == nil {
+		return nil, ErrNoLoggerProvided
+	}
+
+	if reporter == nil {
+		return nil, ErrNoReporterProvided
+	}
+
+	if repository == nil {
+		return nil, ErrNoRepositoryProvided
+	}
+
+	if config.Interval <= 0 || config.BatchSize <= 0 {
+		return nil, ErrInvalidSweeperConfig
+	}
+
+	s := &GistsSweeper{
+		log:        log,
+		reporter:   reporter,
+		repository: repository,
+		config:     config,
+		now:        time.Now,
+	}
+
+	// Sweep the expired gists on every interval, until the sweeper is stopped
+	s.Periodic = lifecycle.NewPeriodic(config.Interval, func(ctx context.Context) {
+		if _, err := s.Sweep(ctx, false); err != nil && ctx.Err() == nil {
+			s.log.Error(err, "Failed to sweep the expired gists")
+		}
+	})
+
+	return s, nil
+}
+
+// Sweep deletes all the gists that have expired by now in batches
+// and returns the deleted gists. The dry run only returns the gists
+// that would be deleted, without deleting them.
+func (s *GistsSweeper) Sweep(ctx context.Context, dryRun bool) ([]Gist, error) {
+	log := logger.FromContext(s.log, ctx, "Sweep")
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := s.now()
+	if dryRun {
+		return s.repository.ListExpired(ctx, now, 0)
+	}
+
+	var swept []Gist
+	for {
+		batch, err := s.repository.ListExpired(ctx, now, s.config.BatchSize)
+		if err != nil {
+			return swept, storageFailed(s.reporter, storageListExpired, err)
+		}
+
+		// The gist could be updated with a new expiration time since
+		// it has been listed, so it is deleted only if it is still expired
+		deleted := 0
+		for _, gist := range batch {
+			err := s.repository.DeleteIf(ctx, gist.Id, func(gist Gist) bool {
+				return gist.IsExpired(now)
+			})
+			if errors.Is(err, ErrGistNotFound) {
+				continue
+			}
+			if err != nil {
+				s.reporter.GistsSwept(deleted)
+				return swept, storageFailed(s.reporter, storageDelete, err)
+			}
+			swept = append(swept, gist)
+			deleted++
+		}
+		s.reporter.GistsSwept(deleted)
+
+		if len(batch) < s.config.BatchSize || ctx.Err() != nil {
+			break
+		}
+	}
+
+	if len(swept) > 0 {
+		log.Infof("Swept %d expired gists", len(swept))
+	}
+
+	return swept, ctx.Err()
+}
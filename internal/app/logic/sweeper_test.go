@@ -0,0 +1,153 @@
+package logic
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"git.lothric.net/examples/go/gogin/internal/pkg/logger"
+)
+
+func TestGistsSweeper(t *testing.T) {
+	for scenario, fn := range map[string]func(
+		t *testing.T,
+		s *GistsSweeper,
+		r *repositoryMock,
+		m *reporterMock,
+	){
+		"sweeps expired gists in batches": testSweepsExpiredGistsInBatches,
+		"dry run keeps expired gists":     testDryRunKeepsExpiredGists,
+		"stops running sweeper":           testStopsRunningSweeper,
+		"keeps gists renewed after list":  testKeepsGistsRenewedAfterList,
+	} {
+		t.Run(scenario, func(t *testing.T) {
+			log, _ := logger.NewNullLogger()
+			reporter := &reporterMock{}
+			repository := &repositoryMock{gists: map[string]Gist{}}
+
+			now := time.Now()
+			for i := 0; i < 5; i++ {
+				id := fmt.Sprintf("expired-%d", i)
+				repository.gists[id] = Gist{Id: id, ExpiresAt: now.Add(-time.Minute)}
+			}
+			repository.gists["active"] = Gist{Id: "active", ExpiresAt: now.Add(time.Hour)}
+			repository.gists["eternal"] = Gist{Id: "eternal"}
+
+			sweeper, err := NewGistsSweeper(
+				log,
+				reporter,
+				repository,
+				SweeperConfig{Interval: time.Millisecond, BatchSize: 2},
+			)
+			require.NoError(t, err)
+
+			fn(t, sweeper, repository, reporter)
+		})
+	}
+}
+
+func TestGistsSweeperCreation(t *testing.T) {
+	log, _ := logger.NewNullLogger()
+
+	sweeper, err := NewGistsSweeper(
+		log,
+		&reporterMock{},
+		&repositoryMock{},
+		SweeperConfig{Interval: time.Minute},
+	)
+
+	require.Nil(t, sweeper)
+	require.Equal(t, ErrInvalidSweeperConfig, err)
+}
+
+func testSweepsExpiredGistsInBatches(
+	t *testing.T,
+	s *GistsSweeper,
+	r *repositoryMock,
+	m *reporterMock,
+) {
+
+	swept, err := s.Sweep(context.Background(), false)
+
+	require.NoError(t, err)
+	require.Len(t, swept, 5)
+	require.Equal(t, 5, m.swept)
+	require.Len(t, r.gists, 2)
+	require.Contains(t, r.gists, "active")
+	require.Contains(t, r.gists, "eternal")
+}
+
+func testDryRunKeepsExpiredGists(
+	t *testing.T,
+	s *GistsSweeper,
+	r *repositoryMock,
+	m *reporterMock,
+) {
+
+	swept, err := s.Sweep(context.Background(), true)
+
+	require.NoError(t, err)
+	require.Len(t, swept, 5)
+	require.Equal(t, 0, m.swept)
+	require.Len(t, r.gists, 7)
+}
+
+func testStopsRunningSweeper(
+	t *testing.T,
+	s *GistsSweeper,
+	r *repositoryMock,
+	m *reporterMock,
+) {
+
+	stopped := make(chan error)
+	go func() {
+		stopped <- s.Run()
+	}()
+
+	require.Eventually(t, func() bool {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		return len(r.gists) == 2
+	}, time.Second, time.Millisecond)
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	require.NoError(t, s.Stop(ctx))
+	require.NoError(t, <-stopped)
+}
+
+// renewingRepository renews the expiration of the listed gists,
+// as if they have been updated after the sweeper has listed them.
+type renewingRepository struct {
+	*repositoryMock
+}
+
+func (r *renewingRepository) ListExpired(ctx context.Context, at time.Time, limit int) ([]Gist, error) {
+	gists, err := r.repositoryMock.ListExpired(ctx, at, limit)
+	for _, gist := range gists {
+		gist.ExpiresAt = at.Add(time.Hour)
+		r.gists[gist.Id] = gist
+	}
+	return gists, err
+}
+
+func testKeepsGistsRenewedAfterList(
+	t *testing.T,
+	s *GistsSweeper,
+	r *repositoryMock,
+	m *reporterMock,
+) {
+
+	s.repository = &renewingRepository{r}
+
+	swept, err := s.Sweep(context.Background(), false)
+
+	require.NoError(t, err)
+	require.Empty(t, swept)
+	require.Equal(t, 0, m.swept)
+	require.Len(t, r.gists, 7)
+}
@@ -0,0 +1,94 @@
+package storage
+
+import (
+	"context"
+	"sort"
+	"sync"
+
+	"git.lothric.net/examples/go/gogin/internal/app/logic"
+	"git.lothric.net/examples/go/gogin/internal/pkg/logger"
+)
+
+// memoryCollections is an in-memory collections repository.
+//
+// The repository is safe for concurrent use, but doesn't
+// persist the collections between the application restarts.
+type memoryCollections struct {
+	log         logger.Log
+	mu          sync.RWMutex
+	collections map[string]logic.Collection
+}
+
+// NewMemoryCollections creates a new in-memory collections repository.
+func NewMemoryCollections(log logger.Log) (*memoryCollections, error) {
+	if log == nil {
+		return nil, ErrNoLoggerProvided
+	}
+
+	return &memoryCollections{
+		log:         log.WithField(logger.FieldPackage, "storage"),
+		collections: make(map[string]logic.Collection),
+	}, nil
+}
+
+// List returns all stored collections ordered by creation time.
+func (m *memoryCollections) List(ctx context.Context) ([]logic.Collection, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	collections := make([]logic.Collection, 0, len(m.collections))
+	for _, collection := range m.collections {
+		collections = append(collections, clone(collection))
+	}
+
+	sort.Slice(collections, func(i, j int) bool {
+		if collections[i].CreatedAt.Equal(collections[j].CreatedAt) {
+			return collections[i].Id < collections[j].Id
+		}
+		return collections[i].CreatedAt.Before(collections[j].CreatedAt)
+	})
+
+	return collections, nil
+}
+
+// Get returns the collection with the specified 'id'.
+func (m *memoryCollections) Get(ctx context.Context, id string) (logic.Collection, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	collection, ok := m.collections[id]
+	if !ok {
+		return logic.Collection{}, logic.ErrCollectionNotFound
+	}
+
+	return clone(collection), nil
+}
+
+// Save creates a new collection or replaces the existing one.
+func (m *memoryCollections) Save(ctx context.Context, collection logic.Collection) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.collections[collection.Id] = clone(collection)
+	return nil
+}
+
+// Delete removes the collection with the specified 'id'.
+func (m *memoryCollections) Delete(ctx context.Context, id string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if _, ok := m.collections[id]; !ok {
+		return logic.ErrCollectionNotFound
+	}
+
+	delete(m.collections, id)
+	return nil
+}
+
+// clone copies the collection, so the stored collection
+// doesn't share the list of gists with the caller.
+func clone(collection logic.Collection) logic.Collection {
+	collection.GistIds = append([]string{}, collection.GistIds...)
+	return collection
+}
@@ -0,0 +1,231 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"git.lothric.net/examples/go/gogin/internal/app/logic"
+	"git.lothric.net/examples/go/gogin/internal/pkg/logger"
+)
+
+var (
+	// ErrNoLoggerProvided happens when logger is not provided.
+	ErrNoLoggerProvided = errors.New("no logger provided")
+)
+
+// memoryGists is an in-memory gists repository.
+//
+// The repository is safe for concurrent use, but doesn't
+// persist the gists between the application restarts.
+type memoryGists struct {
+	log   logger.Log
+	mu    sync.RWMutex
+	gists map[string]logic.Gist
+}
+
+// NewMemoryGists creates a new in-memory gists repository.
+func NewMemoryGists(log logger.Log) (*memoryGists, error) {
+	if log == nil {
+		return nil, ErrNoLoggerProvided
+	}
+
+	return &memoryGists{
+		log:   log.WithField(logger.FieldPackage, "storage"),
+		gists: make(map[string]logic.Gist),
+	}, nil
+}
+
+// List returns all stored gists that are written using the 'language',
+// or all gists if the 'language' is empty, ordered by creation time.
+func (m *memoryGists) List(ctx context.Context, language string) ([]logic.Gist, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	gists := make([]logic.Gist, 0, len(m.gists))
+	for _, gist := range m.gists {
+		if language == "" || strings.EqualFold(gist.Language, language) {
+			gists = append(gists, gist)
+		}
+	}
+
+	sort.Slice(gists, func(i, j int) bool {
+		if gists[i].CreatedAt.Equal(gists[j].CreatedAt) {
+			return gists[i].Id < gists[j].Id
+		}
+		return gists[i].CreatedAt.Before(gists[j].CreatedAt)
+	})
+
+	return gists, nil
+}
+
+// Get returns the gist with the specified 'id'.
+func (m *memoryGists) Get(ctx context.Context, id string) (logic.Gist, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	gist, ok := m.gists[id]
+	if !ok {
+		return logic.Gist{}, logic.ErrGistNotFound
+	}
+
+	return gist, nil
+}
+
+// Save creates a new gist or replaces the existing one.
+func (m *memoryGists) Save(ctx context.Context, gist logic.Gist) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.gists[gist.Id] = gist
+	return nil
+}
+
+// Replace replaces the gist with the specified 'id' by the gist returned from 'replace'.
+func (m *memoryGists) Replace(ctx context.Context, id string, replace func(logic.Gist, bool) (logic.Gist, error)) (logic.Gist, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	existing, ok := m.gists[id]
+	gist, err := replace(existing, ok)
+	if err != nil {
+		return logic.Gist{}, err
+	}
+
+	gist.Id = id
+	m.gists[id] = gist
+	return gist, nil
+}
+
+// Touch updates the access time of the gist with the specified 'id',
+// if the gist is visible at the time 'at'.
+func (m *memoryGists) Touch(ctx context.Context, id string, at time.Time) (logic.Gist, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	gist, ok := m.gists[id]
+	if !ok || !gist.IsVisible(at) {
+		return logic.Gist{}, logic.ErrGistNotFound
+	}
+
+	gist.AccessedAt = at
+	m.gists[id] = gist
+	return gist, nil
+}
+
+// Trash moves the gist with the specified 'id' to the trash,
+// if the gist is visible at the time 'at'.
+func (m *memoryGists) Trash(ctx context.Context, id string, at time.Time) (logic.Gist, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	gist, ok := m.gists[id]
+	if !ok || !gist.IsVisible(at) {
+		return logic.Gist{}, logic.ErrGistNotFound
+	}
+
+	gist.DeletedAt = at
+	m.gists[id] = gist
+	return gist, nil
+}
+
+// Restore restores the gist with the specified 'id' from the trash,
+// if the gist is in the trash and is not expired at the time 'at'.
+func (m *memoryGists) Restore(ctx context.Context, id string, at time.Time) (logic.Gist, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	gist, ok := m.gists[id]
+	if !ok || !gist.IsTrashed() || gist.IsExpired(at) {
+		return logic.Gist{}, logic.ErrGistNotFound
+	}
+
+	gist.DeletedAt = time.Time{}
+	m.gists[id] = gist
+	return gist, nil
+}
+
+// Delete removes the gist with the specified 'id'.
+func (m *memoryGists) Delete(ctx context.Context, id string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if _, ok := m.gists[id]; !ok {
+		return logic.ErrGistNotFound
+	}
+
+	delete(m.gists, id)
+	return nil
+}
+
+// DeleteIf removes the gist with the specified 'id', if it satisfies the 'condition'.
+func (m *memoryGists) DeleteIf(ctx context.Context, id string, condition func(logic.Gist) bool) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	gist, ok := m.gists[id]
+	if !ok || !condition(gist) {
+		return logic.ErrGistNotFound
+	}
+
+	delete(m.gists, id)
+	return nil
+}
+
+// ListExpired returns up to 'limit' gists that have expired by the time 'at',
+// ordered by the expiration time. The zero 'limit' means no limit.
+func (m *memoryGists) ListExpired(ctx context.Context, at time.Time, limit int) ([]logic.Gist, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	var gists []logic.Gist
+	for _, gist := range m.gists {
+		if gist.IsExpired(at) {
+			gists = append(gists, gist)
+		}
+	}
+
+	sort.Slice(gists, func(i, j int) bool {
+		if gists[i].ExpiresAt.Equal(gists[j].ExpiresAt) {
+			return gists[i].Id < gists[j].Id
+		}
+		return gists[i].ExpiresAt.Before(gists[j].ExpiresAt)
+	})
+
+	if limit > 0 && len(gists) > limit {
+		gists = gists[:limit]
+	}
+
+	return gists, nil
+}
+
+// ListTrashed returns up to 'limit' gists that have been moved to the trash
+// before the time 'before', ordered by the deletion time. The zero 'before'
+// means all the trashed gists, and the zero 'limit' means no limit.
+func (m *memoryGists) ListTrashed(ctx context.Context, before time.Time, limit int) ([]logic.Gist, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	var gists []logic.Gist
+	for _, gist := range m.gists {
+		if gist.IsTrashed() && (before.IsZero() || gist.DeletedAt.Before(before)) {
+			gists = append(gists, gist)
+		}
+	}
+
+	sort.Slice(gists, func(i, j int) bool {
+		if gists[i].DeletedAt.Equal(gists[j].DeletedAt) {
+			return gists[i].Id < gists[j].Id
+		}
+		return gists[i].DeletedAt.Before(gists[j].DeletedAt)
+	})
+
+	if limit > 0 && len(gists) > limit {
+		gists = gists[:limit]
+	}
+
+	return gists, nil
+}
@@ -0,0 +1,38 @@
+package storage
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// lockPollInterval is the interval between the attempts to acquire
+// the lock of the repository during the health check.
+const lockPollInterval = 5 * time.Millisecond
+
+// Check reports the repository as not healthy if it stays locked,
+// for example by a stuck writer, until the context is done.
+func (m *memoryGists) Check(ctx context.Context) error {
+	return available(ctx, &m.mu)
+}
+
+// Check reports the repository as not healthy if it stays locked,
+// for example by a stuck writer, until the context is done.
+func (m *memoryCollections) Check(ctx context.Context) error {
+	return available(ctx, &m.mu)
+}
+
+// available waits until the read lock of 'mu' could be acquired,
+// or returns the context error if it hasn't been released in time.
+func available(ctx context.Context, mu *sync.RWMutex) error {
+	for !mu.TryRLock() {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(lockPollInterval):
+		}
+	}
+	mu.RUnlock()
+
+	return nil
+}
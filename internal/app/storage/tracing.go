@@ -0,0 +1,175 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+
+	"git.lothric.net/examples/go/gogin/internal/app/logic"
+)
+
+var (
+	// ErrNoRepositoryProvided happens when the traced repository is not provided.
+	ErrNoRepositoryProvided = errors.New("no repository provided")
+
+	// ErrNoTracerProvided happens when tracer is not provided.
+	ErrNoTracerProvided = errors.New("no tracer provided")
+)
+
+const (
+
+	// attributeGistId is the span attribute with the gist id.
+	attributeGistId = attribute.Key("gist.id")
+
+	// attributeGistsCount is the span attribute with the number of the returned gists.
+	attributeGistsCount = attribute.Key("gists.count")
+)
+
+// tracedGists is a gists repository that records a span
+// for every call to the underlying repository.
+type tracedGists struct {
+	repository logic.GistsRepository
+	tracer     trace.Tracer
+}
+
+// NewTracedGists creates a new gists repository that traces the calls to the 'repository'.
+func NewTracedGists(repository logic.GistsRepository, tracer trace.Tracer) (*tracedGists, error) {
+	if repository == nil {
+		return nil, ErrNoRepositoryProvided
+	}
+
+	if tracer == nil {
+		return nil, ErrNoTracerProvided
+	}
+
+	return &tracedGists{
+		repository: repository,
+		tracer:     tracer,
+	}, nil
+}
+
+// List returns all stored gists that are written
+// using the 'language', or all gists if the 'language' is empty.
+func (t *tracedGists) List(ctx context.Context, language string) ([]logic.Gist, error) {
+	ctx, span := t.start(ctx, "GistsRepository.List")
+	defer span.End()
+
+	gists, err := t.repository.List(ctx, language)
+	span.SetAttributes(attributeGistsCount.Int(len(gists)))
+	return gists, end(span, err)
+}
+
+// Get returns the gist with the specified 'id'.
+func (t *tracedGists) Get(ctx context.Context, id string) (logic.Gist, error) {
+	ctx, span := t.start(ctx, "GistsRepository.Get", attributeGistId.String(id))
+	defer span.End()
+
+	gist, err := t.repository.Get(ctx, id)
+	return gist, end(span, err)
+}
+
+// Save creates a new gist or replaces the existing one.
+func (t *tracedGists) Save(ctx context.Context, gist logic.Gist) error {
+	ctx, span := t.start(ctx, "GistsRepository.Save", attributeGistId.String(gist.Id))
+	defer span.End()
+
+	return end(span, t.repository.Save(ctx, gist))
+}
+
+// Replace replaces the gist with the specified 'id' by the gist returned from 'replace'.
+func (t *tracedGists) Replace(ctx context.Context, id string, replace func(logic.Gist, bool) (logic.Gist, error)) (logic.Gist, error) {
+	ctx, span := t.start(ctx, "GistsRepository.Replace", attributeGistId.String(id))
+	defer span.End()
+
+	gist, err := t.repository.Replace(ctx, id, replace)
+	return gist, end(span, err)
+}
+
+// Touch updates the access time of the gist with the specified 'id'.
+func (t *tracedGists) Touch(ctx context.Context, id string, at time.Time) (logic.Gist, error) {
+	ctx, span := t.start(ctx, "GistsRepository.Touch", attributeGistId.String(id))
+	defer span.End()
+
+	gist, err := t.repository.Touch(ctx, id, at)
+	return gist, end(span, err)
+}
+
+// Trash moves the gist with the specified 'id' to the trash.
+func (t *tracedGists) Trash(ctx context.Context, id string, at time.Time) (logic.Gist, error) {
+	ctx, span := t.start(ctx, "GistsRepository.Trash", attributeGistId.String(id))
+	defer span.End()
+
+	gist, err := t.repository.Trash(ctx, id, at)
+	return gist, end(span, err)
+}
+
+// Restore restores the gist with the specified 'id' from the trash.
+func (t *tracedGists) Restore(ctx context.Context, id string, at time.Time) (logic.Gist, error) {
+	ctx, span := t.start(ctx, "GistsRepository.Restore", attributeGistId.String(id))
+	defer span.End()
+
+	gist, err := t.repository.Restore(ctx, id, at)
+	return gist, end(span, err)
+}
+
+// Delete removes the gist with the specified 'id'.
+func (t *tracedGists) Delete(ctx context.Context, id string) error {
+	ctx, span := t.start(ctx, "GistsRepository.Delete", attributeGistId.String(id))
+	defer span.End()
+
+	return end(span, t.repository.Delete(ctx, id))
+}
+
+// DeleteIf removes the gist with the specified 'id', if it satisfies the 'condition'.
+func (t *tracedGists) DeleteIf(ctx context.Context, id string, condition func(logic.Gist) bool) error {
+	ctx, span := t.start(ctx, "GistsRepository.DeleteIf", attributeGistId.String(id))
+	defer span.End()
+
+	return end(span, t.repository.DeleteIf(ctx, id, condition))
+}
+
+// ListExpired returns up to 'limit' gists that have expired by the time 'at'.
+func (t *tracedGists) ListExpired(ctx context.Context, at time.Time, limit int) ([]logic.Gist, error) {
+	ctx, span := t.start(ctx, "GistsRepository.ListExpired")
+	defer span.End()
+
+	gists, err := t.repository.ListExpired(ctx, at, limit)
+	span.SetAttributes(attributeGistsCount.Int(len(gists)))
+	return gists, end(span, err)
+}
+
+// ListTrashed returns up to 'limit' gists that have been moved to the trash before the time 'before'.
+func (t *tracedGists) ListTrashed(ctx context.Context, before time.Time, limit int) ([]logic.Gist, error) {
+	ctx, span := t.start(ctx, "GistsRepository.ListTrashed")
+	defer span.End()
+
+	gists, err := t.repository.ListTrashed(ctx, before, limit)
+	span.SetAttributes(attributeGistsCount.Int(len(gists)))
+	return gists, end(span, err)
+}
+
+// start starts a new client span of the repository call.
+func (t *tracedGists) start(
+	ctx context.Context,
+	name string,
+	attributes ...attribute.KeyValue,
+) (context.Context, trace.Span) {
+
+	return t.tracer.Start(ctx, name,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(attributes...))
+}
+
+// end records the 'err' on the 'span' and returns it.
+// The missing or trashed gist is an expected outcome and is not recorded as a failure.
+func end(span trace.Span, err error) error {
+	if err != nil && !errors.Is(err, logic.ErrGistNotFound) && !errors.Is(err, logic.ErrGistTrashed) {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	return err
+}
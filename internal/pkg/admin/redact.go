@@ -0,0 +1,93 @@
+package admin
+
+import (
+	"fmt"
+	"net/url"
+	"reflect"
+	"strings"
+	"time"
+)
+
+// Redacted replaces the values of the secrets.
+const Redacted = "[REDACTED]"
+
+var durationType = reflect.TypeOf(time.Duration(0))
+
+// Redact converts the configuration 'value' to the plain maps, slices and values,
+// that could be reported safely. The non-empty struct fields tagged with
+// `redact:"true"` and the credentials in the URLs are replaced with Redacted,
+// the durations are formatted as strings, for example "1m30s".
+func Redact(value interface{}) interface{} {
+	if value == nil {
+		return nil
+	}
+	return redact(reflect.ValueOf(value))
+}
+
+// redact converts the 'v' recursively.
+func redact(v reflect.Value) interface{} {
+	if v.Type() == durationType {
+		return time.Duration(v.Int()).String()
+	}
+
+	switch v.Kind() {
+	case reflect.Pointer, reflect.Interface:
+		if v.IsNil() {
+			return nil
+		}
+		return redact(v.Elem())
+
+	case reflect.Struct:
+		fields := make(map[string]interface{}, v.NumField())
+		for i := 0; i < v.NumField(); i++ {
+			field := v.Type().Field(i)
+			if !field.IsExported() {
+				continue
+			}
+
+			if field.Tag.Get("redact") == "true" && !v.Field(i).IsZero() {
+				fields[field.Name] = Redacted
+				continue
+			}
+			fields[field.Name] = redact(v.Field(i))
+		}
+		return fields
+
+	case reflect.Map:
+		entries := make(map[string]interface{}, v.Len())
+		iter := v.MapRange()
+		for iter.Next() {
+			entries[fmt.Sprint(iter.Key().Interface())] = redact(iter.Value())
+		}
+		return entries
+
+	case reflect.Slice, reflect.Array:
+		items := make([]interface{}, v.Len())
+		for i := range items {
+			items[i] = redact(v.Index(i))
+		}
+		return items
+
+	case reflect.String:
+		return redactURL(v.String())
+
+	default:
+		return v.Interface()
+	}
+}
+
+// redactURL replaces the user credentials of the URL 's',
+// the strings that aren't URLs are returned as is.
+func redactURL(s string) string {
+	if !strings.Contains(s, "://") {
+		return s
+	}
+
+	u, err := url.Parse(s)
+	if err != nil || u.User == nil {
+		return s
+	}
+
+	u.User = nil
+	return strings.Replace(u.String(), "://", "://"+Redacted+"@", 1)
+}
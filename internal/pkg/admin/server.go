@@ -0,0 +1,315 @@
+package admin
+
+import (
+	"context"
+	"crypto/subtle"
+	"crypto/tls"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/pprof"
+	"strings"
+	"sync"
+
+	"git.lothric.net/examples/go/gogin/internal/pkg/buildinfo"
+	"git.lothric.net/examples/go/gogin/internal/pkg/logger"
+	"git.lothric.net/examples/go/gogin/internal/pkg/tlsconfig"
+)
+
+const (
+
+	// PathLogLevel reports the current log level on GET and changes it on PUT.
+	PathLogLevel = "/admin/loglevel"
+
+	// PathBuildInfo reports the version, commit and Go version of the service.
+	PathBuildInfo = "/admin/buildinfo"
+
+	// PathConfig reports the configuration of the service with the secrets redacted.
+	PathConfig = "/admin/config"
+
+	// PathPprof is the prefix of the Go runtime profiles.
+	PathPprof = "/debug/pprof/"
+)
+
+var (
+	// ErrNoLoggerProvided happens when logger is not provided.
+	ErrNoLoggerProvided = errors.New("no logger provided")
+
+	// ErrUnprotectedAddr happens when the admin server is exposed
+	// beyond the loopback interface without the token.
+	ErrUnprotectedAddr = errors.New("admin server should either listen on loopback or require token")
+
+	// ErrUnauthorized happens when the request has no valid token.
+	ErrUnauthorized = errors.New("unauthorized")
+)
+
+// Config is an admin server configuration.
+type Config struct {
+
+	// Addr is the endpoint of the admin server, the server is disabled if it is empty.
+	// For example: "127.0.0.1:8900"
+	Addr string
+
+	// Token is the shared token the requests should provide as the bearer token,
+	// it is required unless the server listens on the loopback interface only.
+	Token string `redact:"true"`
+
+	// TLS is the TLS configuration of the admin server.
+	TLS tlsconfig.Config
+}
+
+// Enabled returns true if the admin server is configured.
+func (c Config) Enabled() bool {
+	return c.Addr != ""
+}
+
+// Validate checks that the admin server is protected either by the token
+// or by listening on the loopback interface.
+func (c Config) Validate() error {
+	if c.Token == "" && !isLoopback(c.Addr) {
+		return fmt.Errorf("%w: %s", ErrUnprotectedAddr, c.Addr)
+	}
+	return nil
+}
+
+// levelBody is the request and response body of the log level endpoint.
+type levelBody struct {
+	Level string `json:"level"`
+}
+
+// errorBody is the response body of the failed request.
+type errorBody struct {
+	Error string `json:"error"`
+}
+
+// Server is the admin HTTP server for debugging of the running service.
+// It exposes the Go runtime profiles, the build information and the
+// configuration, and allows changing the log level without the restart.
+type Server struct {
+	log      logger.Log
+	config   Config
+	app      func() interface{}
+	mux      *http.ServeMux
+	server   *http.Server
+	listener net.Listener
+	tls      *tls.Config
+
+	// token is the current token, that could be changed at runtime
+	mu    sync.RWMutex
+	token string
+}
+
+// NewServer creates a new admin server, that changes the level of the 'log'
+// and reports the current configuration returned by 'app' with the secrets
+// redacted, so the reloaded values are reported as well.
+// The server uses TLS if the 'tlsConfig' is provided.
+func NewServer(log logger.Log, config Config, app func() interface{}, tlsConfig *tls.Config) (*Server, error) {
+	if log == nil {
+		return nil, ErrNoLoggerProvided
+	}
+
+	if err := config.Validate(); err != nil {
+		return nil, err
+	}
+
+	s := &Server{
+		log:    log.WithField(logger.FieldPackage, "admin"),
+		config: config,
+		app:    app,
+		tls:    tlsConfig,
+		token:  config.Token,
+	}
+
+	mux := http.NewServeMux()
+	s.mux = mux
+	mux.HandleFunc(PathLogLevel, s.logLevel)
+	mux.HandleFunc(PathBuildInfo, s.buildInfo)
+	mux.HandleFunc(PathConfig, s.appConfig)
+
+	mux.HandleFunc(PathPprof, pprof.Index)
+	mux.HandleFunc(PathPprof+"cmdline", pprof.Cmdline)
+	mux.HandleFunc(PathPprof+"profile", pprof.Profile)
+	mux.HandleFunc(PathPprof+"symbol", pprof.Symbol)
+	mux.HandleFunc(PathPprof+"trace", pprof.Trace)
+
+	s.server = &http.Server{
+		Addr:    config.Addr,
+		Handler: s.authorize(mux),
+	}
+
+	return s, nil
+}
+
+// Handle registers the 'handler' of the 'path' on the admin server,
+// for example the administration operations of the application.
+// The requests are authorized by the token as all the others.
+func (s *Server) Handle(path string, handler http.Handler) {
+	s.mux.Handle(path, handler)
+}
+
+// Handler returns the HTTP handler of the admin server.
+func (s *Server) Handler() http.Handler {
+	return s.server.Handler
+}
+
+// Listen binds the listener of the admin server.
+func (s *Server) Listen() error {
+	ln, err := net.Listen("tcp", s.config.Addr)
+	if err != nil {
+		return err
+	}
+
+	if s.tls != nil {
+		ln = tls.NewListener(ln, s.tls)
+	}
+
+	s.listener = ln
+	return nil
+}
+
+// Serve starts the admin server, the listener
+// is bound first if it hasn't been bound yet.
+func (s *Server) Serve() error {
+	if s.listener == nil {
+		if err := s.Listen(); err != nil {
+			return err
+		}
+	}
+
+	if err := s.server.Serve(s.listener); err != http.ErrServerClosed {
+		return err
+	}
+	return nil
+}
+
+// Stop stops the admin server.
+func (s *Server) Stop(ctx context.Context) error {
+	return s.server.Shutdown(ctx)
+}
+
+// SetToken changes the token the requests should provide, for example
+// once it is rotated. The token could be empty only if the server
+// listens on the loopback interface.
+func (s *Server) SetToken(token string) error {
+	config := s.config
+	config.Token = token
+	if err := config.Validate(); err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.token = token
+	return nil
+}
+
+// authorize rejects the requests without the current bearer token,
+// unless the token is not configured.
+func (s *Server) authorize(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		s.mu.RLock()
+		token := s.token
+		s.mu.RUnlock()
+
+		if token == "" {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		expected := []byte("Bearer " + token)
+		provided := []byte(r.Header.Get("Authorization"))
+		if subtle.ConstantTimeCompare(provided, expected) != 1 {
+			w.Header().Set("WWW-Authenticate", "Bearer")
+			writeJSON(w, http.StatusUnauthorized, errorBody{Error: ErrUnauthorized.Error()})
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// logLevel reports or changes the log level.
+func (s *Server) logLevel(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		writeJSON(w, http.StatusOK, levelBody{Level: s.log.Level()})
+
+	case http.MethodPut:
+		var body levelBody
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			writeJSON(w, http.StatusBadRequest, errorBody{Error: err.Error()})
+			return
+		}
+
+		previous := s.log.Level()
+		if err := s.log.SetLevel(body.Level); err != nil {
+			writeJSON(w, http.StatusBadRequest, errorBody{Error: err.Error()})
+			return
+		}
+
+		log := s.log.WithField(logger.FieldFunction, "logLevel")
+		log.Warn("Changed log level from ", previous, " to ", s.log.Level())
+
+		writeJSON(w, http.StatusOK, levelBody{Level: s.log.Level()})
+
+	default:
+		methodNotAllowed(w, http.MethodGet, http.MethodPut)
+	}
+}
+
+// buildInfo reports the build information.
+func (s *Server) buildInfo(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		methodNotAllowed(w, http.MethodGet)
+		return
+	}
+	writeJSON(w, http.StatusOK, buildinfo.Get())
+}
+
+// appConfig reports the current application configuration with the secrets redacted.
+func (s *Server) appConfig(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		methodNotAllowed(w, http.MethodGet)
+		return
+	}
+
+	var app interface{}
+	if s.app != nil {
+		app = s.app()
+	}
+	writeJSON(w, http.StatusOK, Redact(app))
+}
+
+// methodNotAllowed rejects the request with the method other than 'allowed'.
+func methodNotAllowed(w http.ResponseWriter, allowed ...string) {
+	w.Header().Set("Allow", strings.Join(allowed, ", "))
+	writeJSON(w, http.StatusMethodNotAllowed, errorBody{Error: http.StatusText(http.StatusMethodNotAllowed)})
+}
+
+// writeJSON writes the 'body' as the JSON response with the 'status'.
+func writeJSON(w http.ResponseWriter, status int, body interface{}) {
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	w.WriteHeader(status)
+
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	encoder.Encode(body)
+}
+
+// isLoopback returns true if the 'addr' is bound to the loopback interface only.
+func isLoopback(addr string) bool {
+	host, _, err := net.SplitHostPort(addr)
+	if err != nil {
+		return false
+	}
+
+	if host == "localhost" {
+		return true
+	}
+
+	ip := net.ParseIP(host)
+	return ip != nil && ip.IsLoopback()
+}
@@ -0,0 +1,57 @@
+package buildinfo
+
+import (
+	"runtime"
+	"runtime/debug"
+)
+
+// The build information is injected by the linker, for example:
+//
+//	go build -ldflags "-X git.lothric.net/examples/go/gogin/internal/pkg/buildinfo.Version=1.0.0"
+var (
+
+	// Version is the version of the service, usually the git tag or branch.
+	Version = "dev"
+
+	// Commit is the git commit the service is built from.
+	Commit = ""
+
+	// Date is the build date in RFC 3339 format.
+	Date = ""
+)
+
+// Info is the build information of the service.
+type Info struct {
+	Version   string `json:"version"`
+	Commit    string `json:"commit"`
+	Date      string `json:"date"`
+	GoVersion string `json:"goVersion"`
+}
+
+// Get returns the build information of the service. The commit and date
+// that weren't injected by the linker are taken from the version control
+// information embedded by the Go toolchain, if any.
+func Get() Info {
+	info := Info{
+		Version:   Version,
+		Commit:    Commit,
+		Date:      Date,
+		GoVersion: runtime.Version(),
+	}
+
+	build, ok := debug.ReadBuildInfo()
+	if !ok {
+		return info
+	}
+
+	for _, setting := range build.Settings {
+		switch {
+		case setting.Key == "vcs.revision" && info.Commit == "":
+			info.Commit = setting.Value
+		case setting.Key == "vcs.time" && info.Date == "":
+			info.Date = setting.Value
+		}
+	}
+
+	return info
+}
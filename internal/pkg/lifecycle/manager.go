@@ -0,0 +1,231 @@
+package lifecycle
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"git.lothric.net/examples/go/gogin/internal/pkg/logger"
+)
+
+var (
+	// ErrNoLoggerProvided happens when logger is not provided.
+	ErrNoLoggerProvided = errors.New("no logger provided")
+
+	// ErrInvalidDrainTimeout happens when the drain timeout is not positive.
+	ErrInvalidDrainTimeout = errors.New("invalid drain timeout")
+
+	// ErrInvalidDrainDelay happens when the drain delay is negative.
+	ErrInvalidDrainDelay = errors.New("invalid drain delay")
+)
+
+// Config is a lifecycle configuration.
+type Config struct {
+
+	// DrainTimeout is the time the components have to finish
+	// the work in progress and stop after the termination is requested.
+	// For example: 3s
+	DrainTimeout time.Duration
+
+	// DrainDelay is the time between the readiness flip and the components stop,
+	// so the load balancers notice that the service is not ready and stop sending
+	// new requests before the listeners are closed. The delay is not a part of
+	// the drain timeout.
+	// For example: 5s
+	DrainDelay time.Duration
+}
+
+// Component is a part of the service, which lifecycle is managed by the manager.
+// All the functions are optional.
+type Component struct {
+
+	// Name is the name of the component in the logs and errors.
+	Name string
+
+	// Listen binds the listener of the component before it runs,
+	// so the service fails to start if the listener could not be bound.
+	Listen func() error
+
+	// Run runs the component until it is stopped, the error returned
+	// by Run terminates the service.
+	Run func() error
+
+	// Stop stops the component, it should finish the work in progress
+	// until the context is done.
+	Stop func(ctx context.Context) error
+}
+
+// running is the started component, the 'done' is closed when its Run has returned.
+type running struct {
+	Component
+	done chan struct{}
+}
+
+// Manager starts the components of the service in order and stops them in the
+// reverse order, so the components are stopped before the components they depend on.
+//
+// The service is terminated when the termination is requested or any component
+// fails. The readiness is flipped by the shutdown hooks before the components
+// are drained, so the clients stop sending new requests first.
+type Manager struct {
+	log          logger.Log
+	drainTimeout time.Duration
+	drainDelay   time.Duration
+
+	// interrupt is closed to cut the drain delay short
+	interrupt     chan struct{}
+	interruptOnce sync.Once
+
+	components []Component
+	onReady    []func()
+	onShutdown []func()
+}
+
+// NewManager creates a new lifecycle manager.
+func NewManager(log logger.Log, config Config) (*Manager, error) {
+	if log == nil {
+		return nil, ErrNoLoggerProvided
+	}
+
+	if config.DrainTimeout <= 0 {
+		return nil, fmt.Errorf("%w: %s", ErrInvalidDrainTimeout, config.DrainTimeout)
+	}
+
+	if config.DrainDelay < 0 {
+		return nil, fmt.Errorf("%w: %s", ErrInvalidDrainDelay, config.DrainDelay)
+	}
+
+	return &Manager{
+		log:          log.WithField(logger.FieldPackage, "lifecycle"),
+		drainTimeout: config.DrainTimeout,
+		drainDelay:   config.DrainDelay,
+		interrupt:    make(chan struct{}),
+	}, nil
+}
+
+// Add adds the 'component', that is started after all the previously added components.
+func (m *Manager) Add(component Component) {
+	m.components = append(m.components, component)
+}
+
+// OnReady adds the 'hook' that is called once all the components have started.
+func (m *Manager) OnReady(hook func()) {
+	m.onReady = append(m.onReady, hook)
+}
+
+// OnShutdown adds the 'hook' that is called before the components are stopped.
+func (m *Manager) OnShutdown(hook func()) {
+	m.onShutdown = append(m.onShutdown, hook)
+}
+
+// Interrupt cuts the drain delay short, so the components are stopped
+// right away, for example when the termination is requested again.
+func (m *Manager) Interrupt() {
+	m.interruptOnce.Do(func() { close(m.interrupt) })
+}
+
+// Run starts the components and waits until the 'ctx' is done or any
+// component fails, then flips the readiness, waits for the drain delay
+// and stops the components, and returns once all of them have finished
+// or the drain timeout has passed. The error is returned
+// if any component has failed to start or run.
+//
+// The drain delay is skipped if any component has failed,
+// and it is cut short once the manager is interrupted.
+func (m *Manager) Run(ctx context.Context) error {
+	log := m.log.WithField(logger.FieldFunction, "Run")
+
+	failures := make(chan error, len(m.components))
+	started := make([]*running, 0, len(m.components))
+
+	for _, c := range m.components {
+		log.Info("Starting ", c.Name, "...")
+
+		if c.Listen != nil {
+			if err := c.Listen(); err != nil {
+				err = fmt.Errorf("failed to start %s: %w", c.Name, err)
+				log.Error(err, "Failed to start the service.")
+
+				m.stop(started)
+				return err
+			}
+		}
+
+		r := &running{Component: c, done: make(chan struct{})}
+		go func() {
+			defer close(r.done)
+
+			if r.Run != nil {
+				if err := r.Run(); err != nil {
+					failures <- fmt.Errorf("%s has failed: %w", r.Name, err)
+				}
+			}
+		}()
+		started = append(started, r)
+	}
+
+	for _, hook := range m.onReady {
+		hook()
+	}
+	log.Info("The service is up and running.")
+
+	var err error
+	select {
+	case <-ctx.Done():
+		log.Info("Requested the service termination.")
+
+	case err = <-failures:
+		log.Error(err, "Terminating the service.")
+	}
+
+	for _, hook := range m.onShutdown {
+		hook()
+	}
+
+	// The components keep serving the requests, that the clients
+	// send until they notice the readiness flip
+	if err == nil && m.drainDelay > 0 {
+		log.Info("Waiting ", m.drainDelay, " for the clients to stop sending requests...")
+
+		timer := time.NewTimer(m.drainDelay)
+		select {
+		case <-timer.C:
+		case <-m.interrupt:
+			timer.Stop()
+			log.Info("Interrupted waiting for the clients.")
+		}
+	}
+
+	m.stop(started)
+	log.Info("The service has terminated.")
+
+	return err
+}
+
+// stop stops the 'started' components in the reverse order within the drain timeout.
+// The components are stopped even after the timeout, so they could release their resources.
+func (m *Manager) stop(started []*running) {
+	log := m.log.WithField(logger.FieldFunction, "stop")
+
+	ctx, cancel := context.WithTimeout(context.Background(), m.drainTimeout)
+	defer cancel()
+
+	for i := len(started) - 1; i >= 0; i-- {
+		r := started[i]
+		log.Info("Gracefully terminating ", r.Name, "...")
+
+		if r.Stop != nil {
+			if err := r.Stop(ctx); err != nil {
+				log.Error(err, "Failed to gracefully terminate ", r.Name, ".")
+			}
+		}
+
+		select {
+		case <-r.done:
+		case <-ctx.Done():
+			log.Error(ctx.Err(), "Timed out waiting for ", r.Name, " to terminate.")
+		}
+	}
+}
@@ -0,0 +1,238 @@
+package lifecycle
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"git.lothric.net/examples/go/gogin/internal/pkg/logger"
+)
+
+var errFailed = errors.New("failed")
+
+func TestManager(t *testing.T) {
+	for scenario, fn := range map[string]func(t *testing.T, m *Manager, e *events){
+		"starts in order and stops in reverse": testStartsInOrderAndStopsInReverse,
+		"calls hooks around components":        testCallsHooksAroundComponents,
+		"delays drain after readiness flip":    testDelaysDrainAfterReadinessFlip,
+		"skips drain delay if component fails": testSkipsDrainDelayIfComponentFails,
+		"interrupts drain delay":               testInterruptsDrainDelay,
+		"fails fast if listener fails":         testFailsFastIfListenerFails,
+		"terminates if component fails":        testTerminatesIfComponentFails,
+		"keeps running if component finishes":  testKeepsRunningIfComponentFinishes,
+		"exits once components stop":           testExitsOnceComponentsStop,
+		"exits on drain timeout":               testExitsOnDrainTimeout,
+		"stops components without run":         testStopsComponentsWithoutRun,
+	} {
+		t.Run(scenario, func(t *testing.T) {
+			log, _ := logger.NewNullLogger()
+
+			manager, err := NewManager(log, Config{DrainTimeout: 200 * time.Millisecond})
+			require.NoError(t, err)
+
+			fn(t, manager, &events{})
+		})
+	}
+}
+
+func TestManagerConfig(t *testing.T) {
+	log, _ := logger.NewNullLogger()
+
+	_, err := NewManager(nil, Config{DrainTimeout: time.Second})
+	require.ErrorIs(t, err, ErrNoLoggerProvided)
+
+	_, err = NewManager(log, Config{})
+	require.ErrorIs(t, err, ErrInvalidDrainTimeout)
+
+	_, err = NewManager(log, Config{DrainTimeout: time.Second, DrainDelay: -time.Second})
+	require.ErrorIs(t, err, ErrInvalidDrainDelay)
+}
+
+// events records the lifecycle events of the components in order.
+type events struct {
+	mu     sync.Mutex
+	events []string
+}
+
+func (e *events) Add(event string) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.events = append(e.events, event)
+}
+
+func (e *events) List() []string {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return append([]string{}, e.events...)
+}
+
+// component creates a component that runs until it is stopped,
+// and records its lifecycle events.
+func component(name string, e *events) Component {
+	stop := make(chan struct{})
+	var once sync.Once
+
+	return Component{
+		Name: name,
+		Listen: func() error {
+			e.Add("listen " + name)
+			return nil
+		},
+		Run: func() error {
+			<-stop
+			return nil
+		},
+		Stop: func(ctx context.Context) error {
+			e.Add("stop " + name)
+			once.Do(func() { close(stop) })
+			return nil
+		},
+	}
+}
+
+// runUntilReady runs the manager until the 'ready' hook is called, then cancels it.
+func runUntilReady(t *testing.T, m *Manager) error {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	m.OnReady(cancel)
+	return m.Run(ctx)
+}
+
+func testStartsInOrderAndStopsInReverse(t *testing.T, m *Manager, e *events) {
+	m.Add(component("first", e))
+	m.Add(component("second", e))
+	m.Add(component("third", e))
+
+	require.NoError(t, runUntilReady(t, m))
+	require.Equal(t, []string{
+		"listen first", "listen second", "listen third",
+		"stop third", "stop second", "stop first",
+	}, e.List())
+}
+
+func testCallsHooksAroundComponents(t *testing.T, m *Manager, e *events) {
+	m.Add(component("first", e))
+	m.OnReady(func() { e.Add("ready") })
+	m.OnShutdown(func() { e.Add("shutdown") })
+
+	require.NoError(t, runUntilReady(t, m))
+	require.Equal(t, []string{"listen first", "ready", "shutdown", "stop first"}, e.List())
+}
+
+func testDelaysDrainAfterReadinessFlip(t *testing.T, m *Manager, e *events) {
+	m.drainDelay = 50 * time.Millisecond
+
+	var shutdownAt, stoppedAt time.Time
+	c := component("first", e)
+	stop := c.Stop
+	c.Stop = func(ctx context.Context) error {
+		stoppedAt = time.Now()
+		return stop(ctx)
+	}
+
+	m.Add(c)
+	m.OnShutdown(func() { shutdownAt = time.Now() })
+
+	require.NoError(t, runUntilReady(t, m))
+	require.GreaterOrEqual(t, stoppedAt.Sub(shutdownAt), m.drainDelay)
+}
+
+func testSkipsDrainDelayIfComponentFails(t *testing.T, m *Manager, e *events) {
+	m.drainDelay = time.Hour
+
+	failing := component("first", e)
+	failing.Run = func() error { return errFailed }
+	m.Add(failing)
+
+	require.ErrorIs(t, m.Run(context.Background()), errFailed)
+}
+
+func testInterruptsDrainDelay(t *testing.T, m *Manager, e *events) {
+	m.drainDelay = time.Hour
+
+	m.Add(component("first", e))
+	m.OnShutdown(m.Interrupt)
+
+	// The manager is interrupted more than once
+	m.OnShutdown(m.Interrupt)
+
+	require.NoError(t, runUntilReady(t, m))
+	require.Equal(t, []string{"listen first", "stop first"}, e.List())
+}
+
+func testFailsFastIfListenerFails(t *testing.T, m *Manager, e *events) {
+	failing := component("second", e)
+	failing.Listen = func() error { return errFailed }
+
+	m.Add(component("first", e))
+	m.Add(failing)
+	m.Add(component("third", e))
+	m.OnReady(func() { e.Add("ready") })
+
+	err := m.Run(context.Background())
+	require.ErrorIs(t, err, errFailed)
+	require.ErrorContains(t, err, "second")
+	require.Equal(t, []string{"listen first", "stop first"}, e.List())
+}
+
+func testTerminatesIfComponentFails(t *testing.T, m *Manager, e *events) {
+	failing := component("second", e)
+	failing.Run = func() error { return errFailed }
+
+	m.Add(component("first", e))
+	m.Add(failing)
+	m.OnShutdown(func() { e.Add("shutdown") })
+
+	err := m.Run(context.Background())
+	require.ErrorIs(t, err, errFailed)
+	require.Equal(t, []string{"listen first", "listen second", "shutdown", "stop second", "stop first"}, e.List())
+}
+
+func testKeepsRunningIfComponentFinishes(t *testing.T, m *Manager, e *events) {
+	finished := component("first", e)
+	finished.Run = func() error { return nil }
+	m.Add(finished)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	require.NoError(t, m.Run(ctx))
+	require.Equal(t, []string{"listen first", "stop first"}, e.List())
+}
+
+func testExitsOnceComponentsStop(t *testing.T, m *Manager, e *events) {
+	m.Add(component("first", e))
+
+	started := time.Now()
+	require.NoError(t, runUntilReady(t, m))
+	require.Less(t, time.Since(started), 100*time.Millisecond)
+}
+
+func testExitsOnDrainTimeout(t *testing.T, m *Manager, e *events) {
+	stuck := component("stuck", e)
+	stuck.Run = func() error { select {} }
+	m.Add(stuck)
+
+	started := time.Now()
+	require.NoError(t, runUntilReady(t, m))
+	require.GreaterOrEqual(t, time.Since(started), 200*time.Millisecond)
+	require.Less(t, time.Since(started), time.Second)
+}
+
+func testStopsComponentsWithoutRun(t *testing.T, m *Manager, e *events) {
+	m.Add(Component{
+		Name: "flush",
+		Stop: func(ctx context.Context) error {
+			e.Add("stop flush")
+			return nil
+		},
+	})
+
+	require.NoError(t, runUntilReady(t, m))
+	require.Equal(t, []string{"stop flush"}, e.List())
+}
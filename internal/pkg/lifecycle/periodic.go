@@ -0,0 +1,95 @@
+package lifecycle
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// Runner runs the loop of a component until the component is stopped,
+// so the component Stop could wait for the loop to return.
+type Runner struct {
+	stop     chan struct{}
+	stopOnce sync.Once
+	done     chan struct{}
+}
+
+// NewRunner creates a new runner of the component loop.
+func NewRunner() *Runner {
+	return &Runner{
+		stop: make(chan struct{}),
+		done: make(chan struct{}),
+	}
+}
+
+// Run runs the 'loop' with the context, that is cancelled once
+// the runner is stopped, and returns the error of the loop.
+// The loop should return as soon as the context is done.
+func (r *Runner) Run(loop func(ctx context.Context) error) error {
+	defer close(r.done)
+
+	// Stopping the runner cancels the work in progress
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go func() {
+		select {
+		case <-r.stop:
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+
+	return loop(ctx)
+}
+
+// Stop stops the loop and waits for it to return.
+func (r *Runner) Stop(ctx context.Context) error {
+	r.stopOnce.Do(func() { close(r.stop) })
+
+	select {
+	case <-r.done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Periodic runs the job on every interval, until it is stopped.
+type Periodic struct {
+	runner   *Runner
+	interval time.Duration
+	job      func(ctx context.Context)
+}
+
+// NewPeriodic creates a new runner of the 'job' on every 'interval'.
+func NewPeriodic(interval time.Duration, job func(ctx context.Context)) *Periodic {
+	return &Periodic{
+		runner:   NewRunner(),
+		interval: interval,
+		job:      job,
+	}
+}
+
+// Run runs the job on every interval, until it is stopped.
+func (p *Periodic) Run() error {
+	return p.runner.Run(func(ctx context.Context) error {
+		ticker := time.NewTicker(p.interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return nil
+
+			case <-ticker.C:
+				p.job(ctx)
+			}
+		}
+	})
+}
+
+// Stop stops the job and waits for the run in progress to finish,
+// the context of the run in progress is cancelled.
+func (p *Periodic) Stop(ctx context.Context) error {
+	return p.runner.Stop(ctx)
+}
@@ -0,0 +1,94 @@
+package lifecycle
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestPeriodic(t *testing.T) {
+	for scenario, fn := range map[string]func(t *testing.T){
+		"runs job on every interval":   testRunsJobOnEveryInterval,
+		"cancels job in progress":      testCancelsJobInProgress,
+		"stops more than once":         testStopsMoreThanOnce,
+		"times out waiting for job":    testTimesOutWaitingForJob,
+		"returns loop error on runner": testReturnsLoopErrorOnRunner,
+	} {
+		t.Run(scenario, fn)
+	}
+}
+
+func testRunsJobOnEveryInterval(t *testing.T) {
+	var runs atomic.Int32
+	p := NewPeriodic(10*time.Millisecond, func(ctx context.Context) {
+		runs.Add(1)
+	})
+
+	done := make(chan error)
+	go func() { done <- p.Run() }()
+
+	require.Eventually(t, func() bool { return runs.Load() >= 3 }, time.Second, 5*time.Millisecond)
+	require.NoError(t, p.Stop(context.Background()))
+	require.NoError(t, <-done)
+}
+
+func testCancelsJobInProgress(t *testing.T) {
+	started := make(chan struct{})
+	cancelled := make(chan struct{})
+	p := NewPeriodic(time.Millisecond, func(ctx context.Context) {
+		select {
+		case started <- struct{}{}:
+			<-ctx.Done()
+			close(cancelled)
+		default:
+		}
+	})
+
+	go func() { _ = p.Run() }()
+
+	<-started
+	require.NoError(t, p.Stop(context.Background()))
+	<-cancelled
+}
+
+func testStopsMoreThanOnce(t *testing.T) {
+	p := NewPeriodic(time.Hour, func(ctx context.Context) {})
+
+	go func() { _ = p.Run() }()
+
+	require.NoError(t, p.Stop(context.Background()))
+	require.NoError(t, p.Stop(context.Background()))
+}
+
+func testTimesOutWaitingForJob(t *testing.T) {
+	release := make(chan struct{})
+	defer close(release)
+
+	started := make(chan struct{})
+	p := NewPeriodic(time.Millisecond, func(ctx context.Context) {
+		select {
+		case started <- struct{}{}:
+			<-release
+		default:
+		}
+	})
+
+	go func() { _ = p.Run() }()
+	<-started
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	require.ErrorIs(t, p.Stop(ctx), context.DeadlineExceeded)
+}
+
+func testReturnsLoopErrorOnRunner(t *testing.T) {
+	r := NewRunner()
+
+	require.ErrorIs(t, r.Run(func(ctx context.Context) error { return errFailed }), errFailed)
+
+	// The stop doesn't wait for the loop that has already returned
+	require.NoError(t, r.Stop(context.Background()))
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 
 	"github.com/gin-gonic/gin"
+	"go.opentelemetry.io/otel/trace"
 )
 
 // context key type for CorrelationID
@@ -24,7 +25,7 @@ const (
 
 // FromGin extracts correlationId from Gin context
 // and creates a logger and context that has the associated
-// correlation id fields set and configured
+// correlation id and trace fields set and configured
 func FromGin(
 	parentLog Log,
 	c *gin.Context,
@@ -34,22 +35,22 @@ func FromGin(
 	// Extract fields from the gin context
 	corrId := c.GetString(HeaderCorrelationId)
 
-	// Logger with the required fields set
-	log := parentLog.WithFields(Fields{
-		FieldFunction:    function,
-		FieldCorrelation: corrId,
-	})
-
 	// Create a new context that could be used by other methods,
 	// down the call chain with the correlation id set
 	ctx := context.WithValue(c.Request.Context(), CorrelationId, corrId)
 
+	// Logger with the required fields set
+	log := parentLog.WithFields(withTrace(ctx, Fields{
+		FieldFunction:    function,
+		FieldCorrelation: corrId,
+	}))
+
 	return log, ctx
 }
 
 // FromContext extracts correlationId from context
 // and creates a logger that has the associated
-// correlation id field set and configured
+// correlation id and trace fields set and configured
 func FromContext(
 	parentLog Log,
 	ctx context.Context,
@@ -60,10 +61,21 @@ func FromContext(
 	corrId := ctx.Value(CorrelationId)
 
 	// Logger with the fields set
-	log := parentLog.WithFields(Fields{
+	log := parentLog.WithFields(withTrace(ctx, Fields{
 		FieldFunction:    function,
 		FieldCorrelation: corrId,
-	})
+	}))
 
 	return log
 }
+
+// withTrace adds the trace and span ids of the span
+// that is active within the 'ctx' to the 'fields', if any.
+func withTrace(ctx context.Context, fields Fields) Fields {
+	sc := trace.SpanContextFromContext(ctx)
+	if sc.IsValid() {
+		fields[FieldTrace] = sc.TraceID().String()
+		fields[FieldSpan] = sc.SpanID().String()
+	}
+	return fields
+}
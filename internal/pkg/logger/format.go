@@ -0,0 +1,251 @@
+package logger
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"runtime"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+const (
+
+	// FormatterText is the human-readable text format
+	FormatterText = "text"
+
+	// FormatterJson is the JSON format with the logrus field names
+	FormatterJson = "json"
+
+	// FormatterLogfmt is the logfmt format, the key=value pairs
+	FormatterLogfmt = "logfmt"
+
+	// FormatterEcs is the JSON format of Elastic Common Schema
+	FormatterEcs = "ecs"
+
+	// FormatterGcp is the JSON format of Google Cloud Logging
+	FormatterGcp = "gcp"
+)
+
+const (
+
+	// ecsVersion is the version of Elastic Common Schema of the ecs format
+	ecsVersion = "1.6.0"
+
+	// ecsTimestampFormat is the timestamp of the ecs format with the milliseconds
+	ecsTimestampFormat = "2006-01-02T15:04:05.000Z07:00"
+
+	// Special fields of Google Cloud Logging
+	gcpTrace          = "logging.googleapis.com/trace"
+	gcpSpan           = "logging.googleapis.com/spanId"
+	gcpSourceLocation = "logging.googleapis.com/sourceLocation"
+)
+
+var (
+	// ErrUnknownFormatter happens when the log formatter is not supported.
+	ErrUnknownFormatter = errors.New("unknown log formatter")
+)
+
+// formatFieldNames are the default names of the fields by the format,
+// the fields keep their names in the formats that are not listed.
+var formatFieldNames = map[string]map[Field]string{
+	FormatterEcs: {
+		FieldNode:        "service.node.name",
+		FieldService:     "service.name",
+		FieldPackage:     "log.logger",
+		FieldFunction:    "log.origin.function",
+		FieldError:       "error.message",
+		FieldCorrelation: "http.request.id",
+		FieldTrace:       "trace.id",
+		FieldSpan:        "span.id",
+	},
+	FormatterGcp: {
+		FieldTrace: gcpTrace,
+		FieldSpan:  gcpSpan,
+	},
+}
+
+// loggerPackage is the package of this logger, its frames
+// are skipped when the source location is looked up.
+var loggerPackage = reflect.TypeOf(log{}).PkgPath()
+
+// getFormatter creates a new log formatter of the configuration,
+// with the fields renamed by the format and the configuration.
+// The fields are matched case-insensitively, as the keys of the
+// configuration file are lower-cased.
+func getFormatter(config Config) (logrus.Formatter, error) {
+	names := make(map[string]string)
+	for field, name := range formatFieldNames[config.Formatter] {
+		names[strings.ToLower(string(field))] = name
+	}
+	for field, name := range config.FieldNames {
+		names[strings.ToLower(field)] = name
+	}
+
+	var formatter logrus.Formatter
+	static := logrus.Fields{}
+
+	switch config.Formatter {
+	case FormatterText:
+		formatter = &logrus.TextFormatter{
+			DisableColors: true,
+			FullTimestamp: true,
+		}
+
+	case FormatterJson:
+		formatter = &logrus.JSONFormatter{}
+
+	case FormatterLogfmt:
+		formatter = &logrus.TextFormatter{
+			DisableColors:    true,
+			FullTimestamp:    true,
+			TimestampFormat:  time.RFC3339Nano,
+			QuoteEmptyFields: true,
+		}
+
+	case FormatterEcs:
+		static["ecs.version"] = ecsVersion
+		formatter = &logrus.JSONFormatter{
+			TimestampFormat: ecsTimestampFormat,
+			FieldMap: logrus.FieldMap{
+				logrus.FieldKeyTime:  "@timestamp",
+				logrus.FieldKeyLevel: "log.level",
+				logrus.FieldKeyMsg:   "message",
+			},
+		}
+
+	case FormatterGcp:
+		trace, ok := names[strings.ToLower(string(FieldTrace))]
+		if !ok {
+			trace = string(FieldTrace)
+		}
+		formatter = &gcpFormatter{
+			project: config.GcpProject,
+			trace:   trace,
+		}
+
+	default:
+		return nil, fmt.Errorf("%w: %q", ErrUnknownFormatter, config.Formatter)
+	}
+
+	if len(names) == 0 && len(static) == 0 {
+		return formatter, nil
+	}
+	return &fieldFormatter{formatter: formatter, names: names, static: static}, nil
+}
+
+// fieldFormatter renames the fields of the log entries
+// and adds the static fields before they are formatted.
+type fieldFormatter struct {
+	formatter logrus.Formatter
+
+	// names are the new names by the lower-cased fields
+	names  map[string]string
+	static logrus.Fields
+}
+
+func (f *fieldFormatter) Format(entry *logrus.Entry) ([]byte, error) {
+
+	// The entry is shared by the hooks, so the copy is changed
+	renamed := *entry
+	renamed.Data = make(logrus.Fields, len(entry.Data)+len(f.static))
+
+	for key, value := range f.static {
+		renamed.Data[key] = value
+	}
+	for key, value := range entry.Data {
+		if name, ok := f.names[strings.ToLower(key)]; ok {
+			key = name
+		}
+		renamed.Data[key] = value
+	}
+
+	return f.formatter.Format(&renamed)
+}
+
+// gcpFormatter formats the log entries as JSON of Google Cloud Logging,
+// with the severity, the trace and the source location it recognizes.
+type gcpFormatter struct {
+
+	// project is the Google Cloud project of the trace ids, they
+	// are linked to Cloud Trace as projects/<project>/traces/<id>
+	project string
+
+	// trace is the field name of the trace id
+	trace string
+}
+
+func (f *gcpFormatter) Format(entry *logrus.Entry) ([]byte, error) {
+	data := make(map[string]interface{}, len(entry.Data)+4)
+	for key, value := range entry.Data {
+		if err, ok := value.(error); ok {
+			value = err.Error()
+		}
+		data[key] = value
+	}
+
+	data["time"] = entry.Time.Format(time.RFC3339Nano)
+	data["severity"] = gcpSeverity(entry.Level)
+	data["message"] = entry.Message
+
+	if trace, ok := data[f.trace].(string); ok && trace != "" && f.project != "" {
+		data[f.trace] = "projects/" + f.project + "/traces/" + trace
+	}
+
+	if frame := sourceLocation(); frame != nil {
+		data[gcpSourceLocation] = map[string]string{
+			"file":     frame.File,
+			"line":     strconv.Itoa(frame.Line),
+			"function": frame.Function,
+		}
+	}
+
+	serialized, err := json.Marshal(data)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal fields to JSON: %w", err)
+	}
+	return append(serialized, '\n'), nil
+}
+
+// gcpSeverity returns the Google Cloud Logging severity of the log 'level'.
+func gcpSeverity(level logrus.Level) string {
+	switch level {
+	case logrus.PanicLevel:
+		return "ALERT"
+	case logrus.FatalLevel:
+		return "CRITICAL"
+	case logrus.ErrorLevel:
+		return "ERROR"
+	case logrus.WarnLevel:
+		return "WARNING"
+	case logrus.InfoLevel:
+		return "INFO"
+	default:
+		return "DEBUG"
+	}
+}
+
+// sourceLocation returns the frame of the function that has called the
+// logger, the frames of logrus and of this logger are skipped.
+func sourceLocation() *runtime.Frame {
+	pcs := make([]uintptr, 32)
+	frames := runtime.CallersFrames(pcs[:runtime.Callers(2, pcs)])
+
+	for {
+		frame, more := frames.Next()
+
+		logrusFrame := strings.HasPrefix(frame.Function, "github.com/sirupsen/logrus.")
+		loggerFrame := strings.HasPrefix(frame.Function, loggerPackage+".") && !strings.HasSuffix(frame.File, "_test.go")
+		if !logrusFrame && !loggerFrame {
+			return &frame
+		}
+
+		if !more {
+			return nil
+		}
+	}
+}
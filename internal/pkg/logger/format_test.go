@@ -0,0 +1,150 @@
+package logger
+
+import (
+	"encoding/json"
+	"errors"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestFormatters(t *testing.T) {
+	for scenario, fn := range map[string]func(t *testing.T, dir string){
+		"formats logfmt":              testFormatsLogfmt,
+		"formats ecs":                 testFormatsEcs,
+		"formats gcp":                 testFormatsGcp,
+		"prefixes gcp trace":          testPrefixesGcpTrace,
+		"renames fields":              testRenamesFields,
+		"renames fields of format":    testRenamesFieldsOfFormat,
+		"rejects unknown formatter":   testRejectsUnknownFormatter,
+		"keeps field names on change": testKeepsFieldNamesOnChange,
+	} {
+		t.Run(scenario, func(t *testing.T) {
+			fn(t, t.TempDir())
+		})
+	}
+}
+
+// logEntry logs the warning with the fields by the logger of the 'config',
+// and returns the formatted entry.
+func logEntry(t *testing.T, dir string, config Config) string {
+	path := filepath.Join(dir, "gogin.log")
+	config.Level = "info"
+	config.Sinks = []SinkConfig{{Type: SinkFile, File: FileConfig{Path: path}}}
+
+	l, err := New(config)
+	require.NoError(t, err)
+
+	l.WithFields(Fields{
+		FieldService:     "gogin",
+		FieldCorrelation: "c1",
+		FieldTrace:       "4bf92f3577b34da6a3ce929d0e0e4736",
+	}).ErrorWithFields(errors.New("failed"), Fields{"gist": 42}, "Failed to save the gist.")
+	require.NoError(t, l.Close())
+
+	return readFile(t, path)
+}
+
+// jsonEntry returns the fields of the JSON log entry.
+func jsonEntry(t *testing.T, entry string) map[string]interface{} {
+	var fields map[string]interface{}
+	require.NoError(t, json.Unmarshal([]byte(entry), &fields))
+	return fields
+}
+
+func testFormatsLogfmt(t *testing.T, dir string) {
+	entry := logEntry(t, dir, Config{Formatter: FormatterLogfmt})
+
+	require.Contains(t, entry, `level=error msg="Failed to save the gist." correlationId=c1 error=failed gist=42`)
+	require.True(t, strings.HasPrefix(entry, "time="))
+}
+
+func testFormatsEcs(t *testing.T, dir string) {
+	fields := jsonEntry(t, logEntry(t, dir, Config{Formatter: FormatterEcs}))
+
+	require.Equal(t, "1.6.0", fields["ecs.version"])
+	require.Equal(t, "error", fields["log.level"])
+	require.Equal(t, "Failed to save the gist.", fields["message"])
+	require.Equal(t, "failed", fields["error.message"])
+	require.Equal(t, "gogin", fields["service.name"])
+	require.Equal(t, "c1", fields["http.request.id"])
+	require.Equal(t, "4bf92f3577b34da6a3ce929d0e0e4736", fields["trace.id"])
+	require.Equal(t, 42.0, fields["gist"])
+	require.Contains(t, fields, "@timestamp")
+	require.NotContains(t, fields, FieldTrace)
+}
+
+func testFormatsGcp(t *testing.T, dir string) {
+	fields := jsonEntry(t, logEntry(t, dir, Config{Formatter: FormatterGcp}))
+
+	require.Equal(t, "ERROR", fields["severity"])
+	require.Equal(t, "Failed to save the gist.", fields["message"])
+	require.Equal(t, "failed", fields[FieldError])
+	require.Equal(t, "4bf92f3577b34da6a3ce929d0e0e4736", fields[gcpTrace])
+	require.Contains(t, fields, "time")
+
+	// The source location is the caller of the logger
+	location := fields[gcpSourceLocation].(map[string]interface{})
+	require.True(t, strings.HasSuffix(location["file"].(string), "format_test.go"), location["file"])
+	require.True(t, strings.HasSuffix(location["function"].(string), ".logEntry"), location["function"])
+	require.NotEmpty(t, location["line"])
+}
+
+func testPrefixesGcpTrace(t *testing.T, dir string) {
+	fields := jsonEntry(t, logEntry(t, dir, Config{Formatter: FormatterGcp, GcpProject: "gogin-prod"}))
+
+	require.Equal(t, "projects/gogin-prod/traces/4bf92f3577b34da6a3ce929d0e0e4736", fields[gcpTrace])
+}
+
+func testRenamesFields(t *testing.T, dir string) {
+	fields := jsonEntry(t, logEntry(t, dir, Config{
+		Formatter:  FormatterJson,
+		FieldNames: map[string]string{"correlationid": "request_id", "gist": "gist_id"},
+	}))
+
+	require.Equal(t, "c1", fields["request_id"])
+	require.Equal(t, 42.0, fields["gist_id"])
+	require.NotContains(t, fields, FieldCorrelation)
+	require.Equal(t, "error", fields["level"])
+}
+
+func testRenamesFieldsOfFormat(t *testing.T, dir string) {
+	fields := jsonEntry(t, logEntry(t, dir, Config{
+		Formatter:  FormatterEcs,
+		FieldNames: map[string]string{"traceId": "transaction.trace_id"},
+	}))
+
+	require.Equal(t, "4bf92f3577b34da6a3ce929d0e0e4736", fields["transaction.trace_id"])
+	require.Equal(t, "c1", fields["http.request.id"])
+}
+
+func testRejectsUnknownFormatter(t *testing.T, dir string) {
+	config := Config{Level: "info", Formatter: "xml"}
+	require.ErrorIs(t, config.Validate(), ErrUnknownFormatter)
+
+	_, err := New(config)
+	require.ErrorIs(t, err, ErrUnknownFormatter)
+
+	l, _ := NewNullLogger()
+	require.ErrorIs(t, l.SetFormatter("xml"), ErrUnknownFormatter)
+}
+
+func testKeepsFieldNamesOnChange(t *testing.T, dir string) {
+	path := filepath.Join(dir, "gogin.log")
+
+	l, err := New(Config{
+		Level:      "info",
+		Formatter:  FormatterText,
+		FieldNames: map[string]string{FieldCorrelation: "request_id"},
+		Sinks:      []SinkConfig{{Type: SinkFile, File: FileConfig{Path: path}}},
+	})
+	require.NoError(t, err)
+
+	require.NoError(t, l.SetFormatter(FormatterJson))
+	l.WithField(FieldCorrelation, "c1").Info("Saved the gist.")
+	require.NoError(t, l.Close())
+
+	require.Equal(t, "c1", jsonEntry(t, readFile(t, path))["request_id"])
+}
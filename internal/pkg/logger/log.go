@@ -22,6 +22,21 @@ type Log interface {
 	// in the base log, it is overwritten with a new value.
 	WithField(field Field, value interface{}) Log
 
+	// Level returns the current log level, for example "info".
+	Level() string
+
+	// SetLevel changes the log level at runtime. The level is shared
+	// by the base logger and all the loggers derived from it.
+	SetLevel(level string) error
+
+	// SetFormatter changes the log formatter at runtime. The formatter is
+	// shared by the base logger and all the loggers derived from it.
+	SetFormatter(formatter string) error
+
+	// Close closes the log sinks, for example the log file. The sinks are
+	// shared by the base logger and all the loggers derived from it.
+	Close() error
+
 	Trace(args ...interface{})
 	Tracef(format string, args ...interface{})
 	TraceWithFields(fields Fields, args ...interface{})
@@ -1,7 +1,7 @@
 package logger
 
 import (
-	"os"
+	"io"
 
 	"github.com/sirupsen/logrus"
 	logtest "github.com/sirupsen/logrus/hooks/test"
@@ -26,6 +26,12 @@ const (
 
 	// FieldCorrelation is a unique correlation id
 	FieldCorrelation = "correlationId"
+
+	// FieldTrace is a W3C trace id of the request
+	FieldTrace = "traceId"
+
+	// FieldSpan is an id of the span within the trace
+	FieldSpan = "spanId"
 )
 
 // Config is a logger configuration
@@ -44,13 +50,52 @@ type Config struct {
 	// Supported log formatters:
 	// - text
 	// - json
+	// - logfmt
+	// - ecs
+	// - gcp
 	Formatter string
+
+	// FieldNames rename the fields, for example "correlationId" to
+	// "request_id", over the default names of the formatter.
+	FieldNames map[string]string
+
+	// GcpProject is the Google Cloud project of the trace ids of
+	// the gcp formatter, the trace ids are not prefixed if it is empty.
+	GcpProject string
+
+	// Sinks are the outputs the log is written to,
+	// the standard output if there are none.
+	Sinks []SinkConfig
+
+	// Redact is the redaction of the sensitive data,
+	// the default fields and patterns are always redacted.
+	Redact RedactConfig
+}
+
+// Validate checks the log level and the log formatter.
+func (c Config) Validate() error {
+	if _, err := logrus.ParseLevel(c.Level); err != nil {
+		return err
+	}
+
+	if _, err := getFormatter(c); err != nil {
+		return err
+	}
+
+	for _, sink := range c.Sinks {
+		if err := sink.Validate(); err != nil {
+			return err
+		}
+	}
+
+	return c.Redact.Validate()
 }
 
 // log implements service logger with tracing and custom field support.
 type log struct {
 	config Config
 	logger *logrus.Logger
+	output *output
 	fields logrus.Fields
 }
 
@@ -72,17 +117,25 @@ func New(config Config) (Log, error) {
 }
 
 // NewNullLogger creates a new discarding null logger for unit test purposes.
+// The entries are redacted before the hook records them.
 func NewNullLogger() (Log, *logtest.Hook) {
-	logger, hook := logtest.NewNullLogger()
+	redactor, _ := newRedactor(RedactConfig{})
+
+	logger := logrus.New()
+	logger.SetOutput(io.Discard)
+	logger.AddHook(redactor)
+
 	return &log{
 		logger: logger,
-	}, hook
+	}, logtest.NewLocal(logger)
 }
 
 // WithFields combines this logger with a new custom fields.
 func (l *log) WithFields(fields Fields) Log {
 	return &log{
+		config: l.config,
 		logger: l.logger,
+		output: l.output,
 		fields: l.combineFields(fields),
 	}
 }
@@ -92,6 +145,51 @@ func (l *log) WithField(field Field, value interface{}) Log {
 	return l.WithFields(Fields{field: value})
 }
 
+// Level returns the current log level.
+func (l *log) Level() string {
+	return l.logger.GetLevel().String()
+}
+
+// SetLevel parses and applies the log level to the underlying logger,
+// so the level of all the derived loggers is changed as well.
+func (l *log) SetLevel(level string) error {
+	parsed, err := logrus.ParseLevel(level)
+	if err != nil {
+		return err
+	}
+	l.logger.SetLevel(parsed)
+	return nil
+}
+
+// SetFormatter creates and applies the log formatter to the underlying logger,
+// so the formatter of all the derived loggers is changed as well.
+// The fields are renamed by the configuration of the logger.
+func (l *log) SetFormatter(formatter string) error {
+	config := l.config
+	config.Formatter = formatter
+
+	f, err := getFormatter(config)
+	if err != nil {
+		return err
+	}
+
+	if l.output == nil {
+		l.logger.SetFormatter(f)
+		return nil
+	}
+	l.output.setFormatter(f)
+	return nil
+}
+
+// Close closes the sinks of the underlying logger, so the
+// base logger and all the derived loggers stop writing the log.
+func (l *log) Close() error {
+	if l.output == nil {
+		return nil
+	}
+	return l.output.Close()
+}
+
 func (l *log) Trace(args ...interface{}) {
 	l.logger.WithFields(l.fields).Trace(args...)
 }
@@ -215,11 +313,10 @@ func (l *log) applyConfig(config Config) error {
 	l.config = config
 
 	// log formatter
-	formatter, err := getFormatter(config.Formatter)
+	formatter, err := getFormatter(config)
 	if err != nil {
 		return err
 	}
-	l.logger.SetFormatter(formatter)
 
 	// log level
 	level, err := logrus.ParseLevel(config.Level)
@@ -228,8 +325,24 @@ func (l *log) applyConfig(config Config) error {
 	}
 	l.logger.SetLevel(level)
 
-	// log output
-	l.logger.SetOutput(os.Stdout)
+	// log redaction, the hook is the first one,
+	// so the sensitive data never reaches the output
+	redactor, err := newRedactor(config.Redact)
+	if err != nil {
+		return err
+	}
+	l.logger.AddHook(redactor)
+
+	// log output, the entries are formatted and
+	// written to the sinks by the output hook
+	output, err := newOutput(formatter, config.Sinks)
+	if err != nil {
+		return err
+	}
+	l.output = output
+	l.logger.AddHook(output)
+	l.logger.SetFormatter(discardFormatter{})
+	l.logger.SetOutput(io.Discard)
 
 	return nil
 }
@@ -250,20 +363,3 @@ func (l *log) combineFields(fields Fields) logrus.Fields {
 
 	return combined
 }
-
-// getFormatter parses and creates a new log formatter.
-func getFormatter(formatter string) (logrus.Formatter, error) {
-	switch formatter {
-	case "text":
-		return &logrus.TextFormatter{
-			DisableColors: true,
-			FullTimestamp: true,
-		}, nil
-
-	case "json":
-		return &logrus.JSONFormatter{}, nil
-
-	default:
-		return &logrus.JSONFormatter{}, nil
-	}
-}
@@ -0,0 +1,275 @@
+package logger
+
+import (
+	"fmt"
+	"net/http"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/sirupsen/logrus"
+)
+
+// Redacted replaces the sensitive data in the log.
+const Redacted = "[REDACTED]"
+
+var (
+
+	// defaultRedactedFields are the names of the fields, and of the keys of the
+	// maps and the HTTP headers in the fields, that are always redacted.
+	defaultRedactedFields = []string{
+		"authorization",
+		"proxy-authorization",
+		"cookie",
+		"set-cookie",
+		"password",
+		"passwd",
+		"secret",
+		"token",
+		"api-key",
+	}
+
+	// defaultRedactedPatterns are the patterns of the secrets, that are
+	// redacted in the messages and the string values of all the fields.
+	defaultRedactedPatterns = []redactPattern{
+		{
+			name:        "bearer-authorization",
+			pattern:     regexp.MustCompile(`\b(Bearer|bearer)\s+[A-Za-z0-9\-._~+/]{8,}=*`),
+			replacement: "${1} " + Redacted,
+		},
+		{
+			// The credentials are base64, so the words after "Basic" are kept
+			name:        "basic-authorization",
+			pattern:     regexp.MustCompile(`\b(Basic|basic)\s+(?:[A-Za-z0-9+/]{4}){2,}(?:[A-Za-z0-9+/]{2}==|[A-Za-z0-9+/]{3}=)?([^A-Za-z0-9+/=]|$)`),
+			replacement: "${1} " + Redacted + "${2}",
+		},
+		{
+			name:        "credential-assignment",
+			pattern:     regexp.MustCompile(`(?i)\b(password|passwd|secret|token|api[_-]?key)(["']?\s*[:=]\s*["']?)[^"'\s,&;]+`),
+			replacement: "${1}${2}" + Redacted,
+		},
+		{
+			name:        "url-credentials",
+			pattern:     regexp.MustCompile(`(://)[^/\s:@]+:[^/\s@]+@`),
+			replacement: "${1}" + Redacted + "@",
+		},
+		{
+			name:        "jwt",
+			pattern:     regexp.MustCompile(`\beyJ[A-Za-z0-9_-]{5,}\.eyJ[A-Za-z0-9_-]{5,}\.[A-Za-z0-9_-]{10,}`),
+			replacement: Redacted,
+		},
+		{
+			name:        "aws-access-key-id",
+			pattern:     regexp.MustCompile(`\b(?:AKIA|ASIA)[0-9A-Z]{16}\b`),
+			replacement: Redacted,
+		},
+		{
+			name:        "private-key",
+			pattern:     regexp.MustCompile(`(?s)-----BEGIN [A-Z0-9 ]*PRIVATE KEY-----.*?(?:-----END [A-Z0-9 ]*PRIVATE KEY-----|\z)`),
+			replacement: Redacted,
+		},
+	}
+)
+
+// RedactConfig is a configuration of the redaction of the sensitive data in the log.
+type RedactConfig struct {
+
+	// Fields are the names of the fields redacted in addition to the
+	// default ones, for example "authorization", "password" or "token".
+	// The names are matched case-insensitively, ignoring '-', '_' and '.',
+	// by the suffix, so "token" redacts "accessToken" as well.
+	// The keys of the maps and the HTTP headers in the fields are matched too.
+	Fields []string
+
+	// Patterns are the regular expressions of the secrets redacted in
+	// addition to the default ones by the pattern name. The matches are
+	// replaced in the messages and the string values of all the fields.
+	Patterns map[string]string
+
+	// Allow are the names of the fields, that are never redacted,
+	// neither by the name nor by the patterns.
+	Allow []string
+}
+
+// Validate checks the patterns of the secrets.
+func (c RedactConfig) Validate() error {
+	_, err := newRedactor(c)
+	return err
+}
+
+// redactPattern is the named pattern of a secret.
+type redactPattern struct {
+	name        string
+	pattern     *regexp.Regexp
+	replacement string
+}
+
+// redactor is the logrus hook that redacts the sensitive data of the
+// log entries before they are formatted, it is the first hook of the logger.
+type redactor struct {
+	fields   []string
+	allow    map[string]bool
+	patterns []redactPattern
+}
+
+// newRedactor creates the redactor with the default fields and
+// patterns, and the ones of the configuration.
+func newRedactor(config RedactConfig) (*redactor, error) {
+	r := &redactor{
+		allow:    make(map[string]bool),
+		patterns: append([]redactPattern{}, defaultRedactedPatterns...),
+	}
+
+	for _, field := range append(append([]string{}, defaultRedactedFields...), config.Fields...) {
+		r.fields = append(r.fields, normalizeField(field))
+	}
+	for _, field := range config.Allow {
+		r.allow[normalizeField(field)] = true
+	}
+
+	// Sort the patterns by name, so the redaction order is stable
+	names := make([]string, 0, len(config.Patterns))
+	for name := range config.Patterns {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		pattern, err := regexp.Compile(config.Patterns[name])
+		if err != nil {
+			return nil, fmt.Errorf("invalid log redaction pattern %q: %w", name, err)
+		}
+		r.patterns = append(r.patterns, redactPattern{name: name, pattern: pattern, replacement: Redacted})
+	}
+
+	return r, nil
+}
+
+// Levels returns all the levels, the entries of all of them are redacted.
+func (r *redactor) Levels() []logrus.Level {
+	return logrus.AllLevels
+}
+
+// Fire redacts the message and the fields of the log entry. The entry is the
+// copy made by logrus for every log call, but the values of the fields are
+// shared with the caller, so they are copied before they are redacted.
+func (r *redactor) Fire(entry *logrus.Entry) error {
+	entry.Message = r.redactString(entry.Message)
+
+	for key, value := range entry.Data {
+		entry.Data[key] = r.redactField(key, value)
+	}
+	return nil
+}
+
+// redactField redacts the 'value' of the field or the map entry 'key':
+// the values of the sensitive keys are redacted entirely, and the
+// secrets are redacted in the rest, unless the key is allowed.
+func (r *redactor) redactField(key string, value interface{}) interface{} {
+	name := normalizeField(key)
+	switch {
+	case r.allow[name]:
+		return value
+	case r.sensitive(name):
+		return Redacted
+	default:
+		return r.redactValue(value)
+	}
+}
+
+// sensitive returns true if the normalized field 'name' is redacted.
+func (r *redactor) sensitive(name string) bool {
+	for _, field := range r.fields {
+		if strings.HasSuffix(name, field) {
+			return true
+		}
+	}
+	return false
+}
+
+// redactedError is the error with the secrets redacted in its message,
+// that still wraps the original error, so errors.Is and errors.As work.
+type redactedError struct {
+	err     error
+	message string
+}
+
+// Error returns the redacted message of the error.
+func (e *redactedError) Error() string {
+	return e.message
+}
+
+// Unwrap returns the original error.
+func (e *redactedError) Unwrap() error {
+	return e.err
+}
+
+// redactValue redacts the secrets in the value of the field, including
+// the maps and the HTTP headers.
+func (r *redactor) redactValue(value interface{}) interface{} {
+	switch v := value.(type) {
+	case string:
+		return r.redactString(v)
+
+	case error:
+		if message := r.redactString(v.Error()); message != v.Error() {
+			return &redactedError{err: v, message: message}
+		}
+		return v
+
+	case []string:
+		redacted := make([]string, len(v))
+		for i, item := range v {
+			redacted[i] = r.redactString(item)
+		}
+		return redacted
+
+	case http.Header:
+		return http.Header(r.redactMap(v))
+
+	case map[string][]string:
+		return r.redactMap(v)
+
+	case map[string]string:
+		redacted := make(map[string]string, len(v))
+		for key, item := range v {
+			redacted[key] = r.redactField(key, item).(string)
+		}
+		return redacted
+
+	case map[string]interface{}:
+		redacted := make(map[string]interface{}, len(v))
+		for key, item := range v {
+			redacted[key] = r.redactField(key, item)
+		}
+		return redacted
+
+	default:
+		return value
+	}
+}
+
+// redactMap redacts the multi-value map, for example the HTTP headers.
+func (r *redactor) redactMap(values map[string][]string) map[string][]string {
+	redacted := make(map[string][]string, len(values))
+	for key, items := range values {
+		redacted[key] = make([]string, len(items))
+		for i, item := range items {
+			redacted[key][i] = r.redactField(key, item).(string)
+		}
+	}
+	return redacted
+}
+
+// redactString replaces the secrets in the string.
+func (r *redactor) redactString(s string) string {
+	for _, p := range r.patterns {
+		s = p.pattern.ReplaceAllString(s, p.replacement)
+	}
+	return s
+}
+
+// normalizeField returns the field name in lower case without '-', '_' and '.'.
+func normalizeField(field string) string {
+	return strings.NewReplacer("-", "", "_", "", ".", "").Replace(strings.ToLower(field))
+}
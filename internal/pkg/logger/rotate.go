@@ -0,0 +1,268 @@
+package logger
+
+import (
+	"compress/gzip"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+const (
+
+	// backupTimeFormat is the timestamp of the rotated files, without
+	// the colons, that are not allowed in the file names on some systems
+	backupTimeFormat = "2006-01-02T15-04-05.000"
+
+	// compressSuffix is the suffix of the compressed rotated files
+	compressSuffix = ".gz"
+
+	// megabyte is the unit of the file size
+	megabyte = 1024 * 1024
+)
+
+// rotatingFile writes the log entries to the file, and rotates it once it
+// reaches the maximum size. The rotated files are compressed and removed
+// by their age and count in the background.
+type rotatingFile struct {
+	config FileConfig
+
+	mu   sync.Mutex
+	file *os.File
+	size int64
+
+	// now returns the current time of the rotated file names
+	now func() time.Time
+
+	// millMu serializes compression and removal of the rotated files
+	millMu  sync.Mutex
+	milling sync.WaitGroup
+}
+
+// newRotatingFile opens the log file, the directory is created if it doesn't exist.
+func newRotatingFile(config FileConfig) (*rotatingFile, error) {
+	if config.Path == "" {
+		return nil, ErrNoFilePath
+	}
+
+	f := &rotatingFile{
+		config: config,
+		now:    time.Now,
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if err := f.open(); err != nil {
+		return nil, err
+	}
+	return f, nil
+}
+
+func (f *rotatingFile) write(level logrus.Level, entry []byte) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.file == nil {
+		return os.ErrClosed
+	}
+
+	maxSize := int64(f.config.MaxSize) * megabyte
+	if maxSize > 0 && f.size > 0 && f.size+int64(len(entry)) > maxSize {
+		if err := f.rotate(); err != nil {
+			return err
+		}
+	}
+
+	n, err := f.file.Write(entry)
+	f.size += int64(n)
+	return err
+}
+
+// Close closes the file and waits for the rotated files to be processed.
+func (f *rotatingFile) Close() error {
+	f.mu.Lock()
+	var err error
+	if f.file != nil {
+		err = f.file.Close()
+		f.file = nil
+	}
+	f.mu.Unlock()
+
+	f.milling.Wait()
+	return err
+}
+
+// open opens the log file for appending.
+func (f *rotatingFile) open() error {
+	if err := os.MkdirAll(filepath.Dir(f.config.Path), 0755); err != nil {
+		return err
+	}
+
+	file, err := os.OpenFile(f.config.Path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return err
+	}
+
+	info, err := file.Stat()
+	if err != nil {
+		_ = file.Close()
+		return err
+	}
+
+	f.file = file
+	f.size = info.Size()
+	return nil
+}
+
+// rotate renames the log file with the timestamp suffix, opens the new one
+// and starts the compression and removal of the rotated files.
+func (f *rotatingFile) rotate() error {
+	if err := f.file.Close(); err != nil {
+		return err
+	}
+	f.file = nil
+
+	if err := os.Rename(f.config.Path, f.backupName(f.now())); err != nil {
+		return err
+	}
+
+	if err := f.open(); err != nil {
+		return err
+	}
+
+	f.milling.Add(1)
+	go func() {
+		defer f.milling.Done()
+		f.mill()
+	}()
+	return nil
+}
+
+// backupName returns the name of the file rotated at the time 't'.
+func (f *rotatingFile) backupName(t time.Time) string {
+	dir, prefix, ext := f.nameParts()
+	return filepath.Join(dir, prefix+t.UTC().Format(backupTimeFormat)+ext)
+}
+
+// nameParts returns the directory, the prefix and the extension of the rotated files.
+func (f *rotatingFile) nameParts() (string, string, string) {
+	dir, name := filepath.Split(f.config.Path)
+	ext := filepath.Ext(name)
+	return dir, strings.TrimSuffix(name, ext) + "-", ext
+}
+
+// backup is the rotated log file.
+type backup struct {
+	path string
+	time time.Time
+}
+
+// backups returns the rotated files, the newest first.
+func (f *rotatingFile) backups() ([]backup, error) {
+	dir, prefix, ext := f.nameParts()
+
+	entries, err := os.ReadDir(filepath.Clean(dir))
+	if err != nil {
+		return nil, err
+	}
+
+	var backups []backup
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() || !strings.HasPrefix(name, prefix) {
+			continue
+		}
+
+		timestamp := strings.TrimSuffix(strings.TrimSuffix(strings.TrimPrefix(name, prefix), compressSuffix), ext)
+		t, err := time.Parse(backupTimeFormat, timestamp)
+		if err != nil {
+			continue
+		}
+		backups = append(backups, backup{path: filepath.Join(dir, name), time: t})
+	}
+
+	sort.Slice(backups, func(i, j int) bool { return backups[i].time.After(backups[j].time) })
+	return backups, nil
+}
+
+// mill removes the rotated files above the maximum count and age,
+// and compresses the rest. The failures are reported to stderr,
+// as the log itself is not available here.
+func (f *rotatingFile) mill() {
+	f.millMu.Lock()
+	defer f.millMu.Unlock()
+
+	if err := f.millBackups(); err != nil {
+		_, _ = io.WriteString(os.Stderr, "Failed to process the rotated log files: "+err.Error()+"\n")
+	}
+}
+
+func (f *rotatingFile) millBackups() error {
+	backups, err := f.backups()
+	if err != nil {
+		return err
+	}
+
+	var errs []error
+	cutoff := f.now().Add(-f.config.MaxAge)
+	for i, b := range backups {
+		expired := f.config.MaxAge > 0 && b.time.Before(cutoff)
+		excess := f.config.MaxBackups > 0 && i >= f.config.MaxBackups
+
+		if expired || excess {
+			if err := os.Remove(b.path); err != nil && !errors.Is(err, os.ErrNotExist) {
+				errs = append(errs, err)
+			}
+			continue
+		}
+
+		if f.config.Compress && !strings.HasSuffix(b.path, compressSuffix) {
+			if err := compressFile(b.path); err != nil {
+				errs = append(errs, err)
+			}
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// compressFile compresses the file with gzip, and removes the original.
+func compressFile(path string) (err error) {
+	source, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer source.Close()
+
+	// The partially compressed file is removed on failure
+	target, err := os.OpenFile(path+compressSuffix, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0644)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err != nil {
+			_ = os.Remove(path + compressSuffix)
+		}
+	}()
+
+	writer := gzip.NewWriter(target)
+	if _, err = io.Copy(writer, source); err != nil {
+		_ = target.Close()
+		return err
+	}
+	if err = writer.Close(); err != nil {
+		_ = target.Close()
+		return err
+	}
+	if err = target.Close(); err != nil {
+		return err
+	}
+
+	return os.Remove(path)
+}
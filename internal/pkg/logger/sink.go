@@ -0,0 +1,269 @@
+package logger
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+const (
+
+	// SinkStdout writes the log to the standard output
+	SinkStdout = "stdout"
+
+	// SinkStderr writes the log to the standard error
+	SinkStderr = "stderr"
+
+	// SinkFile writes the log to the rotating file
+	SinkFile = "file"
+
+	// SinkSyslog writes the log to the syslog server
+	SinkSyslog = "syslog"
+)
+
+var (
+	// ErrUnknownSink happens when the sink type is not supported.
+	ErrUnknownSink = errors.New("unknown log sink")
+
+	// ErrNoFilePath happens when the file sink has no path.
+	ErrNoFilePath = errors.New("no log file path provided")
+)
+
+// SinkConfig is a configuration of the log output.
+type SinkConfig struct {
+
+	// Supported sink types:
+	// - stdout
+	// - stderr
+	// - file
+	// - syslog
+	Type string
+
+	// Level is the threshold of the sink, the entries below it are not
+	// written to the sink. The entries below the log level are not
+	// written at all, so the threshold could only raise the log level.
+	// All the entries are written if it is empty.
+	Level string
+
+	// File is the configuration of the file sink.
+	File FileConfig
+
+	// Syslog is the configuration of the syslog sink.
+	Syslog SyslogConfig
+}
+
+// FileConfig is a configuration of the rotating log file.
+type FileConfig struct {
+
+	// Path is the path of the log file, the rotated files
+	// are kept in the same directory with the timestamp suffix,
+	// for example "gogin-2023-05-01T10-00-00.000.log".
+	Path string
+
+	// MaxSize is the size of the file in megabytes it is rotated at,
+	// the file is never rotated if it is zero.
+	MaxSize int
+
+	// MaxAge is the age of the rotated files they are removed at,
+	// the rotated files are not removed by age if it is zero.
+	MaxAge time.Duration
+
+	// MaxBackups is the number of the rotated files that are kept,
+	// all the rotated files are kept if it is zero.
+	MaxBackups int
+
+	// Compress compresses the rotated files with gzip.
+	Compress bool
+}
+
+// SyslogConfig is a configuration of the syslog server connection.
+type SyslogConfig struct {
+
+	// Supported networks:
+	// - udp
+	// - tcp
+	// - unix
+	Network string
+
+	// Addr is the address of the syslog server,
+	// for example "localhost:514" or "/dev/log".
+	Addr string
+
+	// Tag is the tag of the syslog messages.
+	Tag string
+}
+
+// Validate checks the sink configuration.
+func (c SinkConfig) Validate() error {
+	if c.Level != "" {
+		if _, err := logrus.ParseLevel(c.Level); err != nil {
+			return err
+		}
+	}
+
+	switch c.Type {
+	case SinkStdout, SinkStderr:
+		return nil
+
+	case SinkFile:
+		if c.File.Path == "" {
+			return ErrNoFilePath
+		}
+		return nil
+
+	case SinkSyslog:
+		return c.Syslog.Validate()
+
+	default:
+		return fmt.Errorf("%w: %q", ErrUnknownSink, c.Type)
+	}
+}
+
+// sink writes the formatted log entries of the 'level' to the output.
+type sink interface {
+	write(level logrus.Level, entry []byte) error
+	io.Closer
+}
+
+// newSink creates a new sink of the configuration type.
+func newSink(config SinkConfig) (sink, error) {
+	switch config.Type {
+	case SinkStdout:
+		return &writerSink{writer: os.Stdout}, nil
+
+	case SinkStderr:
+		return &writerSink{writer: os.Stderr}, nil
+
+	case SinkFile:
+		return newRotatingFile(config.File)
+
+	case SinkSyslog:
+		return newSyslogSink(config.Syslog)
+
+	default:
+		return nil, fmt.Errorf("%w: %q", ErrUnknownSink, config.Type)
+	}
+}
+
+// writerSink writes the log entries to the standard output or error.
+type writerSink struct {
+	mu     sync.Mutex
+	writer io.Writer
+}
+
+func (s *writerSink) write(level logrus.Level, entry []byte) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	_, err := s.writer.Write(entry)
+	return err
+}
+
+// Close keeps the standard outputs open.
+func (s *writerSink) Close() error {
+	return nil
+}
+
+// levelSink is the sink with its level threshold.
+type levelSink struct {
+	sink
+	level logrus.Level
+}
+
+// output is the logrus hook that formats the log entries once
+// and fans them out to the sinks by their level thresholds.
+type output struct {
+	mu        sync.RWMutex
+	formatter logrus.Formatter
+	sinks     []levelSink
+}
+
+// newOutput creates the output with the 'formatter' and the sinks of the
+// 'configs', the log is written to the standard output if there are none.
+func newOutput(formatter logrus.Formatter, configs []SinkConfig) (*output, error) {
+	if len(configs) == 0 {
+		configs = []SinkConfig{{Type: SinkStdout}}
+	}
+
+	o := &output{formatter: formatter}
+	for _, config := range configs {
+		if err := config.Validate(); err != nil {
+			_ = o.Close()
+			return nil, err
+		}
+
+		level := logrus.TraceLevel
+		if config.Level != "" {
+			level, _ = logrus.ParseLevel(config.Level)
+		}
+
+		s, err := newSink(config)
+		if err != nil {
+			_ = o.Close()
+			return nil, fmt.Errorf("failed to create %s log sink: %w", config.Type, err)
+		}
+		o.sinks = append(o.sinks, levelSink{sink: s, level: level})
+	}
+
+	return o, nil
+}
+
+// Levels returns all the levels, the sinks filter them by their thresholds.
+func (o *output) Levels() []logrus.Level {
+	return logrus.AllLevels
+}
+
+// Fire formats the log entry and writes it to the sinks, the entry
+// is written to all the sinks even if some of them fail.
+func (o *output) Fire(entry *logrus.Entry) error {
+	o.mu.RLock()
+	formatter := o.formatter
+	o.mu.RUnlock()
+
+	formatted, err := formatter.Format(entry)
+	if err != nil {
+		return err
+	}
+
+	var errs []error
+	for _, s := range o.sinks {
+		if entry.Level > s.level {
+			continue
+		}
+		if err := s.write(entry.Level, formatted); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// setFormatter changes the formatter of the log entries.
+func (o *output) setFormatter(formatter logrus.Formatter) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	o.formatter = formatter
+}
+
+// Close closes all the sinks.
+func (o *output) Close() error {
+	var errs []error
+	for _, s := range o.sinks {
+		if err := s.Close(); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// discardFormatter skips formatting of the log entries by the logrus
+// logger itself, they are formatted and written by the output hook.
+type discardFormatter struct{}
+
+func (discardFormatter) Format(*logrus.Entry) ([]byte, error) {
+	return nil, nil
+}
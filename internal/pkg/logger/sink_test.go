@@ -0,0 +1,265 @@
+package logger
+
+import (
+	"compress/gzip"
+	"io"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestSinks(t *testing.T) {
+	for scenario, fn := range map[string]func(t *testing.T, dir string){
+		"fans out by level":           testFansOutByLevel,
+		"changes formatter":           testChangesFormatter,
+		"rotates file":                testRotatesFile,
+		"removes excess backups":      testRemovesExcessBackups,
+		"removes expired backups":     testRemovesExpiredBackups,
+		"compresses backups":          testCompressesBackups,
+		"writes to syslog":            testWritesToSyslog,
+		"rejects invalid sinks":       testRejectsInvalidSinks,
+		"stops writing once closed":   testStopsWritingOnceClosed,
+		"appends to existing file":    testAppendsToExistingFile,
+		"writes to stdout if no sink": testWritesToStdoutIfNoSink,
+	} {
+		t.Run(scenario, func(t *testing.T) {
+			fn(t, t.TempDir())
+		})
+	}
+}
+
+// readFile returns the content of the file at the 'path'.
+func readFile(t *testing.T, path string) string {
+	content, err := os.ReadFile(path)
+	require.NoError(t, err)
+	return string(content)
+}
+
+// newRotatingFileAt creates the rotating file with the clock at the 'now' time.
+func newRotatingFileAt(t *testing.T, config FileConfig, now *time.Time) *rotatingFile {
+	f, err := newRotatingFile(config)
+	require.NoError(t, err)
+	f.now = func() time.Time { return *now }
+	return f
+}
+
+// rotate rotates the file and waits for the rotated files to be processed.
+func rotate(t *testing.T, f *rotatingFile) {
+	f.mu.Lock()
+	require.NoError(t, f.rotate())
+	f.mu.Unlock()
+	f.milling.Wait()
+}
+
+// backupNames returns the sorted names of the rotated files in the 'dir'.
+func backupNames(t *testing.T, dir string) []string {
+	entries, err := os.ReadDir(dir)
+	require.NoError(t, err)
+
+	var names []string
+	for _, entry := range entries {
+		if entry.Name() != "gogin.log" {
+			names = append(names, entry.Name())
+		}
+	}
+	return names
+}
+
+func testFansOutByLevel(t *testing.T, dir string) {
+	all := filepath.Join(dir, "all.log")
+	warnings := filepath.Join(dir, "warnings.log")
+
+	l, err := New(Config{
+		Level:     "debug",
+		Formatter: "text",
+		Sinks: []SinkConfig{
+			{Type: SinkFile, File: FileConfig{Path: all}},
+			{Type: SinkFile, Level: "warning", File: FileConfig{Path: warnings}},
+		},
+	})
+	require.NoError(t, err)
+
+	l.Trace("trace message")
+	l.WithField(FieldPackage, "test").Debug("debug message")
+	l.Warn("warning message")
+	require.NoError(t, l.Close())
+
+	content := readFile(t, all)
+	require.NotContains(t, content, "trace message")
+	require.Contains(t, content, "debug message")
+	require.Contains(t, content, "package=test")
+	require.Contains(t, content, "warning message")
+
+	content = readFile(t, warnings)
+	require.NotContains(t, content, "debug message")
+	require.Contains(t, content, "warning message")
+}
+
+func testChangesFormatter(t *testing.T, dir string) {
+	path := filepath.Join(dir, "gogin.log")
+
+	l, err := New(Config{Level: "info", Formatter: "json", Sinks: []SinkConfig{{Type: SinkFile, File: FileConfig{Path: path}}}})
+	require.NoError(t, err)
+
+	l.Info("json message")
+	require.NoError(t, l.WithField(FieldPackage, "test").SetFormatter("text"))
+	l.Info("text message")
+	require.NoError(t, l.Close())
+
+	lines := strings.Split(strings.TrimSpace(readFile(t, path)), "\n")
+	require.Len(t, lines, 2)
+	require.True(t, strings.HasPrefix(lines[0], "{"))
+	require.Contains(t, lines[1], `msg="text message"`)
+}
+
+func testRotatesFile(t *testing.T, dir string) {
+	path := filepath.Join(dir, "gogin.log")
+	now := time.Date(2023, 5, 1, 10, 0, 0, 0, time.UTC)
+
+	f := newRotatingFileAt(t, FileConfig{Path: path, MaxSize: 1}, &now)
+	entry := []byte(strings.Repeat("a", megabyte/2-1) + "\n")
+
+	require.NoError(t, f.write(0, entry))
+	require.NoError(t, f.write(0, entry))
+	require.NoError(t, f.write(0, entry))
+	require.NoError(t, f.Close())
+
+	require.Equal(t, []string{"gogin-2023-05-01T10-00-00.000.log"}, backupNames(t, dir))
+	require.Len(t, readFile(t, filepath.Join(dir, "gogin-2023-05-01T10-00-00.000.log")), megabyte)
+	require.Len(t, readFile(t, path), megabyte/2)
+}
+
+func testRemovesExcessBackups(t *testing.T, dir string) {
+	path := filepath.Join(dir, "gogin.log")
+	now := time.Date(2023, 5, 1, 10, 0, 0, 0, time.UTC)
+
+	f := newRotatingFileAt(t, FileConfig{Path: path, MaxBackups: 2}, &now)
+	for i := 0; i < 4; i++ {
+		require.NoError(t, f.write(0, []byte("entry\n")))
+		rotate(t, f)
+		now = now.Add(time.Minute)
+	}
+	require.NoError(t, f.Close())
+
+	require.Equal(t, []string{"gogin-2023-05-01T10-02-00.000.log", "gogin-2023-05-01T10-03-00.000.log"}, backupNames(t, dir))
+}
+
+func testRemovesExpiredBackups(t *testing.T, dir string) {
+	path := filepath.Join(dir, "gogin.log")
+	now := time.Date(2023, 5, 1, 10, 0, 0, 0, time.UTC)
+
+	f := newRotatingFileAt(t, FileConfig{Path: path, MaxAge: 24 * time.Hour}, &now)
+	for i := 0; i < 3; i++ {
+		require.NoError(t, f.write(0, []byte("entry\n")))
+		rotate(t, f)
+		now = now.Add(20 * time.Hour)
+	}
+	require.NoError(t, f.Close())
+
+	require.Equal(t, []string{"gogin-2023-05-02T06-00-00.000.log", "gogin-2023-05-03T02-00-00.000.log"}, backupNames(t, dir))
+}
+
+func testCompressesBackups(t *testing.T, dir string) {
+	path := filepath.Join(dir, "gogin.log")
+	now := time.Date(2023, 5, 1, 10, 0, 0, 0, time.UTC)
+
+	f := newRotatingFileAt(t, FileConfig{Path: path, Compress: true}, &now)
+	require.NoError(t, f.write(0, []byte("entry\n")))
+	rotate(t, f)
+	require.NoError(t, f.Close())
+
+	require.Equal(t, []string{"gogin-2023-05-01T10-00-00.000.log.gz"}, backupNames(t, dir))
+
+	file, err := os.Open(filepath.Join(dir, "gogin-2023-05-01T10-00-00.000.log.gz"))
+	require.NoError(t, err)
+	defer file.Close()
+
+	reader, err := gzip.NewReader(file)
+	require.NoError(t, err)
+	content, err := io.ReadAll(reader)
+	require.NoError(t, err)
+	require.Equal(t, "entry\n", string(content))
+}
+
+func testWritesToSyslog(t *testing.T, dir string) {
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer conn.Close()
+
+	l, err := New(Config{
+		Level:     "info",
+		Formatter: "json",
+		Sinks: []SinkConfig{{
+			Type:   SinkSyslog,
+			Level:  "warning",
+			Syslog: SyslogConfig{Network: SyslogUdp, Addr: conn.LocalAddr().String(), Tag: "gogin"},
+		}},
+	})
+	require.NoError(t, err)
+	defer l.Close()
+
+	l.Info("info message")
+	l.Warn("warning message")
+
+	require.NoError(t, conn.SetReadDeadline(time.Now().Add(time.Second)))
+	buffer := make([]byte, 4096)
+	n, _, err := conn.ReadFrom(buffer)
+	require.NoError(t, err)
+
+	// The daemon facility with the warning severity
+	message := string(buffer[:n])
+	require.True(t, strings.HasPrefix(message, "<28>"), message)
+	require.Contains(t, message, "gogin")
+	require.Contains(t, message, "warning message")
+}
+
+func testRejectsInvalidSinks(t *testing.T, dir string) {
+	for _, sink := range []SinkConfig{
+		{Type: "kafka"},
+		{Type: SinkStdout, Level: "verbose"},
+		{Type: SinkFile},
+		{Type: SinkSyslog, Syslog: SyslogConfig{Network: "http", Addr: "localhost:514"}},
+		{Type: SinkSyslog, Syslog: SyslogConfig{Network: SyslogTcp}},
+	} {
+		config := Config{Level: "info", Formatter: "json", Sinks: []SinkConfig{sink}}
+		require.Error(t, config.Validate(), sink.Type)
+
+		_, err := New(config)
+		require.Error(t, err, sink.Type)
+	}
+
+	require.ErrorIs(t, SinkConfig{Type: "kafka"}.Validate(), ErrUnknownSink)
+}
+
+func testStopsWritingOnceClosed(t *testing.T, dir string) {
+	f, err := newRotatingFile(FileConfig{Path: filepath.Join(dir, "gogin.log")})
+	require.NoError(t, err)
+	require.NoError(t, f.Close())
+
+	require.ErrorIs(t, f.write(0, []byte("entry\n")), os.ErrClosed)
+}
+
+func testAppendsToExistingFile(t *testing.T, dir string) {
+	path := filepath.Join(dir, "logs", "gogin.log")
+	require.NoError(t, os.MkdirAll(filepath.Dir(path), 0755))
+	require.NoError(t, os.WriteFile(path, []byte("previous\n"), 0644))
+
+	f, err := newRotatingFile(FileConfig{Path: path})
+	require.NoError(t, err)
+	require.NoError(t, f.write(0, []byte("next\n")))
+	require.NoError(t, f.Close())
+
+	require.Equal(t, "previous\nnext\n", readFile(t, path))
+}
+
+func testWritesToStdoutIfNoSink(t *testing.T, dir string) {
+	o, err := newOutput(nil, nil)
+	require.NoError(t, err)
+	require.Len(t, o.sinks, 1)
+	require.Equal(t, &writerSink{writer: os.Stdout}, o.sinks[0].sink)
+}
@@ -0,0 +1,87 @@
+package logger
+
+import (
+	"errors"
+	"fmt"
+	"log/syslog"
+
+	"github.com/sirupsen/logrus"
+)
+
+const (
+
+	// Networks of the syslog server connection
+	SyslogUdp  = "udp"
+	SyslogTcp  = "tcp"
+	SyslogUnix = "unix"
+)
+
+var (
+	// ErrUnknownSyslogNetwork happens when the syslog network is not supported.
+	ErrUnknownSyslogNetwork = errors.New("unknown syslog network")
+
+	// ErrNoSyslogAddr happens when the syslog server has no address.
+	ErrNoSyslogAddr = errors.New("no syslog address provided")
+)
+
+// Validate checks the syslog network and address.
+func (c SyslogConfig) Validate() error {
+	switch c.Network {
+	case SyslogUdp, SyslogTcp, SyslogUnix:
+	default:
+		return fmt.Errorf("%w: %q", ErrUnknownSyslogNetwork, c.Network)
+	}
+
+	if c.Addr == "" {
+		return ErrNoSyslogAddr
+	}
+	return nil
+}
+
+// syslogSink writes the log entries to the syslog server
+// with the severity of their level.
+type syslogSink struct {
+	writer *syslog.Writer
+}
+
+// newSyslogSink connects to the syslog server, the connection is
+// restored by the writer once it is lost.
+func newSyslogSink(config SyslogConfig) (*syslogSink, error) {
+	network := config.Network
+
+	// The local syslog daemons listen on the datagram sockets
+	if network == SyslogUnix {
+		network = "unixgram"
+	}
+
+	writer, err := syslog.Dial(network, config.Addr, syslog.LOG_INFO|syslog.LOG_DAEMON, config.Tag)
+	if err != nil && config.Network == SyslogUnix {
+		writer, err = syslog.Dial(SyslogUnix, config.Addr, syslog.LOG_INFO|syslog.LOG_DAEMON, config.Tag)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return &syslogSink{writer: writer}, nil
+}
+
+func (s *syslogSink) write(level logrus.Level, entry []byte) error {
+	message := string(entry)
+
+	switch level {
+	case logrus.PanicLevel, logrus.FatalLevel:
+		return s.writer.Crit(message)
+	case logrus.ErrorLevel:
+		return s.writer.Err(message)
+	case logrus.WarnLevel:
+		return s.writer.Warning(message)
+	case logrus.InfoLevel:
+		return s.writer.Info(message)
+	default:
+		return s.writer.Debug(message)
+	}
+}
+
+func (s *syslogSink) Close() error {
+	return s.writer.Close()
+}
@@ -0,0 +1,207 @@
+package markdown
+
+import (
+	"bytes"
+	"html/template"
+	"regexp"
+	"strings"
+
+	chromahtml "github.com/alecthomas/chroma/v2/formatters/html"
+	"github.com/alecthomas/chroma/v2/styles"
+	"github.com/microcosm-cc/bluemonday"
+	"github.com/yuin/goldmark"
+	"github.com/yuin/goldmark/ast"
+	"github.com/yuin/goldmark/extension"
+	"github.com/yuin/goldmark/parser"
+	"github.com/yuin/goldmark/text"
+
+	highlighting "github.com/yuin/goldmark-highlighting/v2"
+)
+
+const (
+
+	// highlightStyle is the chroma style used to highlight fenced code blocks.
+	highlightStyle = "github"
+
+	// maxTocLevel is the deepest heading level included into the table of contents.
+	maxTocLevel = 3
+)
+
+var (
+
+	// classNames matches the CSS class names produced by the code highlighter.
+	classNames = regexp.MustCompile(`^[a-zA-Z0-9_\- ]+$`)
+
+	// pageTemplate is the standalone HTML page of the rendered markdown document.
+	pageTemplate = template.Must(template.New("page").Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>{{.Title}}</title>
+<style>{{.Style}}</style>
+</head>
+<body>
+{{- if .Toc}}
+<nav class="toc">
+<ul>
+{{- range .Toc}}
+<li class="toc-h{{.Level}}"><a href="#{{.Id}}">{{.Title}}</a></li>
+{{- end}}
+</ul>
+</nav>
+{{- end}}
+<article>
+{{.Body}}
+</article>
+</body>
+</html>
+`))
+)
+
+// Heading is a single entry of the document table of contents.
+type Heading struct {
+
+	// Level is the heading level, from 1 to 6.
+	Level int
+
+	// Id is the anchor of the heading in the rendered document.
+	Id string
+
+	// Title is the plain text of the heading.
+	Title string
+}
+
+// Document is a rendered and sanitized markdown document.
+type Document struct {
+
+	// Toc is the document table of contents.
+	Toc []Heading
+
+	// Body is the sanitized HTML of the document.
+	Body string
+}
+
+// renderer renders markdown documents to HTML.
+//
+// The rendered HTML is always sanitized, so it is safe
+// to serve the documents provided by the users.
+type renderer struct {
+	markdown goldmark.Markdown
+	policy   *bluemonday.Policy
+	style    template.CSS
+}
+
+// NewRenderer creates a new markdown renderer with GitHub flavored
+// markdown support and syntax highlighting of fenced code blocks.
+func NewRenderer() (*renderer, error) {
+
+	// Highlighting produces CSS classes instead of inline styles,
+	// so the sanitizer doesn't need to allow the 'style' attribute.
+	md := goldmark.New(
+		goldmark.WithExtensions(
+			extension.GFM,
+			highlighting.NewHighlighting(
+				highlighting.WithStyle(highlightStyle),
+				highlighting.WithFormatOptions(
+					chromahtml.WithClasses(true),
+				),
+			),
+		),
+		goldmark.WithParserOptions(
+			parser.WithAutoHeadingID(),
+		),
+	)
+
+	// Stylesheet for the highlighted code blocks
+	var style bytes.Buffer
+	formatter := chromahtml.New(chromahtml.WithClasses(true))
+	if err := formatter.WriteCSS(&style, styles.Get(highlightStyle)); err != nil {
+		return nil, err
+	}
+
+	// User generated content policy extended with
+	// the CSS classes of the code highlighter.
+	policy := bluemonday.UGCPolicy()
+	policy.AllowAttrs("class").Matching(classNames).OnElements("pre", "code", "span")
+
+	return &renderer{
+		markdown: md,
+		policy:   policy,
+		style:    template.CSS(style.String()),
+	}, nil
+}
+
+// Render renders the markdown 'source' to the sanitized HTML
+// and collects the document table of contents.
+func (r *renderer) Render(source []byte) (Document, error) {
+	doc := r.markdown.Parser().Parse(text.NewReader(source))
+
+	var body bytes.Buffer
+	if err := r.markdown.Renderer().Render(&body, source, doc); err != nil {
+		return Document{}, err
+	}
+
+	return Document{
+		Toc:  tableOfContents(doc, source),
+		Body: r.policy.Sanitize(body.String()),
+	}, nil
+}
+
+// RenderPage renders the markdown 'source' to a standalone HTML page
+// with the provided 'title' and the table of contents.
+func (r *renderer) RenderPage(title string, source []byte) ([]byte, error) {
+	doc, err := r.Render(source)
+	if err != nil {
+		return nil, err
+	}
+
+	var page bytes.Buffer
+	err = pageTemplate.Execute(&page, struct {
+		Title string
+		Style template.CSS
+		Toc   []Heading
+		Body  template.HTML
+	}{
+		Title: title,
+		Style: r.style,
+		Toc:   doc.Toc,
+		// The body is already sanitized by the policy
+		Body: template.HTML(doc.Body),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return page.Bytes(), nil
+}
+
+// tableOfContents collects the document headings.
+func tableOfContents(doc ast.Node, source []byte) []Heading {
+	var toc []Heading
+
+	ast.Walk(doc, func(n ast.Node, entering bool) (ast.WalkStatus, error) {
+		if !entering {
+			return ast.WalkContinue, nil
+		}
+
+		heading, ok := n.(*ast.Heading)
+		if !ok {
+			return ast.WalkContinue, nil
+		}
+
+		if heading.Level <= maxTocLevel {
+			id, _ := heading.AttributeString("id")
+			idBytes, _ := id.([]byte)
+
+			toc = append(toc, Heading{
+				Level: heading.Level,
+				Id:    string(idBytes),
+				Title: strings.TrimSpace(string(heading.Text(source))),
+			})
+		}
+
+		return ast.WalkSkipChildren, nil
+	})
+
+	return toc
+}
@@ -0,0 +1,89 @@
+package markdown
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestRenderer(t *testing.T) {
+	for scenario, fn := range map[string]func(
+		t *testing.T,
+		r *renderer,
+	){
+		"strips raw html and scripts":     testStripsScripts,
+		"strips javascript links":         testStripsJavascriptLinks,
+		"highlights fenced code blocks":   testHighlightsCodeBlocks,
+		"collects table of contents":      testCollectsTableOfContents,
+		"renders page with escaped title": testRendersPageWithEscapedTitle,
+	} {
+		t.Run(scenario, func(t *testing.T) {
+			r, err := NewRenderer()
+			require.NoError(t, err)
+
+			fn(t, r)
+		})
+	}
+}
+
+func testStripsScripts(
+	t *testing.T,
+	r *renderer,
+) {
+
+	doc, err := r.Render([]byte("# Title\n\n<script>alert(1)</script>\n\n<img src=x onerror=alert(1)>"))
+
+	require.NoError(t, err)
+	require.NotContains(t, doc.Body, "<script")
+	require.NotContains(t, doc.Body, "onerror")
+}
+
+func testStripsJavascriptLinks(
+	t *testing.T,
+	r *renderer,
+) {
+
+	doc, err := r.Render([]byte("[click](javascript:alert(1))"))
+
+	require.NoError(t, err)
+	require.NotContains(t, doc.Body, "javascript:")
+}
+
+func testHighlightsCodeBlocks(
+	t *testing.T,
+	r *renderer,
+) {
+
+	doc, err := r.Render([]byte("```go\nfunc main() {}\n```"))
+
+	require.NoError(t, err)
+	require.Contains(t, doc.Body, `class="chroma"`)
+	require.Contains(t, doc.Body, `<span class="kd">func</span>`)
+}
+
+func testCollectsTableOfContents(
+	t *testing.T,
+	r *renderer,
+) {
+
+	doc, err := r.Render([]byte("# Runbook\n\n## Restart the service\n\n#### Too deep"))
+
+	require.NoError(t, err)
+	require.Equal(t, []Heading{
+		{Level: 1, Id: "runbook", Title: "Runbook"},
+		{Level: 2, Id: "restart-the-service", Title: "Restart the service"},
+	}, doc.Toc)
+	require.Contains(t, doc.Body, `<h2 id="restart-the-service">`)
+}
+
+func testRendersPageWithEscapedTitle(
+	t *testing.T,
+	r *renderer,
+) {
+
+	page, err := r.RenderPage("<b>Runbook</b>", []byte("# Runbook"))
+
+	require.NoError(t, err)
+	require.Contains(t, string(page), "<title>&lt;b&gt;Runbook&lt;/b&gt;</title>")
+	require.Contains(t, string(page), `<a href="#runbook">Runbook</a>`)
+}
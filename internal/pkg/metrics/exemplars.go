@@ -0,0 +1,83 @@
+package metrics
+
+import (
+	"context"
+	"unicode/utf8"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"go.opentelemetry.io/otel/trace"
+
+	"git.lothric.net/examples/go/gogin/internal/pkg/logger"
+)
+
+const (
+
+	// ExemplarRequestId is the exemplar label with the request correlation id.
+	ExemplarRequestId = "request_id"
+
+	// ExemplarTraceId is the exemplar label with the request trace id.
+	ExemplarTraceId = "trace_id"
+)
+
+// exemplar returns the exemplar labels without the empty values,
+// or nil if there are no labels, so the observation has no exemplar.
+//
+// OpenMetrics limits the exemplar labels to 128 characters in total,
+// the labels that don't fit the limit are dropped, so a long correlation
+// id provided by a client doesn't prevent the observation.
+func exemplar(labels map[string]string) prometheus.Labels {
+	var result prometheus.Labels
+	runes := 0
+
+	// The trace id goes first as the most useful one
+	for _, name := range []string{ExemplarTraceId, ExemplarRequestId} {
+		value := labels[name]
+		if value == "" || !utf8.ValidString(value) {
+			continue
+		}
+
+		n := utf8.RuneCountInString(name) + utf8.RuneCountInString(value)
+		if runes+n > prometheus.ExemplarMaxRunes {
+			continue
+		}
+		runes += n
+
+		if result == nil {
+			result = prometheus.Labels{}
+		}
+		result[name] = value
+	}
+
+	return result
+}
+
+// contextExemplar returns the exemplar labels of the request
+// that is handled within the 'ctx'.
+func contextExemplar(ctx context.Context) prometheus.Labels {
+	corrId, _ := ctx.Value(logger.CorrelationId).(string)
+
+	return exemplar(map[string]string{
+		ExemplarRequestId: corrId,
+		ExemplarTraceId:   TraceId(ctx),
+	})
+}
+
+// TraceId returns the id of the trace that is active within the 'ctx',
+// or empty string if the trace is not sampled, so the exemplars
+// only link to the traces that could be found in the tracing backend.
+func TraceId(ctx context.Context) string {
+	sc := trace.SpanContextFromContext(ctx)
+	if !sc.IsSampled() {
+		return ""
+	}
+	return sc.TraceID().String()
+}
+
+// observe records the 'value' with the 'exemplar' if there is one.
+func observe(observer prometheus.Observer, value float64, exemplar prometheus.Labels) {
+	if eo, ok := observer.(prometheus.ExemplarObserver); ok && exemplar != nil {
+		eo.ObserveWithExemplar(value, exemplar)
+		return
+	}
+	observer.Observe(value)
+}
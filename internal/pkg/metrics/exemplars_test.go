@@ -0,0 +1,120 @@
+package metrics
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/trace"
+
+	"git.lothric.net/examples/go/gogin/internal/pkg/logger"
+)
+
+func TestExemplars(t *testing.T) {
+	for scenario, fn := range map[string]func(
+		t *testing.T,
+		r *reporter,
+		m *Registry,
+	){
+		"exposes request duration exemplar": testExposesRequestDurationExemplar,
+		"exposes search duration exemplar":  testExposesSearchDurationExemplar,
+		"drops labels over the limit":       testDropsExemplarLabelsOverLimit,
+	} {
+		t.Run(scenario, func(t *testing.T) {
+			log, _ := logger.NewNullLogger()
+
+			registry, err := NewRegistry(Config{})
+			require.NoError(t, err)
+
+			reporter, err := NewReporter(log, registry)
+			require.NoError(t, err)
+
+			fn(t, reporter, registry)
+		})
+	}
+}
+
+// scrape returns the metrics in the OpenMetrics format,
+// that is the only format with the exemplars.
+func scrape(t *testing.T, m *Registry) string {
+	handler := promhttp.HandlerFor(m.Gatherer(), promhttp.HandlerOpts{EnableOpenMetrics: true})
+
+	req := httptest.NewRequest(http.MethodGet, "/metrics", nil)
+	req.Header.Set("Accept", "application/openmetrics-text")
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+
+	body, err := io.ReadAll(rec.Body)
+	require.NoError(t, err)
+	return string(body)
+}
+
+func testExposesRequestDurationExemplar(
+	t *testing.T,
+	r *reporter,
+	m *Registry,
+) {
+
+	r.ApiRequestProcessed(http.MethodGet, "/api/gists", http.StatusOK, 15*time.Millisecond, 0, 64, map[string]string{
+		ExemplarRequestId: "a1b2c3",
+		ExemplarTraceId:   "4bf92f3577b34da6a3ce929d0e0e4736",
+	})
+
+	// The order of the exemplar labels is not defined
+	bucket := `request_durations_histogram_seconds_bucket\{method="GET",route="/api/gists",status="2xx",le="0.02"\} 1 # \{`
+	body := scrape(t, m)
+	require.Regexp(t, bucket+`[^}]*\} 0.015 [0-9.e+]+`, body)
+	require.Regexp(t, bucket+`[^}]*request_id="a1b2c3"`, body)
+	require.Regexp(t, bucket+`[^}]*trace_id="4bf92f3577b34da6a3ce929d0e0e4736"`, body)
+}
+
+func testExposesSearchDurationExemplar(
+	t *testing.T,
+	r *reporter,
+	m *Registry,
+) {
+
+	ctx := context.WithValue(context.Background(), logger.CorrelationId, "a1b2c3")
+	r.GistsSearched(ctx, 2*time.Millisecond)
+
+	require.Regexp(t,
+		`gists_search_duration_seconds_bucket\{le="0.005"\} 1 # \{request_id="a1b2c3"\} 0.002 [0-9.e+]+`,
+		scrape(t, m))
+
+	// The trace id of the sampled span is linked
+	traceId, _ := trace.TraceIDFromHex("4bf92f3577b34da6a3ce929d0e0e4736")
+	spanId, _ := trace.SpanIDFromHex("00f067aa0ba902b7")
+	ctx = trace.ContextWithSpanContext(context.Background(), trace.NewSpanContext(trace.SpanContextConfig{
+		TraceID:    traceId,
+		SpanID:     spanId,
+		TraceFlags: trace.FlagsSampled,
+	}))
+	r.GistsSearched(ctx, 20*time.Millisecond)
+
+	require.Regexp(t,
+		`gists_search_duration_seconds_bucket\{le="0.025"\} 2 # \{trace_id="4bf92f3577b34da6a3ce929d0e0e4736"\} 0.02 [0-9.e+]+`,
+		scrape(t, m))
+}
+
+func testDropsExemplarLabelsOverLimit(
+	t *testing.T,
+	r *reporter,
+	m *Registry,
+) {
+
+	traceId := "4bf92f3577b34da6a3ce929d0e0e4736"
+	labels := exemplar(map[string]string{
+		ExemplarRequestId: strings.Repeat("x", prometheus.ExemplarMaxRunes),
+		ExemplarTraceId:   traceId,
+	})
+	require.Equal(t, prometheus.Labels{ExemplarTraceId: traceId}, labels)
+
+	require.Nil(t, exemplar(map[string]string{ExemplarRequestId: ""}))
+}
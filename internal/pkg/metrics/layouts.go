@@ -0,0 +1,234 @@
+package metrics
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const (
+
+	// nativeMaxBuckets limits the number of native histogram buckets,
+	// the resolution is reduced if the histogram gets more buckets.
+	nativeMaxBuckets = 160
+
+	// nativeMinResetDuration is the minimal time between
+	// the native histogram resets to reduce the number of buckets.
+	nativeMinResetDuration = time.Hour
+)
+
+var (
+
+	// ErrInvalidBuckets happens when the histogram bucket layout could not be parsed.
+	ErrInvalidBuckets = errors.New("invalid histogram buckets")
+
+	// ErrInvalidObjectives happens when the summary objectives could not be parsed.
+	ErrInvalidObjectives = errors.New("invalid summary objectives")
+
+	// ErrInvalidNativeFactor happens when the native histogram bucket factor is not greater than 1.
+	ErrInvalidNativeFactor = errors.New("native histogram bucket factor should be greater than 1")
+
+	// ErrUnknownMetricFamily happens when the layout is configured for a metric that doesn't exist.
+	ErrUnknownMetricFamily = errors.New("unknown metric family")
+
+	// defaultObjectives are the quantiles of the summaries with their allowed errors.
+	defaultObjectives = map[float64]float64{0.5: 0.05, 0.9: 0.01, 0.99: 0.001}
+)
+
+// layouts holds the configured bucket layouts of the histograms
+// and the objectives of the summaries by the metric family name.
+type layouts struct {
+	buckets      map[string][]float64
+	objectives   map[string]map[float64]float64
+	nativeFactor float64
+
+	// histograms and summaries are the metric families
+	// that the layouts have been applied to
+	histograms map[string]bool
+	summaries  map[string]bool
+
+	// bounds are the classic bucket upper bounds of the histograms
+	bounds map[string][]float64
+}
+
+// newLayouts parses the bucket layouts and the summary objectives.
+//
+// Supported bucket layouts:
+//   - linear:<start>:<width>:<count>
+//   - exponential:<start>:<factor>:<count>
+//   - <bound>;<bound>;... - explicit upper bounds
+//
+// Objectives are specified as <quantile>:<error>;<quantile>:<error>;...
+func newLayouts(config Config) (*layouts, error) {
+	l := &layouts{
+		buckets:      make(map[string][]float64, len(config.Buckets)),
+		objectives:   make(map[string]map[float64]float64, len(config.Objectives)),
+		nativeFactor: config.NativeHistogramFactor,
+		histograms:   make(map[string]bool),
+		summaries:    make(map[string]bool),
+		bounds:       make(map[string][]float64),
+	}
+
+	if l.nativeFactor != 0 && l.nativeFactor <= 1 {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidNativeFactor, l.nativeFactor)
+	}
+
+	for family, spec := range config.Buckets {
+		buckets, err := parseBuckets(spec)
+		if err != nil {
+			return nil, fmt.Errorf("%w for %q: %s", ErrInvalidBuckets, family, err)
+		}
+		l.buckets[family] = buckets
+	}
+
+	for family, spec := range config.Objectives {
+		objectives, err := parseObjectives(spec)
+		if err != nil {
+			return nil, fmt.Errorf("%w for %q: %s", ErrInvalidObjectives, family, err)
+		}
+		l.objectives[family] = objectives
+	}
+
+	return l, nil
+}
+
+// histogram applies the configured layout to the histogram options.
+func (l *layouts) histogram(opts prometheus.HistogramOpts) prometheus.HistogramOpts {
+	l.histograms[opts.Name] = true
+
+	if buckets, ok := l.buckets[opts.Name]; ok {
+		opts.Buckets = buckets
+	}
+
+	// Native histograms are exposed in addition to the classic
+	// buckets, so the scrapers without native histograms support
+	// still get the classic histograms.
+	if l.nativeFactor > 0 {
+		opts.NativeHistogramBucketFactor = l.nativeFactor
+		opts.NativeHistogramMaxBucketNumber = nativeMaxBuckets
+		opts.NativeHistogramMinResetDuration = nativeMinResetDuration
+	}
+
+	// The histograms without the buckets get the default ones,
+	// unless they are native histograms only
+	l.bounds[opts.Name] = opts.Buckets
+	if len(opts.Buckets) == 0 && l.nativeFactor <= 1 {
+		l.bounds[opts.Name] = prometheus.DefBuckets
+	}
+
+	return opts
+}
+
+// summary applies the configured objectives to the summary options.
+func (l *layouts) summary(opts prometheus.SummaryOpts) prometheus.SummaryOpts {
+	l.summaries[opts.Name] = true
+
+	opts.Objectives = defaultObjectives
+	if objectives, ok := l.objectives[opts.Name]; ok {
+		opts.Objectives = objectives
+	}
+
+	return opts
+}
+
+// unused returns an error if a layout has been configured for a metric
+// family that doesn't exist or is of another type, which is most likely a typo.
+func (l *layouts) unused() error {
+	var unknown []string
+	for family := range l.buckets {
+		if !l.histograms[family] {
+			unknown = append(unknown, family)
+		}
+	}
+	for family := range l.objectives {
+		if !l.summaries[family] {
+			unknown = append(unknown, family)
+		}
+	}
+
+	if len(unknown) > 0 {
+		sort.Strings(unknown)
+		return fmt.Errorf("%w: %s", ErrUnknownMetricFamily, strings.Join(unknown, ", "))
+	}
+	return nil
+}
+
+// parseBuckets parses the histogram bucket layout.
+func parseBuckets(spec string) ([]float64, error) {
+	kind, args, found := strings.Cut(spec, ":")
+	if !found {
+		buckets, err := parseFloats(spec, ";")
+		if err != nil {
+			return nil, err
+		}
+		if !sort.Float64sAreSorted(buckets) {
+			return nil, errors.New("bounds should be in increasing order")
+		}
+		return buckets, nil
+	}
+
+	params, err := parseFloats(args, ":")
+	if err != nil {
+		return nil, err
+	}
+	if len(params) != 3 || params[2] < 1 || params[2] != float64(int(params[2])) {
+		return nil, fmt.Errorf("expected %s:<start>:<step>:<count>", kind)
+	}
+	start, step, count := params[0], params[1], int(params[2])
+
+	switch kind {
+	case "linear":
+		if step <= 0 {
+			return nil, errors.New("width should be positive")
+		}
+		return prometheus.LinearBuckets(start, step, count), nil
+
+	case "exponential":
+		if start <= 0 || step <= 1 {
+			return nil, errors.New("start should be positive and factor greater than 1")
+		}
+		return prometheus.ExponentialBuckets(start, step, count), nil
+
+	default:
+		return nil, fmt.Errorf("unknown layout %q", kind)
+	}
+}
+
+// parseObjectives parses the summary quantiles with their allowed errors.
+func parseObjectives(spec string) (map[float64]float64, error) {
+	objectives := make(map[float64]float64)
+	for _, pair := range strings.Split(spec, ";") {
+		values, err := parseFloats(pair, ":")
+		if err != nil {
+			return nil, err
+		}
+		if len(values) != 2 {
+			return nil, fmt.Errorf("expected <quantile>:<error>, got %q", pair)
+		}
+
+		quantile, allowed := values[0], values[1]
+		if quantile <= 0 || quantile >= 1 || allowed <= 0 || allowed >= 1 {
+			return nil, fmt.Errorf("quantile and error should be between 0 and 1, got %q", pair)
+		}
+		objectives[quantile] = allowed
+	}
+	return objectives, nil
+}
+
+// parseFloats parses the list of numbers separated with the 'sep'.
+func parseFloats(list string, sep string) ([]float64, error) {
+	var values []float64
+	for _, item := range strings.Split(list, sep) {
+		value, err := strconv.ParseFloat(strings.TrimSpace(item), 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid number %q", item)
+		}
+		values = append(values, value)
+	}
+	return values, nil
+}
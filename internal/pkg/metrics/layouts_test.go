@@ -0,0 +1,172 @@
+package metrics
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"git.lothric.net/examples/go/gogin/internal/pkg/logger"
+	"git.lothric.net/examples/go/gogin/internal/pkg/metrics/metricstest"
+)
+
+func TestLayouts(t *testing.T) {
+	for scenario, fn := range map[string]func(
+		t *testing.T,
+	){
+		"parses bucket layouts":             testParsesBucketLayouts,
+		"rejects invalid bucket layouts":    testRejectsInvalidBucketLayouts,
+		"parses summary objectives":         testParsesSummaryObjectives,
+		"rejects invalid objectives":        testRejectsInvalidObjectives,
+		"rejects unknown metric family":     testRejectsUnknownMetricFamily,
+		"rejects invalid native factor":     testRejectsInvalidNativeFactor,
+		"applies configured buckets":        testAppliesConfiguredBuckets,
+		"registers runtime and gc metrics":  testRegistersRuntimeMetrics,
+		"exposes native histograms enabled": testExposesNativeHistograms,
+	} {
+		t.Run(scenario, func(t *testing.T) {
+			fn(t)
+		})
+	}
+}
+
+func testParsesBucketLayouts(t *testing.T) {
+	for spec, expected := range map[string][]float64{
+		"linear:0.1:0.1:3":      {0.1, 0.2, 0.30000000000000004},
+		"exponential:0.01:10:3": {0.01, 0.1, 1},
+		"0.5;1;2.5":             {0.5, 1, 2.5},
+		"1":                     {1},
+	} {
+		buckets, err := parseBuckets(spec)
+		require.NoError(t, err, spec)
+		require.Equal(t, expected, buckets, spec)
+	}
+}
+
+func testRejectsInvalidBucketLayouts(t *testing.T) {
+	for _, spec := range []string{
+		"",
+		"2;1",
+		"1;x",
+		"linear:0.1:0.1",
+		"linear:0.1:0:3",
+		"linear:0.1:0.1:2.5",
+		"exponential:0:2:3",
+		"exponential:1:1:3",
+		"cubic:1:2:3",
+	} {
+		_, err := parseBuckets(spec)
+		require.Error(t, err, spec)
+	}
+}
+
+func testParsesSummaryObjectives(t *testing.T) {
+	objectives, err := parseObjectives("0.5:0.05; 0.99:0.001")
+	require.NoError(t, err)
+	require.Equal(t, map[float64]float64{0.5: 0.05, 0.99: 0.001}, objectives)
+}
+
+func testRejectsInvalidObjectives(t *testing.T) {
+	for _, spec := range []string{
+		"",
+		"0.5",
+		"0.5:0.05:1",
+		"1:0.01",
+		"0.5:0",
+	} {
+		_, err := parseObjectives(spec)
+		require.Error(t, err, spec)
+	}
+
+	_, err := NewRegistry(Config{Objectives: map[string]string{
+		"request_durations_seconds": "0.5",
+	}})
+	require.True(t, errors.Is(err, ErrInvalidObjectives))
+}
+
+func testRejectsUnknownMetricFamily(t *testing.T) {
+	_, err := NewRegistry(Config{
+		Buckets:    map[string]string{"request_duration_seconds": "1;2"},
+		Objectives: map[string]string{"gist_size_bytes": "0.5:0.05"},
+	})
+	require.True(t, errors.Is(err, ErrUnknownMetricFamily))
+	require.Contains(t, err.Error(), "gist_size_bytes, request_duration_seconds")
+}
+
+func testRejectsInvalidNativeFactor(t *testing.T) {
+	_, err := NewRegistry(Config{NativeHistogramFactor: 1})
+	require.True(t, errors.Is(err, ErrInvalidNativeFactor))
+}
+
+func testAppliesConfiguredBuckets(t *testing.T) {
+	registry, err := NewRegistry(Config{
+		Buckets: map[string]string{"gist_size_bytes": "100;1000"},
+	})
+	require.NoError(t, err)
+
+	log, _ := logger.NewNullLogger()
+	reporter, err := NewReporter(log, registry)
+	require.NoError(t, err)
+
+	reporter.GistCreated("go", 50)
+	reporter.GistCreated("go", 500)
+
+	metricstest.Compare(t, registry.Gatherer(), `
+# HELP gist_size_bytes Code size distribution of the created and updated gists.
+# TYPE gist_size_bytes histogram
+gist_size_bytes_bucket{le="100"} 1
+gist_size_bytes_bucket{le="1000"} 2
+gist_size_bytes_bucket{le="+Inf"} 2
+gist_size_bytes_sum 550
+gist_size_bytes_count 2
+`, "gist_size_bytes")
+
+	require.Equal(t, []float64{100, 1000}, registry.Buckets("gist_size_bytes"))
+	require.Len(t, registry.Buckets(FamilyRequestDurations), 20)
+	require.Empty(t, registry.Buckets("unknown"))
+}
+
+func testRegistersRuntimeMetrics(t *testing.T) {
+	registry, err := NewRegistry(Config{})
+	require.NoError(t, err)
+
+	families, err := registry.Gatherer().Gather()
+	require.NoError(t, err)
+
+	var names []string
+	for _, family := range families {
+		names = append(names, family.GetName())
+	}
+	all := strings.Join(names, " ")
+
+	require.Contains(t, all, "go_goroutines")
+	require.Contains(t, all, "go_gc_")
+	require.Contains(t, all, "go_build_info")
+}
+
+func testExposesNativeHistograms(t *testing.T) {
+	registry, err := NewRegistry(Config{NativeHistogramFactor: 1.1})
+	require.NoError(t, err)
+
+	log, _ := logger.NewNullLogger()
+	reporter, err := NewReporter(log, registry)
+	require.NoError(t, err)
+
+	reporter.GistCreated("go", 500)
+
+	families, err := registry.Gatherer().Gather()
+	require.NoError(t, err)
+
+	for _, family := range families {
+		if family.GetName() == "gist_size_bytes" {
+			histogram := family.GetMetric()[0].GetHistogram()
+			require.NotZero(t, histogram.GetSchema())
+			require.NotEmpty(t, histogram.GetPositiveSpan())
+			// Classic buckets are still exposed
+			require.NotEmpty(t, histogram.GetBucket())
+			return
+		}
+	}
+	require.Fail(t, "gist_size_bytes histogram is not found")
+}
@@ -2,46 +2,280 @@ package metrics
 
 import (
 	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
 )
 
-var (
+const (
+
+	// FamilyRequestDurations is the metric family of the API request durations,
+	// that is labeled by the LabelRoute, the method and the LabelStatus class.
+	FamilyRequestDurations = "request_durations_histogram_seconds"
+
+	// LabelRoute is the label of the API request route template.
+	LabelRoute = "route"
+
+	// LabelStatus is the label of the API response status class, for example "5xx".
+	LabelStatus = "status"
+)
+
+// Registry holds all the application metrics and
+// the prometheus registry they are registered in.
+//
+// Each Registry is independent from the others, so several
+// instances could coexist in the same process, for example in tests.
+type Registry struct {
+	registry *prometheus.Registry
+
+	// bounds are the classic bucket upper bounds of the histograms by the metric family name.
+	bounds map[string][]float64
 
 	// requestsTotal is a total number of processed API requests.
-	requestsTotal = prometheus.NewCounterVec(
-		prometheus.CounterOpts{
-			Name: "requests_total",
-			Help: "Total number of processed API requests.",
-		},
-		[]string{"operation"},
-	)
+	requestsTotal *prometheus.CounterVec
 
 	// requestsFailures is a total number of API request errors.
-	requestsFailures = prometheus.NewCounterVec(
-		prometheus.CounterOpts{
-			Name: "requests_errors_total",
-			Help: "Total number of API request errors.",
-		},
-		[]string{"operation", "failure"},
-	)
+	requestsFailures *prometheus.CounterVec
+
+	// requestsInFlight is a number of API requests that are being processed.
+	requestsInFlight *prometheus.GaugeVec
 
 	// requestDuration is API request processing duration distributions.
-	requestDuration = prometheus.NewSummaryVec(
-		prometheus.SummaryOpts{
-			Name:       "request_durations_seconds",
-			Help:       "API request processing duration distributions.",
-			Objectives: map[float64]float64{},
-		},
-		[]string{"operation"},
-	)
+	requestDuration *prometheus.SummaryVec
 
 	// requestDurationsHistogram is API request processing duration distributions.
-	requestDurationsHistogram = prometheus.NewHistogramVec(
-		prometheus.HistogramOpts{
-			Name: "request_durations_histogram_seconds",
-			Help: "API request processing duration distributions.",
-			// Start at 10 milliseconds, add 20 buckets, 10 milliseconds each
-			Buckets: prometheus.LinearBuckets(0.01, 0.01, 20),
-		},
-		[]string{"operation"},
-	)
-)
+	requestDurationsHistogram *prometheus.HistogramVec
+
+	// requestSize is API request body size distributions.
+	requestSize *prometheus.HistogramVec
+
+	// responseSize is API response body size distributions.
+	responseSize *prometheus.HistogramVec
+
+	// secretsDetected is a total number of secrets detected in the gists.
+	secretsDetected *prometheus.CounterVec
+
+	// gistsSwept is a total number of expired gists deleted by the sweeper.
+	gistsSwept prometheus.Counter
+
+	// gistsPurged is a total number of trashed gists permanently deleted.
+	gistsPurged prometheus.Counter
+
+	// gistsCreated is a total number of created gists by language.
+	gistsCreated *prometheus.CounterVec
+
+	// gistsUpdated is a total number of updated gists by language.
+	gistsUpdated *prometheus.CounterVec
+
+	// gistsDeleted is a total number of gists moved to the trash by language.
+	gistsDeleted *prometheus.CounterVec
+
+	// gistSize is the distribution of the created and updated gists code size.
+	gistSize prometheus.Histogram
+
+	// gistsSearchDuration is the distribution of the gists search duration.
+	gistsSearchDuration prometheus.Histogram
+
+	// storageErrors is a total number of failed storage operations.
+	storageErrors *prometheus.CounterVec
+}
+
+// NewRegistry creates a new registry with all the application metrics registered,
+// including the Go runtime, the garbage collector and the process metrics.
+//
+// The histogram buckets and the summary objectives are configured
+// per metric family, the defaults are used for the rest of them.
+func NewRegistry(config Config) (*Registry, error) {
+	l, err := newLayouts(config)
+	if err != nil {
+		return nil, err
+	}
+
+	r := &Registry{
+		registry: prometheus.NewRegistry(),
+		bounds:   l.bounds,
+
+		requestsTotal: prometheus.NewCounterVec(
+			prometheus.CounterOpts{
+				Name: "requests_total",
+				Help: "Total number of processed API requests.",
+			},
+			[]string{"route", "method", "status"},
+		),
+
+		requestsFailures: prometheus.NewCounterVec(
+			prometheus.CounterOpts{
+				Name: "requests_errors_total",
+				Help: "Total number of API request errors.",
+			},
+			[]string{"route", "method", "status", "failure"},
+		),
+
+		requestsInFlight: prometheus.NewGaugeVec(
+			prometheus.GaugeOpts{
+				Name: "requests_in_flight",
+				Help: "Number of API requests that are being processed.",
+			},
+			[]string{"route", "method"},
+		),
+
+		requestDuration: prometheus.NewSummaryVec(
+			l.summary(prometheus.SummaryOpts{
+				Name: "request_durations_seconds",
+				Help: "API request processing duration distributions.",
+			}),
+			[]string{"route", "method", "status"},
+		),
+
+		requestDurationsHistogram: prometheus.NewHistogramVec(
+			l.histogram(prometheus.HistogramOpts{
+				Name: FamilyRequestDurations,
+				Help: "API request processing duration distributions.",
+				// Start at 10 milliseconds, add 20 buckets, 10 milliseconds each
+				Buckets: prometheus.LinearBuckets(0.01, 0.01, 20),
+			}),
+			[]string{"route", "method", "status"},
+		),
+
+		requestSize: prometheus.NewHistogramVec(
+			l.histogram(prometheus.HistogramOpts{
+				Name: "request_size_bytes",
+				Help: "API request body size distributions.",
+				// Start at 64 bytes, add 8 buckets, 4 times larger each, up to 1 MiB
+				Buckets: prometheus.ExponentialBuckets(64, 4, 8),
+			}),
+			[]string{"route", "method"},
+		),
+
+		responseSize: prometheus.NewHistogramVec(
+			l.histogram(prometheus.HistogramOpts{
+				Name: "response_size_bytes",
+				Help: "API response body size distributions.",
+				// Start at 64 bytes, add 8 buckets, 4 times larger each, up to 1 MiB
+				Buckets: prometheus.ExponentialBuckets(64, 4, 8),
+			}),
+			[]string{"route", "method", "status"},
+		),
+
+		secretsDetected: prometheus.NewCounterVec(
+			prometheus.CounterOpts{
+				Name: "secrets_detected_total",
+				Help: "Total number of secrets detected in the gists.",
+			},
+			[]string{"rule"},
+		),
+
+		gistsSwept: prometheus.NewCounter(
+			prometheus.CounterOpts{
+				Name: "gists_swept_total",
+				Help: "Total number of expired gists deleted by the sweeper.",
+			},
+		),
+
+		gistsPurged: prometheus.NewCounter(
+			prometheus.CounterOpts{
+				Name: "gists_purged_total",
+				Help: "Total number of trashed gists permanently deleted.",
+			},
+		),
+
+		gistsCreated: prometheus.NewCounterVec(
+			prometheus.CounterOpts{
+				Name: "gists_created_total",
+				Help: "Total number of created gists.",
+			},
+			[]string{"language"},
+		),
+
+		gistsUpdated: prometheus.NewCounterVec(
+			prometheus.CounterOpts{
+				Name: "gists_updated_total",
+				Help: "Total number of updated gists.",
+			},
+			[]string{"language"},
+		),
+
+		gistsDeleted: prometheus.NewCounterVec(
+			prometheus.CounterOpts{
+				Name: "gists_deleted_total",
+				Help: "Total number of gists moved to the trash.",
+			},
+			[]string{"language"},
+		),
+
+		gistSize: prometheus.NewHistogram(
+			l.histogram(prometheus.HistogramOpts{
+				Name: "gist_size_bytes",
+				Help: "Code size distribution of the created and updated gists.",
+				// Start at 64 bytes, add 8 buckets, 4 times larger each, up to 1 MiB
+				Buckets: prometheus.ExponentialBuckets(64, 4, 8),
+			}),
+		),
+
+		gistsSearchDuration: prometheus.NewHistogram(
+			l.histogram(prometheus.HistogramOpts{
+				Name:    "gists_search_duration_seconds",
+				Help:    "Gists search duration distribution.",
+				Buckets: prometheus.DefBuckets,
+			}),
+		),
+
+		storageErrors: prometheus.NewCounterVec(
+			prometheus.CounterOpts{
+				Name: "storage_errors_total",
+				Help: "Total number of failed storage operations.",
+			},
+			[]string{"operation"},
+		),
+	}
+
+	if err := l.unused(); err != nil {
+		return nil, err
+	}
+
+	// Register all defined metrics
+	for _, c := range []prometheus.Collector{
+		r.requestsTotal,
+		r.requestsFailures,
+		r.requestsInFlight,
+		r.requestDuration,
+		r.requestDurationsHistogram,
+		r.requestSize,
+		r.responseSize,
+		r.secretsDetected,
+		r.gistsSwept,
+		r.gistsPurged,
+		r.gistsCreated,
+		r.gistsUpdated,
+		r.gistsDeleted,
+		r.gistSize,
+		r.gistsSearchDuration,
+		r.storageErrors,
+		collectors.NewBuildInfoCollector(),
+		collectors.NewGoCollector(
+			collectors.WithGoCollectorRuntimeMetrics(collectors.MetricsGC),
+		),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+	} {
+		if err := r.registry.Register(c); err != nil {
+			return nil, err
+		}
+	}
+
+	return r, nil
+}
+
+// Gatherer returns the gatherer of all registered metrics.
+func (r *Registry) Gatherer() prometheus.Gatherer {
+	return r.registry
+}
+
+// Buckets returns the classic bucket upper bounds of the histogram 'family',
+// that are empty if the histogram has only the native buckets or doesn't exist.
+func (r *Registry) Buckets(family string) []float64 {
+	return r.bounds[family]
+}
+
+// Register registers the metrics of the 'collector',
+// that are reported by the other packages.
+func (r *Registry) Register(collector prometheus.Collector) error {
+	return r.registry.Register(collector)
+}
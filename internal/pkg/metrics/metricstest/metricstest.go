@@ -0,0 +1,86 @@
+// Package metricstest provides helpers to assert
+// on the collected metrics values in tests.
+package metricstest
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	dto "github.com/prometheus/client_model/go"
+	"github.com/stretchr/testify/require"
+)
+
+// Value collects the metric with the 'name' and exactly the 'labels'
+// from the 'gatherer' and returns its value. For histograms and summaries
+// the number of observations is returned.
+//
+// The metric that has never been reported has no series,
+// so zero is returned for it.
+func Value(
+	t testing.TB,
+	gatherer prometheus.Gatherer,
+	name string,
+	labels map[string]string,
+) float64 {
+	t.Helper()
+
+	families, err := gatherer.Gather()
+	require.NoError(t, err)
+
+	for _, family := range families {
+		if family.GetName() != name {
+			continue
+		}
+
+		for _, metric := range family.GetMetric() {
+			if !hasLabels(metric, labels) {
+				continue
+			}
+
+			switch {
+			case metric.Counter != nil:
+				return metric.GetCounter().GetValue()
+			case metric.Gauge != nil:
+				return metric.GetGauge().GetValue()
+			case metric.Histogram != nil:
+				return float64(metric.GetHistogram().GetSampleCount())
+			case metric.Summary != nil:
+				return float64(metric.GetSummary().GetSampleCount())
+			}
+		}
+	}
+
+	return 0
+}
+
+// Compare collects the metrics with the 'names' from the 'gatherer' and
+// compares them with the 'expected' metrics in the text exposition format.
+// The test fails with the difference, if the metrics don't match.
+func Compare(
+	t testing.TB,
+	gatherer prometheus.Gatherer,
+	expected string,
+	names ...string,
+) {
+	t.Helper()
+
+	err := testutil.GatherAndCompare(gatherer, strings.NewReader(expected), names...)
+	require.NoError(t, err)
+}
+
+// hasLabels reports whether the 'metric' has exactly the 'labels'.
+func hasLabels(metric *dto.Metric, labels map[string]string) bool {
+	if len(metric.GetLabel()) != len(labels) {
+		return false
+	}
+
+	for _, pair := range metric.GetLabel() {
+		if value, ok := labels[pair.GetName()]; !ok || value != pair.GetValue() {
+			return false
+		}
+	}
+
+	return true
+}
@@ -0,0 +1,101 @@
+package metrics
+
+import (
+	"context"
+	"errors"
+
+	"github.com/prometheus/client_golang/prometheus/push"
+
+	"git.lothric.net/examples/go/gogin/internal/pkg/lifecycle"
+	"git.lothric.net/examples/go/gogin/internal/pkg/logger"
+)
+
+const (
+
+	// pushGroupingInstance is the grouping label of the pushed metrics,
+	// so the metrics of the different nodes don't replace each other.
+	pushGroupingInstance = "instance"
+)
+
+var (
+	// ErrNoPushUrlProvided happens when the push exporter is created without the Pushgateway url.
+	ErrNoPushUrlProvided = errors.New("no metrics push url provided")
+
+	// ErrInvalidPushInterval happens when the push interval is not positive.
+	ErrInvalidPushInterval = errors.New("invalid metrics push interval")
+)
+
+// pushExporter pushes the metrics of the registry to the Pushgateway
+// on every interval and once more when it is stopped.
+//
+// The short-lived processes exit before Prometheus scrapes them,
+// so their metrics are pushed instead of being exposed.
+type pushExporter struct {
+	log      logger.Log
+	pusher   *push.Pusher
+	periodic *lifecycle.Periodic
+}
+
+// NewPushExporter creates a new exporter that pushes the metrics
+// of the 'registry' as the 'job' grouped by the 'node' instance.
+func NewPushExporter(
+	log logger.Log,
+	conf Config,
+	registry *Registry,
+	job string,
+	node string,
+) (*pushExporter, error) {
+
+	if log == nil {
+		return nil, ErrNoLoggerProvided
+	}
+
+	if registry == nil {
+		return nil, ErrNoRegistryProvided
+	}
+
+	if conf.PushUrl == "" {
+		return nil, ErrNoPushUrlProvided
+	}
+
+	if conf.PushInterval <= 0 {
+		return nil, ErrInvalidPushInterval
+	}
+
+	p := &pushExporter{
+		log: log.WithFields(logger.Fields{
+			logger.FieldPackage: "metrics",
+		}),
+		pusher: push.New(conf.PushUrl, job).
+			Gatherer(registry.Gatherer()).
+			Grouping(pushGroupingInstance, node),
+	}
+	p.periodic = lifecycle.NewPeriodic(conf.PushInterval, p.push)
+
+	return p, nil
+}
+
+// Run pushes the metrics on every interval, until the exporter is stopped.
+// Stopping the exporter cancels the push in progress.
+func (p *pushExporter) Run() error {
+	return p.periodic.Run()
+}
+
+// Stop stops the periodic pushes and pushes the final metrics,
+// so the metrics recorded since the last push are not lost.
+func (p *pushExporter) Stop(ctx context.Context) error {
+	if err := p.periodic.Stop(ctx); err != nil {
+		return err
+	}
+
+	return p.pusher.PushContext(ctx)
+}
+
+// push pushes the metrics, the failed push is retried on the next interval.
+func (p *pushExporter) push(ctx context.Context) {
+	log := p.log.WithField(logger.FieldFunction, "push")
+
+	if err := p.pusher.PushContext(ctx); err != nil && ctx.Err() == nil {
+		log.Error(err, "Failed to push metrics")
+	}
+}
@@ -0,0 +1,204 @@
+package metrics
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/prometheus/common/expfmt"
+	"github.com/stretchr/testify/require"
+
+	dto "github.com/prometheus/client_model/go"
+
+	"git.lothric.net/examples/go/gogin/internal/pkg/logger"
+)
+
+func TestPushExporter(t *testing.T) {
+	for scenario, fn := range map[string]func(
+		t *testing.T,
+		r *reporter,
+		m *Registry,
+		g *pushgatewayMock,
+	){
+		"pushes metrics on interval":       testPushesMetricsOnInterval,
+		"pushes metrics on stop":           testPushesMetricsOnStop,
+		"keeps pushing after failure":      testKeepsPushingAfterFailure,
+		"fails to create without url":      testFailsToCreateWithoutUrl,
+		"fails to create without interval": testFailsToCreateWithoutInterval,
+	} {
+		t.Run(scenario, func(t *testing.T) {
+			log, _ := logger.NewNullLogger()
+
+			registry, err := NewRegistry(Config{})
+			require.NoError(t, err)
+
+			reporter, err := NewReporter(log, registry)
+			require.NoError(t, err)
+
+			gateway := &pushgatewayMock{}
+			server := httptest.NewServer(gateway)
+			defer server.Close()
+			gateway.url = server.URL
+
+			fn(t, reporter, registry, gateway)
+		})
+	}
+}
+
+// pushRequest is a push request received by the Pushgateway.
+type pushRequest struct {
+	method   string
+	path     string
+	families map[string]bool
+}
+
+// pushgatewayMock is a local stand-in of the Pushgateway.
+type pushgatewayMock struct {
+	url string
+
+	mu     sync.Mutex
+	pushes []pushRequest
+	fail   bool
+}
+
+func (g *pushgatewayMock) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	families := map[string]bool{}
+	decoder := expfmt.NewDecoder(r.Body, expfmt.ResponseFormat(r.Header))
+	for {
+		family := &dto.MetricFamily{}
+		if err := decoder.Decode(family); err != nil {
+			if !errors.Is(err, io.EOF) {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+			break
+		}
+		families[family.GetName()] = true
+	}
+
+	g.pushes = append(g.pushes, pushRequest{
+		method:   r.Method,
+		path:     r.URL.Path,
+		families: families,
+	})
+
+	if g.fail {
+		http.Error(w, "unavailable", http.StatusServiceUnavailable)
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+}
+
+func (g *pushgatewayMock) received() []pushRequest {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	return append([]pushRequest{}, g.pushes...)
+}
+
+func (g *pushgatewayMock) exporter(t *testing.T, m *Registry, interval time.Duration) *pushExporter {
+	log, _ := logger.NewNullLogger()
+	exporter, err := NewPushExporter(log, Config{
+		PushUrl:      g.url,
+		PushInterval: interval,
+	}, m, "gogin", "node-1")
+	require.NoError(t, err)
+	return exporter
+}
+
+func testPushesMetricsOnInterval(
+	t *testing.T,
+	r *reporter,
+	m *Registry,
+	g *pushgatewayMock,
+) {
+
+	r.GistsSwept(3)
+
+	exporter := g.exporter(t, m, 10*time.Millisecond)
+	go exporter.Run()
+	defer exporter.Stop(context.Background())
+
+	require.Eventually(t, func() bool {
+		return len(g.received()) >= 2
+	}, time.Second, 5*time.Millisecond)
+
+	pushed := g.received()[0]
+	require.Equal(t, http.MethodPut, pushed.method)
+	require.Equal(t, "/metrics/job/gogin/instance/node-1", pushed.path)
+	require.True(t, pushed.families["gists_swept_total"])
+	require.True(t, pushed.families["go_goroutines"])
+}
+
+func testPushesMetricsOnStop(
+	t *testing.T,
+	r *reporter,
+	m *Registry,
+	g *pushgatewayMock,
+) {
+
+	exporter := g.exporter(t, m, time.Hour)
+	go exporter.Run()
+
+	r.GistsPurged(2)
+	require.NoError(t, exporter.Stop(context.Background()))
+
+	pushes := g.received()
+	require.Len(t, pushes, 1)
+	require.True(t, pushes[0].families["gists_purged_total"])
+}
+
+func testKeepsPushingAfterFailure(
+	t *testing.T,
+	r *reporter,
+	m *Registry,
+	g *pushgatewayMock,
+) {
+
+	g.fail = true
+
+	exporter := g.exporter(t, m, 10*time.Millisecond)
+	go exporter.Run()
+
+	require.Eventually(t, func() bool {
+		return len(g.received()) >= 2
+	}, time.Second, 5*time.Millisecond)
+
+	// The final push reports the failure to the caller
+	require.Error(t, exporter.Stop(context.Background()))
+}
+
+func testFailsToCreateWithoutUrl(
+	t *testing.T,
+	r *reporter,
+	m *Registry,
+	g *pushgatewayMock,
+) {
+
+	log, _ := logger.NewNullLogger()
+	exporter, err := NewPushExporter(log, Config{PushInterval: time.Second}, m, "gogin", "node-1")
+
+	require.Nil(t, exporter)
+	require.Equal(t, ErrNoPushUrlProvided, err)
+}
+
+func testFailsToCreateWithoutInterval(
+	t *testing.T,
+	r *reporter,
+	m *Registry,
+	g *pushgatewayMock,
+) {
+
+	log, _ := logger.NewNullLogger()
+	exporter, err := NewPushExporter(log, Config{PushUrl: g.url}, m, "gogin", "node-1")
+
+	require.Nil(t, exporter)
+	require.Equal(t, ErrInvalidPushInterval, err)
+}
@@ -1,48 +1,203 @@
 package metrics
 
 import (
+	"context"
 	"errors"
+	"strconv"
+	"strings"
+	"time"
 
 	"git.lothric.net/examples/go/gogin/internal/pkg/logger"
 )
 
+const (
+
+	// unknownLanguage is reported for the gists without a language.
+	unknownLanguage = "unknown"
+
+	// otherLanguage is reported for the gists with a language that is not known.
+	otherLanguage = "other"
+)
+
+// knownLanguages are the labels of the known languages by their lower-cased
+// names and aliases. The language is provided by the clients, so only the
+// known languages are reported as is, to keep the number of series bounded.
+var knownLanguages = map[string]string{
+	"bash":        "shell",
+	"c":           "c",
+	"c#":          "csharp",
+	"c++":         "cpp",
+	"clojure":     "clojure",
+	"cpp":         "cpp",
+	"cs":          "csharp",
+	"csharp":      "csharp",
+	"css":         "css",
+	"dart":        "dart",
+	"dockerfile":  "dockerfile",
+	"elixir":      "elixir",
+	"erlang":      "erlang",
+	"go":          "go",
+	"golang":      "go",
+	"haskell":     "haskell",
+	"html":        "html",
+	"java":        "java",
+	"javascript":  "javascript",
+	"js":          "javascript",
+	"json":        "json",
+	"kotlin":      "kotlin",
+	"lua":         "lua",
+	"markdown":    "markdown",
+	"md":          "markdown",
+	"objective-c": "objective-c",
+	"perl":        "perl",
+	"php":         "php",
+	"powershell":  "powershell",
+	"py":          "python",
+	"python":      "python",
+	"r":           "r",
+	"rb":          "ruby",
+	"ruby":        "ruby",
+	"rust":        "rust",
+	"scala":       "scala",
+	"sh":          "shell",
+	"shell":       "shell",
+	"sql":         "sql",
+	"swift":       "swift",
+	"text":        "text",
+	"toml":        "toml",
+	"ts":          "typescript",
+	"typescript":  "typescript",
+	"xml":         "xml",
+	"yaml":        "yaml",
+	"yml":         "yaml",
+}
+
 var (
 
 	// ErrNoLoggerProvided happens when logger is not provided.
 	ErrNoLoggerProvided = errors.New("no logger provided")
+
+	// ErrNoRegistryProvided happens when metrics registry is not provided.
+	ErrNoRegistryProvided = errors.New("no metrics registry provided")
 )
 
 // reporter collects and reports application metrics and usage statistics.
 type reporter struct {
-	log logger.Log
+	log     logger.Log
+	metrics *Registry
 }
 
-// NewReporter creates a new instance of metrics reporter.
-func NewReporter(log logger.Log) (*reporter, error) {
+// NewReporter creates a new instance of metrics reporter,
+// that reports the metrics to the provided 'registry'.
+func NewReporter(log logger.Log, registry *Registry) (*reporter, error) {
 	if log == nil {
 		return nil, ErrNoLoggerProvided
 	}
 
+	if registry == nil {
+		return nil, ErrNoRegistryProvided
+	}
+
 	return &reporter{
-		log: log,
+		log:     log,
+		metrics: registry,
 	}, nil
 }
 
-// ApiRequestProcessed tracks a successfully processed API request.
-func (r *reporter) ApiRequestProcessed(operation string, milliseconds float64) {
-	log := r.log.WithField(logger.FieldFunction, "ApiRequestProcessed")
-	log.Info("Api request has been processed")
+// ApiRequestStarted tracks an API request that is being processed.
+func (r *reporter) ApiRequestStarted(method string, route string) {
+	r.metrics.requestsInFlight.WithLabelValues(route, method).Inc()
+}
+
+// ApiRequestProcessed tracks a processed API request. The duration
+// is recorded with the 'exemplar' labels, like the correlation id.
+func (r *reporter) ApiRequestProcessed(
+	method string,
+	route string,
+	status int,
+	duration time.Duration,
+	requestBytes int64,
+	responseBytes int64,
+	exemplarLabels map[string]string,
+) {
+	class := statusClass(status)
+	seconds := duration.Seconds()
 
-	seconds := milliseconds / 1000
-	requestDuration.WithLabelValues(operation).Observe(seconds)
-	requestDurationsHistogram.WithLabelValues(operation).Observe(seconds)
-	requestsTotal.WithLabelValues(operation).Inc()
+	r.metrics.requestsInFlight.WithLabelValues(route, method).Dec()
+	r.metrics.requestsTotal.WithLabelValues(route, method, class).Inc()
+	r.metrics.requestDuration.WithLabelValues(route, method, class).Observe(seconds)
+	observe(r.metrics.requestDurationsHistogram.WithLabelValues(route, method, class), seconds, exemplar(exemplarLabels))
+	r.metrics.requestSize.WithLabelValues(route, method).Observe(float64(requestBytes))
+	r.metrics.responseSize.WithLabelValues(route, method, class).Observe(float64(responseBytes))
 }
 
 // ApiRequestFailed tracks an API request that has failed to be processed.
-func (r *reporter) ApiRequestFailed(operation string, failure string) {
-	log := r.log.WithField(logger.FieldFunction, "ApiRequestFailed")
-	log.Info("Api request processing has failed")
+func (r *reporter) ApiRequestFailed(method string, route string, status int, failure string) {
+	r.metrics.requestsFailures.WithLabelValues(route, method, statusClass(status), failure).Inc()
+}
+
+// SecretDetected tracks a secret detected in a gist by the 'rule'.
+func (r *reporter) SecretDetected(rule string) {
+	r.metrics.secretsDetected.WithLabelValues(rule).Inc()
+}
+
+// GistsSwept tracks the expired gists deleted by the sweeper.
+func (r *reporter) GistsSwept(count int) {
+	r.metrics.gistsSwept.Add(float64(count))
+}
+
+// GistsPurged tracks the trashed gists permanently deleted.
+func (r *reporter) GistsPurged(count int) {
+	r.metrics.gistsPurged.Add(float64(count))
+}
+
+// GistCreated tracks a new gist written in the 'language' with the code of 'size' bytes.
+func (r *reporter) GistCreated(language string, size int) {
+	r.metrics.gistsCreated.WithLabelValues(languageLabel(language)).Inc()
+	r.metrics.gistSize.Observe(float64(size))
+}
+
+// GistUpdated tracks an updated gist written in the 'language' with the code of 'size' bytes.
+func (r *reporter) GistUpdated(language string, size int) {
+	r.metrics.gistsUpdated.WithLabelValues(languageLabel(language)).Inc()
+	r.metrics.gistSize.Observe(float64(size))
+}
+
+// GistDeleted tracks a gist written in the 'language' moved to the trash.
+func (r *reporter) GistDeleted(language string) {
+	r.metrics.gistsDeleted.WithLabelValues(languageLabel(language)).Inc()
+}
+
+// GistsSearched tracks how long it took to search the gists
+// with the correlation id of the request as the exemplar.
+func (r *reporter) GistsSearched(ctx context.Context, duration time.Duration) {
+	observe(r.metrics.gistsSearchDuration, duration.Seconds(), contextExemplar(ctx))
+}
 
-	requestsFailures.WithLabelValues(operation, failure).Inc()
+// StorageFailed tracks a failed storage 'operation'.
+func (r *reporter) StorageFailed(operation string) {
+	r.metrics.storageErrors.WithLabelValues(operation).Inc()
+}
+
+// languageLabel normalizes the user provided gist language, so the same
+// language is always reported with the same label, and the languages
+// that are not known are reported as 'other'.
+func languageLabel(language string) string {
+	language = strings.ToLower(strings.TrimSpace(language))
+	if language == "" {
+		return unknownLanguage
+	}
+
+	if label, ok := knownLanguages[language]; ok {
+		return label
+	}
+	return otherLanguage
+}
+
+// statusClass returns the class of the HTTP 'status', for example '2xx'.
+func statusClass(status int) string {
+	if status < 100 || status > 599 {
+		return "unknown"
+	}
+	return strconv.Itoa(status/100) + "xx"
 }
@@ -0,0 +1,159 @@
+package metrics
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"git.lothric.net/examples/go/gogin/internal/pkg/logger"
+	"git.lothric.net/examples/go/gogin/internal/pkg/metrics/metricstest"
+)
+
+func TestReporter(t *testing.T) {
+	for scenario, fn := range map[string]func(
+		t *testing.T,
+		r *reporter,
+		m *Registry,
+	){
+		"reports api requests":                      testReportsApiRequests,
+		"reports gists by language":                 testReportsGistsByLanguage,
+		"reports storage failures":                  testReportsStorageFailures,
+		"registries are independent":                testRegistriesAreIndependent,
+		"fails to create reporter without registry": testFailsWithoutRegistry,
+		"fails to create server without registry":   testFailsServerWithoutRegistry,
+	} {
+		t.Run(scenario, func(t *testing.T) {
+			log, _ := logger.NewNullLogger()
+
+			registry, err := NewRegistry(Config{})
+			require.NoError(t, err)
+
+			reporter, err := NewReporter(log, registry)
+			require.NoError(t, err)
+
+			fn(t, reporter, registry)
+		})
+	}
+}
+
+func testReportsApiRequests(
+	t *testing.T,
+	r *reporter,
+	m *Registry,
+) {
+
+	r.ApiRequestStarted(http.MethodGet, "/api/gists/:id")
+	r.ApiRequestStarted(http.MethodGet, "/api/gists/:id")
+	r.ApiRequestProcessed(http.MethodGet, "/api/gists/:id", http.StatusOK, 10*time.Millisecond, 0, 128, nil)
+	r.ApiRequestProcessed(http.MethodGet, "/api/gists/:id", http.StatusNotFound, time.Millisecond, 0, 64, nil)
+	r.ApiRequestFailed(http.MethodGet, "/api/gists/:id", http.StatusNotFound, "gist-not-found")
+
+	metricstest.Compare(t, m.Gatherer(), `
+# HELP requests_total Total number of processed API requests.
+# TYPE requests_total counter
+requests_total{method="GET",route="/api/gists/:id",status="2xx"} 1
+requests_total{method="GET",route="/api/gists/:id",status="4xx"} 1
+# HELP requests_errors_total Total number of API request errors.
+# TYPE requests_errors_total counter
+requests_errors_total{failure="gist-not-found",method="GET",route="/api/gists/:id",status="4xx"} 1
+# HELP requests_in_flight Number of API requests that are being processed.
+# TYPE requests_in_flight gauge
+requests_in_flight{method="GET",route="/api/gists/:id"} 0
+`, "requests_total", "requests_errors_total", "requests_in_flight")
+
+	require.Equal(t, 1.0, metricstest.Value(t, m.Gatherer(), "response_size_bytes", map[string]string{
+		"method": "GET",
+		"route":  "/api/gists/:id",
+		"status": "2xx",
+	}))
+}
+
+func testReportsGistsByLanguage(
+	t *testing.T,
+	r *reporter,
+	m *Registry,
+) {
+
+	r.GistCreated("Go", 100)
+	r.GistCreated(" go ", 200)
+	r.GistCreated("golang", 50)
+	r.GistCreated("", 10)
+	r.GistCreated("brainfuck", 10)
+	r.GistCreated(strings.Repeat("x", 1000), 10)
+	r.GistUpdated("go", 300)
+	r.GistDeleted("go")
+
+	metricstest.Compare(t, m.Gatherer(), `
+# HELP gists_created_total Total number of created gists.
+# TYPE gists_created_total counter
+gists_created_total{language="go"} 3
+gists_created_total{language="other"} 2
+gists_created_total{language="unknown"} 1
+# HELP gists_updated_total Total number of updated gists.
+# TYPE gists_updated_total counter
+gists_updated_total{language="go"} 1
+# HELP gists_deleted_total Total number of gists moved to the trash.
+# TYPE gists_deleted_total counter
+gists_deleted_total{language="go"} 1
+`, "gists_created_total", "gists_updated_total", "gists_deleted_total")
+
+	require.Equal(t, 7.0, metricstest.Value(t, m.Gatherer(), "gist_size_bytes", nil))
+}
+
+func testReportsStorageFailures(
+	t *testing.T,
+	r *reporter,
+	m *Registry,
+) {
+
+	require.Zero(t, metricstest.Value(t, m.Gatherer(), "storage_errors_total", map[string]string{
+		"operation": "save",
+	}))
+
+	r.StorageFailed("save")
+	r.StorageFailed("save")
+
+	require.Equal(t, 2.0, metricstest.Value(t, m.Gatherer(), "storage_errors_total", map[string]string{
+		"operation": "save",
+	}))
+}
+
+func testRegistriesAreIndependent(
+	t *testing.T,
+	r *reporter,
+	m *Registry,
+) {
+
+	other, err := NewRegistry(Config{})
+	require.NoError(t, err)
+
+	r.GistsSwept(3)
+
+	require.Equal(t, 3.0, metricstest.Value(t, m.Gatherer(), "gists_swept_total", nil))
+	require.Zero(t, metricstest.Value(t, other.Gatherer(), "gists_swept_total", nil))
+}
+
+func testFailsWithoutRegistry(
+	t *testing.T,
+	r *reporter,
+	m *Registry,
+) {
+
+	log, _ := logger.NewNullLogger()
+
+	_, err := NewReporter(log, nil)
+	require.Equal(t, ErrNoRegistryProvided, err)
+}
+
+func testFailsServerWithoutRegistry(
+	t *testing.T,
+	r *reporter,
+	m *Registry,
+) {
+
+	_, err := NewPrometheusServer(Config{Addr: ":0", Path: "/metrics"}, nil, nil)
+	require.Equal(t, ErrNoRegistryProvided, err)
+}
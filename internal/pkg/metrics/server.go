@@ -2,11 +2,14 @@ package metrics
 
 import (
 	"context"
+	"crypto/tls"
+	"net"
 	"net/http"
+	"time"
 
-	"github.com/prometheus/client_golang/prometheus"
-	"github.com/prometheus/client_golang/prometheus/collectors"
 	"github.com/prometheus/client_golang/prometheus/promhttp"
+
+	"git.lothric.net/examples/go/gogin/internal/pkg/tlsconfig"
 )
 
 // Config is a prometheus server configuration.
@@ -19,46 +22,69 @@ type Config struct {
 	// Path is route that the metrics should be exposed on.
 	// For example: "/metrics"
 	Path string
+
+	// Buckets are the bucket layouts of the histograms by the metric family name.
+	// For example: "request_durations_histogram_seconds" -> "exponential:0.005:2:12"
+	Buckets map[string]string
+
+	// Objectives are the quantiles of the summaries with their allowed errors
+	// by the metric family name.
+	// For example: "request_durations_seconds" -> "0.5:0.05;0.99:0.001"
+	Objectives map[string]string
+
+	// NativeHistogramFactor enables the native histograms with the specified
+	// growth factor of the buckets, if it is greater than 1.
+	// For example: 1.1
+	NativeHistogramFactor float64
+
+	// PushUrl is the url of the Pushgateway the metrics are pushed to,
+	// the metrics are not pushed if it is empty.
+	// For example: "http://pushgateway:9091"
+	PushUrl string
+
+	// PushInterval is the interval between the metrics pushes.
+	// For example: 15s
+	PushInterval time.Duration
+
+	// TLS is the TLS configuration of the metrics server.
+	TLS tlsconfig.Config
 }
 
 // prometheusServer is Prometheus HTTP server for metrics collection.
 type prometheusServer struct {
 	server   *http.Server
-	registry *prometheus.Registry
+	mux      *http.ServeMux
+	listener net.Listener
+	tls      *tls.Config
+	registry *Registry
 	conf     Config
 }
 
-// NewPrometheusServer creates a new instance of Prometheus HTTP server.
-func NewPrometheusServer(conf Config) (*prometheusServer, error) {
+// NewPrometheusServer creates a new instance of Prometheus HTTP server,
+// that exposes the metrics of the provided 'registry'.
+// The server uses TLS if the 'tlsConfig' is provided.
+func NewPrometheusServer(conf Config, registry *Registry, tlsConfig *tls.Config) (*prometheusServer, error) {
+	if registry == nil {
+		return nil, ErrNoRegistryProvided
+	}
 
 	// Prometheus HTTP Server with metrics registry
 	p := &prometheusServer{
-		registry: prometheus.NewRegistry(),
+		registry: registry,
+		tls:      tlsConfig,
 		conf:     conf,
 	}
 
-	// Register all defined metrics
-	for _, c := range []prometheus.Collector{
-		requestDuration,
-		requestDurationsHistogram,
-		requestsTotal,
-		requestsFailures,
-		collectors.NewBuildInfoCollector(),
-	} {
-		if err := p.registry.Register(c); err != nil {
-			return nil, err
-		}
-	}
-
 	// Report metrics on the specified route
 	mux := http.NewServeMux()
 	mux.Handle(
 		p.conf.Path,
 		promhttp.HandlerFor(
-			p.registry,
+			p.registry.Gatherer(),
 			promhttp.HandlerOpts{EnableOpenMetrics: true}),
 	)
 
+	p.mux = mux
 	p.server = &http.Server{
 		Addr:    p.conf.Addr,
 		Handler: mux,
@@ -67,9 +93,37 @@ func NewPrometheusServer(conf Config) (*prometheusServer, error) {
 	return p, nil
 }
 
-// Serve starts prometheus HTTP server.
+// Handle exposes the 'handler' on the 'path' alongside the metrics,
+// for example the health probes. It should be called before Serve.
+func (p *prometheusServer) Handle(path string, handler http.Handler) {
+	p.mux.Handle(path, handler)
+}
+
+// Listen binds the listener of prometheus HTTP server.
+func (p *prometheusServer) Listen() error {
+	ln, err := net.Listen("tcp", p.conf.Addr)
+	if err != nil {
+		return err
+	}
+
+	if p.tls != nil {
+		ln = tls.NewListener(ln, p.tls)
+	}
+
+	p.listener = ln
+	return nil
+}
+
+// Serve starts prometheus HTTP server, the listener
+// is bound first if it hasn't been bound yet.
 func (p *prometheusServer) Serve() error {
-	if err := p.server.ListenAndServe(); err != http.ErrServerClosed {
+	if p.listener == nil {
+		if err := p.Listen(); err != nil {
+			return err
+		}
+	}
+
+	if err := p.server.Serve(p.listener); err != http.ErrServerClosed {
 		return err
 	}
 	return nil
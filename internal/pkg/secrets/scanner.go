@@ -0,0 +1,274 @@
+package secrets
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// Policy defines how the detected secrets are handled.
+type Policy string
+
+const (
+
+	// PolicyReject rejects the content that contains secrets.
+	PolicyReject Policy = "reject"
+
+	// PolicyRedact replaces the detected secrets with a placeholder.
+	PolicyRedact Policy = "redact"
+
+	// PolicyWarn reports the detected secrets, but keeps the content as is.
+	PolicyWarn Policy = "warn"
+)
+
+const (
+
+	// RuleHighEntropy is the name of the built-in rule
+	// that detects random looking strings.
+	RuleHighEntropy = "high-entropy-string"
+
+	// minEntropyLen is the shortest string checked for high entropy.
+	minEntropyLen = 20
+
+	// minEntropyBits is the Shannon entropy per character,
+	// starting from which the string is considered a secret.
+	minEntropyBits = 4.2
+)
+
+var (
+
+	// ErrUnknownPolicy happens when the configured policy is not supported.
+	ErrUnknownPolicy = errors.New("unknown secrets policy")
+
+	// ErrEmptyMatchRule happens when the custom rule matches the empty string,
+	// so it would detect a secret at every position of any content.
+	ErrEmptyMatchRule = errors.New("secrets rule matches empty string")
+
+	// builtinRules are the rules that are always enabled.
+	builtinRules = []Rule{
+		{
+			Name:    "aws-access-key-id",
+			Pattern: regexp.MustCompile(`\b(?:AKIA|ASIA|AGPA|AIDA|AROA|ANPA|ANVA|AIPA)[0-9A-Z]{16}\b`),
+		},
+		{
+			Name:    "aws-secret-access-key",
+			Pattern: regexp.MustCompile(`(?i)aws_?secret_?access_?key["']?\s*[:=]\s*["']?[A-Za-z0-9/+=]{40}`),
+		},
+		{
+			Name:    "private-key",
+			Pattern: regexp.MustCompile(`(?s)-----BEGIN [A-Z0-9 ]*PRIVATE KEY( BLOCK)?-----.*?(?:-----END [A-Z0-9 ]*PRIVATE KEY( BLOCK)?-----|\z)`),
+		},
+		{
+			Name:    "jwt",
+			Pattern: regexp.MustCompile(`\beyJ[A-Za-z0-9_-]{5,}\.eyJ[A-Za-z0-9_-]{5,}\.[A-Za-z0-9_-]{10,}`),
+		},
+	}
+
+	// entropyCandidates are the strings that are checked for high entropy.
+	entropyCandidates = regexp.MustCompile(`[A-Za-z0-9+/=_\-]{20,}`)
+)
+
+// Config defines the secret scanner configuration.
+type Config struct {
+
+	// Supported policies:
+	// - reject
+	// - redact
+	// - warn
+	Policy string
+
+	// Rules are the custom rules in addition to the built-in ones,
+	// where the key is the rule name and the value is a regular expression.
+	Rules map[string]string
+}
+
+// Rule is a named pattern of a secret.
+type Rule struct {
+	Name    string
+	Pattern *regexp.Regexp
+}
+
+// Finding is a secret detected in the content.
+type Finding struct {
+
+	// Rule is the name of the rule that detected the secret.
+	Rule string
+
+	// Line is the number of the line where the secret starts, starting from 1.
+	Line int
+
+	// Start and End are the byte offsets of the secret in the content.
+	Start int
+	End   int
+}
+
+// String formats the finding for humans.
+func (f Finding) String() string {
+	return fmt.Sprintf("line %d: %s", f.Line, f.Rule)
+}
+
+// scanner detects credentials and other secrets in the text content.
+type scanner struct {
+	policy Policy
+	rules  []Rule
+}
+
+// NewScanner creates a new secret scanner with the built-in
+// rules and the custom rules from the configuration.
+func NewScanner(config Config) (*scanner, error) {
+	policy := Policy(strings.ToLower(config.Policy))
+	switch policy {
+	case PolicyReject, PolicyRedact, PolicyWarn:
+	default:
+		return nil, fmt.Errorf("%w: %q", ErrUnknownPolicy, config.Policy)
+	}
+
+	rules := append([]Rule{}, builtinRules...)
+
+	// Sort custom rules by name, so the scan order is stable
+	names := make([]string, 0, len(config.Rules))
+	for name := range config.Rules {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		pattern, err := regexp.Compile(config.Rules[name])
+		if err != nil {
+			return nil, fmt.Errorf("invalid secrets rule %q: %w", name, err)
+		}
+		if pattern.MatchString("") {
+			return nil, fmt.Errorf("invalid secrets rule %q: %w", name, ErrEmptyMatchRule)
+		}
+		rules = append(rules, Rule{Name: name, Pattern: pattern})
+	}
+
+	return &scanner{
+		policy: policy,
+		rules:  rules,
+	}, nil
+}
+
+// Policy returns the configured policy.
+func (s *scanner) Policy() Policy {
+	return s.policy
+}
+
+// Scan detects secrets in the 'content'.
+// The findings are ordered by the position in the content
+// and never overlap with each other.
+func (s *scanner) Scan(content string) []Finding {
+	var findings []Finding
+
+	for _, rule := range s.rules {
+		for _, loc := range rule.Pattern.FindAllStringIndex(content, -1) {
+
+			// The rules matching only the positions, like `\b`, pass the
+			// check of the empty string, but the empty matches are no secrets
+			if loc[0] == loc[1] {
+				continue
+			}
+			findings = append(findings, Finding{
+				Rule:  rule.Name,
+				Start: loc[0],
+				End:   loc[1],
+			})
+		}
+	}
+
+	for _, loc := range entropyCandidates.FindAllStringIndex(content, -1) {
+		if isHighEntropy(content[loc[0]:loc[1]]) {
+			findings = append(findings, Finding{
+				Rule:  RuleHighEntropy,
+				Start: loc[0],
+				End:   loc[1],
+			})
+		}
+	}
+
+	return withLines(content, nonOverlapping(findings))
+}
+
+// Redact replaces the 'findings' in the 'content' with a placeholder.
+func (s *scanner) Redact(content string, findings []Finding) string {
+	var b strings.Builder
+	last := 0
+	for _, f := range findings {
+		b.WriteString(content[last:f.Start])
+		b.WriteString("[REDACTED:" + f.Rule + "]")
+		last = f.End
+	}
+	b.WriteString(content[last:])
+	return b.String()
+}
+
+// nonOverlapping orders the findings by position and removes
+// the findings that overlap with the preceding ones.
+// Rule-based findings take precedence over the high entropy ones.
+func nonOverlapping(findings []Finding) []Finding {
+	sort.SliceStable(findings, func(i, j int) bool {
+		if findings[i].Start != findings[j].Start {
+			return findings[i].Start < findings[j].Start
+		}
+		return findings[i].Rule != RuleHighEntropy && findings[j].Rule == RuleHighEntropy
+	})
+
+	result := findings[:0]
+	end := -1
+	for _, f := range findings {
+		if f.Start < end {
+			continue
+		}
+		result = append(result, f)
+		end = f.End
+	}
+	return result
+}
+
+// withLines sets the line numbers of the findings.
+func withLines(content string, findings []Finding) []Finding {
+	line, pos := 1, 0
+	for i := range findings {
+		line += strings.Count(content[pos:findings[i].Start], "\n")
+		pos = findings[i].Start
+		findings[i].Line = line
+	}
+	return findings
+}
+
+// isHighEntropy reports whether the string looks like a random secret.
+//
+// Identifiers and words rarely mix letters with digits and
+// have a lower entropy, so such strings are ignored.
+func isHighEntropy(s string) bool {
+	if len(s) < minEntropyLen {
+		return false
+	}
+
+	if !strings.ContainsAny(s, "0123456789") ||
+		strings.ToLower(s) == s ||
+		strings.ToUpper(s) == s {
+		return false
+	}
+
+	return entropy(s) >= minEntropyBits
+}
+
+// entropy calculates Shannon entropy of the string in bits per character.
+func entropy(s string) float64 {
+	freq := make(map[rune]float64)
+	for _, r := range s {
+		freq[r]++
+	}
+
+	var e float64
+	n := float64(len(s))
+	for _, count := range freq {
+		p := count / n
+		e -= p * math.Log2(p)
+	}
+	return e
+}
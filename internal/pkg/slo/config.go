@@ -0,0 +1,158 @@
+package slo
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+
+	// KindAvailability is the objective of the ratio of the requests,
+	// that haven't failed with the server error.
+	KindAvailability = "availability"
+
+	// KindLatency is the objective of the ratio of the requests,
+	// that have been handled within the latency threshold.
+	KindLatency = "latency"
+)
+
+var (
+	// ErrInvalidObjective happens when the objective could not be parsed.
+	ErrInvalidObjective = errors.New("invalid service level objective")
+
+	// ErrInvalidWindow happens when the windows or the sample interval are not valid.
+	ErrInvalidWindow = errors.New("invalid service level objective window")
+)
+
+// Config is a service level objectives configuration.
+type Config struct {
+
+	// Availability are the target percentages of the requests, that
+	// haven't failed with the server error, by the route template.
+	// For example: "/api/gists/:id" -> "99.9"
+	Availability map[string]string
+
+	// Latency are the thresholds in seconds and the target percentages of the requests,
+	// that have been handled within the threshold, by the route template.
+	// The threshold should be one of the request duration histogram bucket bounds.
+	// For example: "/api/gists/:id" -> "0.1:99"
+	Latency map[string]string
+
+	// Window is the rolling window the error budget is calculated for.
+	// For example: 1h
+	Window time.Duration
+
+	// BurnWindow is the rolling window the budget burn rate is calculated for.
+	// For example: 5m
+	BurnWindow time.Duration
+
+	// Interval is the interval between the samples of the request metrics.
+	// For example: 10s
+	Interval time.Duration
+}
+
+// Objective is a service level objective of the route.
+type Objective struct {
+
+	// Route is the route template, for example "/api/gists/:id".
+	Route string
+
+	// Kind is either KindAvailability or KindLatency.
+	Kind string
+
+	// Target is the ratio of the good requests, for example 0.999.
+	Target float64
+
+	// Threshold is the latency threshold in seconds of the KindLatency objective.
+	Threshold float64
+}
+
+// parseObjectives parses the configured objectives ordered by the route and kind.
+func parseObjectives(config Config) ([]Objective, error) {
+	objectives := []Objective{}
+
+	for route, spec := range config.Availability {
+		target, err := parseTarget(spec)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %s %s=%s: %s", ErrInvalidObjective, KindAvailability, route, spec, err)
+		}
+
+		objectives = append(objectives, Objective{
+			Route:  route,
+			Kind:   KindAvailability,
+			Target: target,
+		})
+	}
+
+	// Latency objective is 'threshold:target'
+	for route, spec := range config.Latency {
+		threshold, target, found := strings.Cut(spec, ":")
+		if !found {
+			return nil, fmt.Errorf("%w: %s %s=%s: expected threshold:target", ErrInvalidObjective, KindLatency, route, spec)
+		}
+
+		seconds, err := strconv.ParseFloat(threshold, 64)
+		if err == nil && seconds <= 0 {
+			err = errors.New("threshold should be positive")
+		}
+		if err != nil {
+			return nil, fmt.Errorf("%w: %s %s=%s: %s", ErrInvalidObjective, KindLatency, route, spec, err)
+		}
+
+		ratio, err := parseTarget(target)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %s %s=%s: %s", ErrInvalidObjective, KindLatency, route, spec, err)
+		}
+
+		objectives = append(objectives, Objective{
+			Route:     route,
+			Kind:      KindLatency,
+			Target:    ratio,
+			Threshold: seconds,
+		})
+	}
+
+	sort.Slice(objectives, func(i, j int) bool {
+		if objectives[i].Route == objectives[j].Route {
+			return objectives[i].Kind < objectives[j].Kind
+		}
+		return objectives[i].Route < objectives[j].Route
+	})
+
+	return objectives, nil
+}
+
+// parseTarget parses the target percentage to the ratio.
+// The 100% target is rejected, because it leaves no error budget.
+func parseTarget(spec string) (float64, error) {
+	percent, err := strconv.ParseFloat(strings.TrimSuffix(spec, "%"), 64)
+	if err != nil {
+		return 0, err
+	}
+
+	if percent <= 0 || percent >= 100 {
+		return 0, errors.New("target should be within (0, 100) percent")
+	}
+
+	// Rounded, so the ratio is reported as configured, for example 0.999 for 99.9%
+	return math.Round(percent*1e7) / 1e9, nil
+}
+
+// validateWindows ensures that the burn window fits the budget window,
+// and the interval is short enough to sample the burn window.
+func validateWindows(config Config) error {
+	switch {
+	case config.Interval <= 0:
+		return fmt.Errorf("%w: interval should be positive", ErrInvalidWindow)
+	case config.BurnWindow < config.Interval:
+		return fmt.Errorf("%w: burn window should not be shorter than interval", ErrInvalidWindow)
+	case config.Window < config.BurnWindow:
+		return fmt.Errorf("%w: window should not be shorter than burn window", ErrInvalidWindow)
+	}
+	return nil
+}
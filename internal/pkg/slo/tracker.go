@@ -0,0 +1,361 @@
+package slo
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+
+	dto "github.com/prometheus/client_model/go"
+
+	"git.lothric.net/examples/go/gogin/internal/pkg/lifecycle"
+	"git.lothric.net/examples/go/gogin/internal/pkg/logger"
+	"git.lothric.net/examples/go/gogin/internal/pkg/metrics"
+)
+
+const (
+
+	// serverErrors is the status class of the failed requests.
+	serverErrors = "5xx"
+
+	// boundTolerance is the tolerance of the latency threshold
+	// comparison with the bucket bounds, that are not exact,
+	// because the buckets are calculated in floating point.
+	boundTolerance = 1e-9
+)
+
+var (
+	// ErrNoLoggerProvided happens when logger is not provided.
+	ErrNoLoggerProvided = errors.New("no logger provided")
+
+	// ErrNoRegistryProvided happens when metrics registry is not provided.
+	ErrNoRegistryProvided = errors.New("no metrics registry provided")
+)
+
+// Status is the current state of the service level objective.
+type Status struct {
+	Objective
+
+	// Requests is the number of requests within the window.
+	Requests float64
+
+	// Errors is the number of requests within the window, that
+	// have failed or haven't been handled within the latency threshold.
+	Errors float64
+
+	// ErrorRate is the ratio of the errors within the window.
+	ErrorRate float64
+
+	// BurnRate is how fast the error budget is consumed within the burn window,
+	// relative to the rate that consumes exactly the whole budget in the window.
+	BurnRate float64
+
+	// BudgetConsumed is the ratio of the error budget consumed within the window,
+	// it is greater than 1 if the objective is violated.
+	BudgetConsumed float64
+
+	// BudgetRemaining is the ratio of the error budget left within the window,
+	// it is negative if the objective is violated.
+	BudgetRemaining float64
+}
+
+// counts are the total and bad requests of the objective.
+type counts struct {
+	total float64
+	bad   float64
+}
+
+// sample are the counts of all objectives at the time of sampling.
+type sample struct {
+	at     time.Time
+	counts []counts
+}
+
+// Tracker tracks the service level objectives of the API routes.
+//
+// The tracker samples the request duration histogram
+// of the metrics registry on every interval and calculates
+// the error rates and the error budgets within the rolling windows.
+type Tracker struct {
+	log        logger.Log
+	gatherer   prometheus.Gatherer
+	objectives []Objective
+	window     time.Duration
+	burnWindow time.Duration
+	interval   time.Duration
+	now        func() time.Time
+
+	// samples are ordered by time, the oldest one is the baseline of the window
+	mu      sync.RWMutex
+	samples []sample
+
+	objective       *prometheus.GaugeVec
+	errorRate       *prometheus.GaugeVec
+	burnRate        *prometheus.GaugeVec
+	budgetRemaining *prometheus.GaugeVec
+
+	periodic *lifecycle.Periodic
+}
+
+// NewTracker creates a new tracker of the configured objectives,
+// that reports the objectives state to the metrics 'registry'.
+func NewTracker(log logger.Log, config Config, registry *metrics.Registry) (*Tracker, error) {
+	if log == nil {
+		return nil, ErrNoLoggerProvided
+	}
+
+	if registry == nil {
+		return nil, ErrNoRegistryProvided
+	}
+
+	if err := validateWindows(config); err != nil {
+		return nil, err
+	}
+
+	objectives, err := parseObjectives(config)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := validateThresholds(objectives, registry.Buckets(metrics.FamilyRequestDurations)); err != nil {
+		return nil, err
+	}
+
+	labels := []string{metrics.LabelRoute, "slo"}
+	t := &Tracker{
+		log:        log.WithField(logger.FieldPackage, "slo"),
+		gatherer:   registry.Gatherer(),
+		objectives: objectives,
+		window:     config.Window,
+		burnWindow: config.BurnWindow,
+		interval:   config.Interval,
+		now:        time.Now,
+
+		objective: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Name: "slo_objective_ratio",
+			Help: "Target ratio of the good API requests.",
+		}, labels),
+		errorRate: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Name: "slo_error_ratio",
+			Help: "Ratio of the bad API requests within the error budget window.",
+		}, labels),
+		burnRate: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Name: "slo_burn_rate",
+			Help: "Error budget burn rate within the burn window.",
+		}, labels),
+		budgetRemaining: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Name: "slo_error_budget_remaining_ratio",
+			Help: "Ratio of the error budget left within the error budget window.",
+		}, labels),
+	}
+	t.periodic = lifecycle.NewPeriodic(config.Interval, func(context.Context) { t.Sample() })
+
+	for _, c := range []prometheus.Collector{t.objective, t.errorRate, t.burnRate, t.budgetRemaining} {
+		if err := registry.Register(c); err != nil {
+			return nil, err
+		}
+	}
+
+	for _, o := range objectives {
+		t.objective.WithLabelValues(o.Route, o.Kind).Set(o.Target)
+	}
+
+	return t, nil
+}
+
+// Run samples the request metrics on every interval, until the tracker is stopped.
+func (t *Tracker) Run() error {
+	// The first sample is the baseline of the windows
+	t.Sample()
+
+	return t.periodic.Run()
+}
+
+// Stop stops the sampling and waits for the sample in progress to finish.
+func (t *Tracker) Stop(ctx context.Context) error {
+	return t.periodic.Stop(ctx)
+}
+
+// Sample samples the request metrics and updates the objectives state.
+func (t *Tracker) Sample() {
+	log := t.log.WithField(logger.FieldFunction, "Sample")
+
+	families, err := t.gatherer.Gather()
+	if err != nil {
+		log.Error(err, "Failed to gather request metrics")
+		return
+	}
+
+	now := t.now()
+	s := sample{
+		at:     now,
+		counts: make([]counts, len(t.objectives)),
+	}
+	for _, family := range families {
+		if family.GetName() == metrics.FamilyRequestDurations {
+			for i, o := range t.objectives {
+				s.counts[i] = count(o, family)
+			}
+		}
+	}
+
+	t.mu.Lock()
+	t.samples = append(t.samples, s)
+
+	// Only the latest sample that is older than
+	// the window is kept as the baseline of the window
+	for len(t.samples) > 1 && !t.samples[1].at.After(now.Add(-t.window)) {
+		t.samples = t.samples[1:]
+	}
+	t.mu.Unlock()
+
+	for _, status := range t.Status() {
+		labels := []string{status.Route, status.Kind}
+		t.errorRate.WithLabelValues(labels...).Set(status.ErrorRate)
+		t.burnRate.WithLabelValues(labels...).Set(status.BurnRate)
+		t.budgetRemaining.WithLabelValues(labels...).Set(status.BudgetRemaining)
+	}
+}
+
+// Window returns the rolling window of the error budget.
+func (t *Tracker) Window() time.Duration {
+	return t.window
+}
+
+// BurnWindow returns the rolling window of the burn rate.
+func (t *Tracker) BurnWindow() time.Duration {
+	return t.burnWindow
+}
+
+// Status returns the current state of all objectives ordered by the route and kind.
+func (t *Tracker) Status() []Status {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+
+	result := make([]Status, 0, len(t.objectives))
+	for i, o := range t.objectives {
+		status := Status{Objective: o}
+
+		if len(t.samples) > 0 {
+			window := t.delta(i, t.window)
+			burn := t.delta(i, t.burnWindow)
+			budget := 1 - o.Target
+
+			status.Requests = window.total
+			status.Errors = window.bad
+			status.ErrorRate = ratio(window.bad, window.total)
+			status.BurnRate = ratio(burn.bad, burn.total) / budget
+			status.BudgetConsumed = status.ErrorRate / budget
+			status.BudgetRemaining = 1 - status.BudgetConsumed
+		} else {
+			status.BudgetRemaining = 1
+		}
+
+		result = append(result, status)
+	}
+
+	return result
+}
+
+// delta returns the counts of the objective 'i' within the 'window'
+// since the latest sample that is not newer than the window start,
+// or since the oldest sample if the window is not filled yet.
+func (t *Tracker) delta(i int, window time.Duration) counts {
+	latest := t.samples[len(t.samples)-1]
+	start := latest.at.Add(-window)
+
+	baseline := t.samples[0]
+	for _, s := range t.samples[1:] {
+		if s.at.After(start) {
+			break
+		}
+		baseline = s
+	}
+
+	return counts{
+		total: latest.counts[i].total - baseline.counts[i].total,
+		bad:   latest.counts[i].bad - baseline.counts[i].bad,
+	}
+}
+
+// count returns the total and bad requests of the objective from the request durations 'family'.
+func count(o Objective, family *dto.MetricFamily) counts {
+	var c counts
+
+	for _, m := range family.GetMetric() {
+		if label(m, metrics.LabelRoute) != o.Route {
+			continue
+		}
+
+		h := m.GetHistogram()
+		total := float64(h.GetSampleCount())
+		c.total += total
+
+		switch o.Kind {
+		case KindAvailability:
+			if label(m, metrics.LabelStatus) == serverErrors {
+				c.bad += total
+			}
+
+		case KindLatency:
+			// The buckets are cumulative, the threshold bucket
+			// has all the fast requests
+			good := 0.0
+			for _, b := range h.GetBucket() {
+				if b.GetUpperBound() > o.Threshold+boundTolerance {
+					break
+				}
+				good = float64(b.GetCumulativeCount())
+			}
+			c.bad += total - good
+		}
+	}
+
+	return c
+}
+
+// validateThresholds ensures that the latency thresholds are the request duration
+// histogram 'bounds', otherwise the fast requests could not be counted exactly.
+func validateThresholds(objectives []Objective, bounds []float64) error {
+	for _, o := range objectives {
+		if o.Kind != KindLatency || isBound(o.Threshold, bounds) {
+			continue
+		}
+
+		return fmt.Errorf("%w: %s %s: threshold %v is not a bucket bound of %s %v",
+			ErrInvalidObjective, KindLatency, o.Route, o.Threshold, metrics.FamilyRequestDurations, bounds)
+	}
+	return nil
+}
+
+// isBound reports whether the 'threshold' is one of the bucket 'bounds'.
+func isBound(threshold float64, bounds []float64) bool {
+	for _, b := range bounds {
+		if math.Abs(b-threshold) <= boundTolerance {
+			return true
+		}
+	}
+	return false
+}
+
+// label returns the value of the metric label 'name'.
+func label(m *dto.Metric, name string) string {
+	for _, l := range m.GetLabel() {
+		if l.GetName() == name {
+			return l.GetValue()
+		}
+	}
+	return ""
+}
+
+// ratio returns the 'part' of the 'total', or zero if there is no total.
+func ratio(part float64, total float64) float64 {
+	if total == 0 {
+		return 0
+	}
+	return math.Max(part/total, 0)
+}
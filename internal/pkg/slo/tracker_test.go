@@ -0,0 +1,308 @@
+package slo
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"git.lothric.net/examples/go/gogin/internal/pkg/logger"
+	"git.lothric.net/examples/go/gogin/internal/pkg/metrics"
+	"git.lothric.net/examples/go/gogin/internal/pkg/metrics/metricstest"
+)
+
+func TestTracker(t *testing.T) {
+	for scenario, fn := range map[string]func(
+		t *testing.T,
+		tr *Tracker,
+		r requestsReporter,
+		m *metrics.Registry,
+		c *clock,
+	){
+		"tracks availability":            testTracksAvailability,
+		"tracks latency":                 testTracksLatency,
+		"calculates burn rate":           testCalculatesBurnRate,
+		"forgets requests out of window": testForgetsRequestsOutOfWindow,
+		"reports gauges":                 testReportsGauges,
+	} {
+		t.Run(scenario, func(t *testing.T) {
+			log, _ := logger.NewNullLogger()
+
+			registry, err := metrics.NewRegistry(metrics.Config{})
+			require.NoError(t, err)
+
+			reporter, err := metrics.NewReporter(log, registry)
+			require.NoError(t, err)
+
+			tracker, err := NewTracker(log, Config{
+				Availability: map[string]string{"/api/gists/:id": "99"},
+				Latency:      map[string]string{"/api/gists/:id": "0.1:90"},
+				Window:       time.Hour,
+				BurnWindow:   5 * time.Minute,
+				Interval:     time.Minute,
+			}, registry)
+			require.NoError(t, err)
+
+			clock := &clock{now: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)}
+			tracker.now = clock.Now
+			tracker.Sample()
+
+			fn(t, tracker, reporter, registry, clock)
+		})
+	}
+}
+
+// requestsReporter reports the processed API requests.
+type requestsReporter interface {
+	ApiRequestProcessed(
+		method string,
+		route string,
+		status int,
+		duration time.Duration,
+		requestSize int64,
+		responseSize int64,
+		exemplar map[string]string,
+	)
+}
+
+// clock is a manually advanced time source.
+type clock struct {
+	now time.Time
+}
+
+func (c *clock) Now() time.Time {
+	return c.now
+}
+
+func (c *clock) Advance(d time.Duration) {
+	c.now = c.now.Add(d)
+}
+
+// requests reports 'n' processed requests of the gist route.
+func requests(r requestsReporter, n int, status int, duration time.Duration) {
+	for i := 0; i < n; i++ {
+		r.ApiRequestProcessed(http.MethodGet, "/api/gists/:id", status, duration, 0, 0, nil)
+	}
+}
+
+// status returns the status of the objective 'kind'.
+func status(t *testing.T, tr *Tracker, kind string) Status {
+	for _, s := range tr.Status() {
+		if s.Kind == kind {
+			return s
+		}
+	}
+	require.FailNow(t, "objective not found", kind)
+	return Status{}
+}
+
+func testTracksAvailability(
+	t *testing.T,
+	tr *Tracker,
+	r requestsReporter,
+	m *metrics.Registry,
+	c *clock,
+) {
+
+	requests(r, 196, http.StatusOK, time.Millisecond)
+	requests(r, 3, http.StatusNotFound, time.Millisecond)
+	requests(r, 1, http.StatusInternalServerError, time.Millisecond)
+
+	// Other routes are not counted
+	r.ApiRequestProcessed(http.MethodGet, "/api/gists", http.StatusInternalServerError, time.Millisecond, 0, 0, nil)
+
+	c.Advance(time.Minute)
+	tr.Sample()
+
+	s := status(t, tr, KindAvailability)
+	require.Equal(t, 200.0, s.Requests)
+	require.Equal(t, 1.0, s.Errors)
+	require.InDelta(t, 0.005, s.ErrorRate, 1e-9)
+	require.InDelta(t, 0.5, s.BudgetConsumed, 1e-9)
+	require.InDelta(t, 0.5, s.BudgetRemaining, 1e-9)
+}
+
+func testTracksLatency(
+	t *testing.T,
+	tr *Tracker,
+	r requestsReporter,
+	m *metrics.Registry,
+	c *clock,
+) {
+
+	// The requests within the threshold bucket are fast
+	requests(r, 70, http.StatusOK, 20*time.Millisecond)
+	requests(r, 10, http.StatusOK, 90*time.Millisecond)
+	requests(r, 20, http.StatusOK, 150*time.Millisecond)
+
+	c.Advance(time.Minute)
+	tr.Sample()
+
+	s := status(t, tr, KindLatency)
+	require.Equal(t, 0.1, s.Threshold)
+	require.Equal(t, 100.0, s.Requests)
+	require.Equal(t, 20.0, s.Errors)
+	require.InDelta(t, 2.0, s.BudgetConsumed, 1e-9)
+	require.InDelta(t, -1.0, s.BudgetRemaining, 1e-9)
+}
+
+func testCalculatesBurnRate(
+	t *testing.T,
+	tr *Tracker,
+	r requestsReporter,
+	m *metrics.Registry,
+	c *clock,
+) {
+
+	// The old failures are out of the burn window, but within the budget window
+	requests(r, 98, http.StatusOK, time.Millisecond)
+	requests(r, 2, http.StatusInternalServerError, time.Millisecond)
+	c.Advance(10 * time.Minute)
+	tr.Sample()
+
+	requests(r, 100, http.StatusOK, time.Millisecond)
+	c.Advance(10 * time.Minute)
+	tr.Sample()
+
+	s := status(t, tr, KindAvailability)
+	require.Equal(t, 0.0, s.BurnRate)
+	require.InDelta(t, 1.0, s.BudgetConsumed, 1e-9)
+
+	// The fresh failures burn the budget 5 times faster than allowed
+	requests(r, 95, http.StatusOK, time.Millisecond)
+	requests(r, 5, http.StatusInternalServerError, time.Millisecond)
+	c.Advance(5 * time.Minute)
+	tr.Sample()
+
+	s = status(t, tr, KindAvailability)
+	require.InDelta(t, 5.0, s.BurnRate, 1e-9)
+}
+
+func testForgetsRequestsOutOfWindow(
+	t *testing.T,
+	tr *Tracker,
+	r requestsReporter,
+	m *metrics.Registry,
+	c *clock,
+) {
+
+	requests(r, 10, http.StatusInternalServerError, time.Millisecond)
+	c.Advance(time.Minute)
+	tr.Sample()
+
+	for i := 0; i < 60; i++ {
+		requests(r, 1, http.StatusOK, time.Millisecond)
+		c.Advance(time.Minute)
+		tr.Sample()
+	}
+
+	s := status(t, tr, KindAvailability)
+	require.Equal(t, 60.0, s.Requests)
+	require.Equal(t, 0.0, s.Errors)
+	require.Equal(t, 1.0, s.BudgetRemaining)
+	require.LessOrEqual(t, len(tr.samples), 62)
+}
+
+func testReportsGauges(
+	t *testing.T,
+	tr *Tracker,
+	r requestsReporter,
+	m *metrics.Registry,
+	c *clock,
+) {
+
+	requests(r, 99, http.StatusOK, time.Millisecond)
+	requests(r, 1, http.StatusInternalServerError, time.Millisecond)
+	c.Advance(time.Minute)
+	tr.Sample()
+
+	metricstest.Compare(t, m.Gatherer(), `
+# HELP slo_objective_ratio Target ratio of the good API requests.
+# TYPE slo_objective_ratio gauge
+slo_objective_ratio{route="/api/gists/:id",slo="availability"} 0.99
+slo_objective_ratio{route="/api/gists/:id",slo="latency"} 0.9
+`, "slo_objective_ratio")
+
+	labels := map[string]string{"route": "/api/gists/:id", "slo": KindAvailability}
+	require.InDelta(t, 0.01, metricstest.Value(t, m.Gatherer(), "slo_error_ratio", labels), 1e-9)
+	require.InDelta(t, 1.0, metricstest.Value(t, m.Gatherer(), "slo_burn_rate", labels), 1e-9)
+	require.InDelta(t, 0.0, metricstest.Value(t, m.Gatherer(), "slo_error_budget_remaining_ratio", labels), 1e-9)
+}
+
+func TestObjectives(t *testing.T) {
+	objectives, err := parseObjectives(Config{
+		Availability: map[string]string{"/api/gists": "99.9%"},
+		Latency:      map[string]string{"/api/gists": "0.25:99"},
+	})
+	require.NoError(t, err)
+	require.Equal(t, []Objective{
+		{Route: "/api/gists", Kind: KindAvailability, Target: 0.999},
+		{Route: "/api/gists", Kind: KindLatency, Target: 0.99, Threshold: 0.25},
+	}, objectives)
+
+	for _, config := range []Config{
+		{Availability: map[string]string{"/api/gists": "100"}},
+		{Availability: map[string]string{"/api/gists": "x"}},
+		{Latency: map[string]string{"/api/gists": "0.25"}},
+		{Latency: map[string]string{"/api/gists": "0:99"}},
+		{Latency: map[string]string{"/api/gists": "0.25:0"}},
+	} {
+		_, err := parseObjectives(config)
+		require.ErrorIs(t, err, ErrInvalidObjective, config)
+	}
+
+	for _, config := range []Config{
+		{Window: time.Hour, BurnWindow: 5 * time.Minute},
+		{Window: time.Hour, BurnWindow: time.Second, Interval: time.Minute},
+		{Window: time.Minute, BurnWindow: time.Hour, Interval: time.Second},
+	} {
+		require.ErrorIs(t, validateWindows(config), ErrInvalidWindow, config)
+	}
+}
+
+func TestThresholds(t *testing.T) {
+	log, _ := logger.NewNullLogger()
+
+	for threshold, buckets := range map[string]string{
+		"0.1":   "0.05;0.1;0.25",
+		"0.3":   "linear:0.1:0.1:3",
+		"0.001": "exponential:0.001:10:3",
+	} {
+		registry, err := metrics.NewRegistry(metrics.Config{
+			Buckets: map[string]string{metrics.FamilyRequestDurations: buckets},
+		})
+		require.NoError(t, err)
+
+		_, err = NewTracker(log, Config{
+			Latency:    map[string]string{"/api/gists": threshold + ":99"},
+			Window:     time.Hour,
+			BurnWindow: 5 * time.Minute,
+			Interval:   time.Minute,
+		}, registry)
+		require.NoError(t, err, threshold)
+	}
+
+	for threshold, buckets := range map[string]string{
+		"0.15": "0.05;0.1;0.25",
+		"1":    "linear:0.1:0.1:3",
+	} {
+		registry, err := metrics.NewRegistry(metrics.Config{
+			Buckets: map[string]string{metrics.FamilyRequestDurations: buckets},
+		})
+		require.NoError(t, err)
+
+		_, err = NewTracker(log, Config{
+			Latency:    map[string]string{"/api/gists": threshold + ":99"},
+			Window:     time.Hour,
+			BurnWindow: 5 * time.Minute,
+			Interval:   time.Minute,
+		}, registry)
+		require.ErrorIs(t, err, ErrInvalidObjective, threshold)
+	}
+
+	// The native histograms without the classic buckets can't count the fast requests
+	require.ErrorIs(t, validateThresholds([]Objective{
+		{Route: "/api/gists", Kind: KindLatency, Target: 0.99, Threshold: 0.1},
+	}, nil), ErrInvalidObjective)
+}
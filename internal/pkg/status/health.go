@@ -0,0 +1,250 @@
+package status
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"sync"
+	"time"
+
+	"google.golang.org/grpc/health"
+
+	gh "google.golang.org/grpc/health/grpc_health_v1"
+
+	"git.lothric.net/examples/go/gogin/internal/pkg/lifecycle"
+	"git.lothric.net/examples/go/gogin/internal/pkg/logger"
+)
+
+// ServiceLiveness is the gRPC health service that is serving while the
+// process is able to respond, regardless of the components health.
+const ServiceLiveness = "liveness"
+
+var (
+	// ErrNoLoggerProvided happens when logger is not provided.
+	ErrNoLoggerProvided = errors.New("no logger provided")
+
+	// ErrNoHealthProvided happens when health registry is not provided.
+	ErrNoHealthProvided = errors.New("no health registry provided")
+
+	// ErrInvalidService happens when the checker is registered without the service name.
+	ErrInvalidService = errors.New("invalid health service name")
+
+	// ErrDuplicateService happens when the checker of the service is already registered.
+	ErrDuplicateService = errors.New("duplicate health service")
+
+	// ErrInvalidCheckInterval happens when the check interval or timeout are not positive.
+	ErrInvalidCheckInterval = errors.New("invalid health check interval")
+
+	// ErrNotReady happens when the service hasn't started yet or is shutting down.
+	ErrNotReady = errors.New("service is not ready")
+)
+
+// Checker checks the health of a component the service depends on.
+type Checker interface {
+
+	// Check returns an error if the component is not healthy.
+	Check(ctx context.Context) error
+}
+
+// CheckerFunc is an adapter to use a function as a Checker.
+type CheckerFunc func(ctx context.Context) error
+
+// Check calls f(ctx).
+func (f CheckerFunc) Check(ctx context.Context) error {
+	return f(ctx)
+}
+
+// Health is a registry of the health checkers of the service components.
+//
+// Every registered component is reported as a separate service of the gRPC
+// health server, and the overall service "" is serving only if all the
+// components are healthy. All the services are not serving until the startup
+// is completed with Ready, and after the Shutdown has started, except for the
+// ServiceLiveness, that is serving while the process is running.
+//
+// The components are probed on every interval, so the status changes
+// are streamed to the clients that watch the services.
+type Health struct {
+	log      logger.Log
+	server   *health.Server
+	interval time.Duration
+	timeout  time.Duration
+
+	mu       sync.Mutex
+	checkers map[string]Checker
+	failures map[string]error
+	ready    bool
+	shutdown bool
+
+	periodic *lifecycle.Periodic
+}
+
+// NewHealth creates a new health registry, that reports
+// all the services as not serving until the startup is completed.
+func NewHealth(log logger.Log, config Config) (*Health, error) {
+	if log == nil {
+		return nil, ErrNoLoggerProvided
+	}
+
+	if config.CheckInterval <= 0 || config.CheckTimeout <= 0 {
+		return nil, ErrInvalidCheckInterval
+	}
+
+	server := health.NewServer()
+	server.SetServingStatus("", gh.HealthCheckResponse_NOT_SERVING)
+	server.SetServingStatus(ServiceLiveness, gh.HealthCheckResponse_SERVING)
+
+	h := &Health{
+		log:      log.WithField(logger.FieldPackage, "status"),
+		server:   server,
+		interval: config.CheckInterval,
+		timeout:  config.CheckTimeout,
+		checkers: make(map[string]Checker),
+		failures: make(map[string]error),
+	}
+	h.periodic = lifecycle.NewPeriodic(h.interval, h.Probe)
+
+	return h, nil
+}
+
+// Register registers the 'checker' of the component 'service'.
+// The service is not serving until it is probed after the startup.
+func (h *Health) Register(service string, checker Checker) error {
+	if service == "" || service == ServiceLiveness {
+		return ErrInvalidService
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if _, ok := h.checkers[service]; ok {
+		return ErrDuplicateService
+	}
+
+	h.checkers[service] = checker
+	h.failures[service] = ErrNotReady
+	h.server.SetServingStatus(service, gh.HealthCheckResponse_NOT_SERVING)
+
+	return nil
+}
+
+// Ready completes the startup and probes the components,
+// so the healthy services are serving right away.
+func (h *Health) Ready() {
+	h.mu.Lock()
+	h.ready = true
+	h.mu.Unlock()
+
+	h.Probe(context.Background())
+}
+
+// Shutdown starts the shutdown, the overall service and the components are
+// not serving from now on, so the clients stop sending new requests. The
+// liveness keeps serving, so the process is not restarted while it drains.
+func (h *Health) Shutdown() {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.shutdown = true
+	h.update()
+}
+
+// Probe checks all the components and updates the statuses of their services.
+func (h *Health) Probe(ctx context.Context) {
+	log := h.log.WithField(logger.FieldFunction, "Probe")
+
+	h.mu.Lock()
+	checkers := make(map[string]Checker, len(h.checkers))
+	for service, checker := range h.checkers {
+		checkers[service] = checker
+	}
+	h.mu.Unlock()
+
+	// The components are checked without the lock,
+	// so a slow check doesn't block the status updates
+	failures := make(map[string]error, len(checkers))
+	for service, checker := range checkers {
+		checkCtx, cancel := context.WithTimeout(ctx, h.timeout)
+		failures[service] = checker.Check(checkCtx)
+		cancel()
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	for service, err := range failures {
+		if previous := h.failures[service]; (previous == nil) != (err == nil) {
+			if err != nil {
+				log.Error(err, "Component is not healthy: ", service)
+			} else {
+				log.Info("Component is healthy: ", service)
+			}
+		}
+		h.failures[service] = err
+	}
+
+	h.update()
+}
+
+// Status returns the failures of the unhealthy components by the service name,
+// or ErrNotReady for all of them during the startup and the shutdown.
+func (h *Health) Status() map[string]error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	result := make(map[string]error, len(h.failures))
+	for service, err := range h.failures {
+		if !h.ready || h.shutdown {
+			err = ErrNotReady
+		}
+		if err != nil {
+			result[service] = err
+		}
+	}
+	return result
+}
+
+// Services returns the names of the registered component services.
+func (h *Health) Services() []string {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	services := make([]string, 0, len(h.checkers))
+	for service := range h.checkers {
+		services = append(services, service)
+	}
+	sort.Strings(services)
+	return services
+}
+
+// update sets the serving statuses of the services from the last probe,
+// all of them are not serving during the startup and the shutdown.
+func (h *Health) update() {
+	serving := h.ready && !h.shutdown
+
+	overall := gh.HealthCheckResponse_SERVING
+	if !serving {
+		overall = gh.HealthCheckResponse_NOT_SERVING
+	}
+
+	for service, err := range h.failures {
+		status := gh.HealthCheckResponse_SERVING
+		if !serving || err != nil {
+			status = gh.HealthCheckResponse_NOT_SERVING
+			overall = gh.HealthCheckResponse_NOT_SERVING
+		}
+		h.server.SetServingStatus(service, status)
+	}
+
+	h.server.SetServingStatus("", overall)
+}
+
+// Run probes the components on every interval, until the probing is stopped.
+func (h *Health) Run() error {
+	return h.periodic.Run()
+}
+
+// Stop stops the probing and waits for the probe in progress to finish.
+func (h *Health) Stop(ctx context.Context) error {
+	return h.periodic.Stop(ctx)
+}
@@ -0,0 +1,349 @@
+package status
+
+import (
+	"context"
+	"errors"
+	"net"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+
+	gh "google.golang.org/grpc/health/grpc_health_v1"
+
+	"git.lothric.net/examples/go/gogin/internal/pkg/logger"
+)
+
+const (
+	serviceStorage = "storage"
+	serviceCache   = "cache"
+)
+
+var errUnavailable = errors.New("unavailable")
+
+func TestHealth(t *testing.T) {
+	for scenario, fn := range map[string]func(
+		t *testing.T,
+		h *Health,
+		client gh.HealthClient,
+		storage *checker,
+		server *statusServer,
+	){
+		"not serving during startup":                 testNotServingDuringStartup,
+		"serves healthy components":                  testServesHealthyComponents,
+		"reports unhealthy component":                testReportsUnhealthyComponent,
+		"recovers healthy component":                 testRecoversHealthyComponent,
+		"times out slow component":                   testTimesOutSlowComponent,
+		"not serving during shutdown":                testNotServingDuringShutdown,
+		"streams status changes":                     testStreamsStatusChanges,
+		"probes components periodically":             testProbesComponentsPeriodically,
+		"ends streams on stop":                       testEndsStreamsOnStop,
+		"fails on unknown service":                   testFailsOnUnknownService,
+		"fails on duplicate service":                 testFailsOnDuplicateService,
+		"fails on service without name":              testFailsOnServiceWithoutName,
+		"lists services":                             testListsServices,
+		"live during startup, failures and shutdown": testLiveDuringStartupAndFailures,
+		"fails on liveness service":                  testFailsOnLivenessService,
+		"reports failures of not ready":              testReportsFailuresOfNotReady,
+		"reports failures of unhealthy":              testReportsFailuresOfUnhealthy,
+		"reports no failures when healthy":           testReportsNoFailuresWhenHealthy,
+	} {
+		t.Run(scenario, func(t *testing.T) {
+			log, _ := logger.NewNullLogger()
+
+			health, err := NewHealth(log, Config{
+				CheckInterval: 10 * time.Millisecond,
+				CheckTimeout:  50 * time.Millisecond,
+			})
+			require.NoError(t, err)
+
+			storage := &checker{}
+			require.NoError(t, health.Register(serviceStorage, storage))
+			require.NoError(t, health.Register(serviceCache, &checker{}))
+
+			server, err := NewStatusServer(Config{}, health, nil)
+			require.NoError(t, err)
+
+			listener := bufconn.Listen(1024 * 1024)
+			server.listener = listener
+			go server.Serve()
+			t.Cleanup(func() { server.Stop(context.Background()) })
+
+			conn, err := grpc.Dial("bufconn",
+				grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+					return listener.DialContext(ctx)
+				}),
+				grpc.WithTransportCredentials(insecure.NewCredentials()))
+			require.NoError(t, err)
+			t.Cleanup(func() { conn.Close() })
+
+			fn(t, health, gh.NewHealthClient(conn), storage, server)
+		})
+	}
+}
+
+func TestHealthConfig(t *testing.T) {
+	for scenario, fn := range map[string]func(t *testing.T, log logger.Log){
+		"fails if no logger":            testFailsIfNoLogger,
+		"fails if no interval":          testFailsIfNoInterval,
+		"fails if no timeout":           testFailsIfNoTimeout,
+		"fails if no health for server": testFailsIfNoHealthForServer,
+	} {
+		t.Run(scenario, func(t *testing.T) {
+			log, _ := logger.NewNullLogger()
+			fn(t, log)
+		})
+	}
+}
+
+// checker is a component health checker that fails on demand,
+// or blocks until the check is cancelled if it is slow.
+type checker struct {
+	mu     sync.Mutex
+	err    error
+	slow   bool
+	checks int
+}
+
+func (c *checker) Check(ctx context.Context) error {
+	c.mu.Lock()
+	c.checks++
+	err, slow := c.err, c.slow
+	c.mu.Unlock()
+
+	if slow {
+		<-ctx.Done()
+		return ctx.Err()
+	}
+	return err
+}
+
+func (c *checker) Fail(err error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.err = err
+}
+
+func (c *checker) Slow() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.slow = true
+}
+
+func (c *checker) Checks() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.checks
+}
+
+// requireStatus checks the serving status of the 'service'.
+func requireStatus(
+	t *testing.T,
+	client gh.HealthClient,
+	service string,
+	expected gh.HealthCheckResponse_ServingStatus,
+) {
+	response, err := client.Check(context.Background(), &gh.HealthCheckRequest{Service: service})
+	require.NoError(t, err)
+	require.Equal(t, expected, response.GetStatus(), service)
+}
+
+func testNotServingDuringStartup(t *testing.T, h *Health, client gh.HealthClient, storage *checker, server *statusServer) {
+	h.Probe(context.Background())
+
+	requireStatus(t, client, "", gh.HealthCheckResponse_NOT_SERVING)
+	requireStatus(t, client, serviceStorage, gh.HealthCheckResponse_NOT_SERVING)
+	requireStatus(t, client, serviceCache, gh.HealthCheckResponse_NOT_SERVING)
+}
+
+func testServesHealthyComponents(t *testing.T, h *Health, client gh.HealthClient, storage *checker, server *statusServer) {
+	h.Ready()
+
+	requireStatus(t, client, "", gh.HealthCheckResponse_SERVING)
+	requireStatus(t, client, serviceStorage, gh.HealthCheckResponse_SERVING)
+	requireStatus(t, client, serviceCache, gh.HealthCheckResponse_SERVING)
+}
+
+func testReportsUnhealthyComponent(t *testing.T, h *Health, client gh.HealthClient, storage *checker, server *statusServer) {
+	h.Ready()
+	storage.Fail(errUnavailable)
+	h.Probe(context.Background())
+
+	requireStatus(t, client, "", gh.HealthCheckResponse_NOT_SERVING)
+	requireStatus(t, client, serviceStorage, gh.HealthCheckResponse_NOT_SERVING)
+	requireStatus(t, client, serviceCache, gh.HealthCheckResponse_SERVING)
+}
+
+func testRecoversHealthyComponent(t *testing.T, h *Health, client gh.HealthClient, storage *checker, server *statusServer) {
+	storage.Fail(errUnavailable)
+	h.Ready()
+	storage.Fail(nil)
+	h.Probe(context.Background())
+
+	requireStatus(t, client, "", gh.HealthCheckResponse_SERVING)
+	requireStatus(t, client, serviceStorage, gh.HealthCheckResponse_SERVING)
+}
+
+func testTimesOutSlowComponent(t *testing.T, h *Health, client gh.HealthClient, storage *checker, server *statusServer) {
+	storage.Slow()
+	h.Ready()
+
+	requireStatus(t, client, "", gh.HealthCheckResponse_NOT_SERVING)
+	requireStatus(t, client, serviceStorage, gh.HealthCheckResponse_NOT_SERVING)
+	require.ErrorIs(t, h.Status()[serviceStorage], context.DeadlineExceeded)
+}
+
+func testNotServingDuringShutdown(t *testing.T, h *Health, client gh.HealthClient, storage *checker, server *statusServer) {
+	h.Ready()
+	h.Shutdown()
+	h.Probe(context.Background())
+
+	requireStatus(t, client, "", gh.HealthCheckResponse_NOT_SERVING)
+	requireStatus(t, client, serviceStorage, gh.HealthCheckResponse_NOT_SERVING)
+	requireStatus(t, client, serviceCache, gh.HealthCheckResponse_NOT_SERVING)
+}
+
+func testStreamsStatusChanges(t *testing.T, h *Health, client gh.HealthClient, storage *checker, server *statusServer) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	stream, err := client.Watch(ctx, &gh.HealthCheckRequest{Service: serviceStorage})
+	require.NoError(t, err)
+
+	next := func() gh.HealthCheckResponse_ServingStatus {
+		response, err := stream.Recv()
+		require.NoError(t, err)
+		return response.GetStatus()
+	}
+
+	require.Equal(t, gh.HealthCheckResponse_NOT_SERVING, next())
+
+	h.Ready()
+	require.Equal(t, gh.HealthCheckResponse_SERVING, next())
+
+	storage.Fail(errUnavailable)
+	h.Probe(ctx)
+	require.Equal(t, gh.HealthCheckResponse_NOT_SERVING, next())
+
+	storage.Fail(nil)
+	h.Probe(ctx)
+	require.Equal(t, gh.HealthCheckResponse_SERVING, next())
+
+	h.Shutdown()
+	require.Equal(t, gh.HealthCheckResponse_NOT_SERVING, next())
+}
+
+func testProbesComponentsPeriodically(t *testing.T, h *Health, client gh.HealthClient, storage *checker, server *statusServer) {
+	h.Ready()
+	go h.Run()
+
+	storage.Fail(errUnavailable)
+	require.Eventually(t, func() bool {
+		response, err := client.Check(context.Background(), &gh.HealthCheckRequest{Service: serviceStorage})
+		return err == nil && response.GetStatus() == gh.HealthCheckResponse_NOT_SERVING
+	}, time.Second, 5*time.Millisecond)
+
+	require.NoError(t, h.Stop(context.Background()))
+
+	checks := storage.Checks()
+	time.Sleep(50 * time.Millisecond)
+	require.Equal(t, checks, storage.Checks())
+}
+
+func testFailsOnUnknownService(t *testing.T, h *Health, client gh.HealthClient, storage *checker, server *statusServer) {
+	h.Ready()
+
+	_, err := client.Check(context.Background(), &gh.HealthCheckRequest{Service: "unknown"})
+	require.Equal(t, codes.NotFound, status.Code(err))
+}
+
+func testFailsOnDuplicateService(t *testing.T, h *Health, client gh.HealthClient, storage *checker, server *statusServer) {
+	require.ErrorIs(t, h.Register(serviceStorage, &checker{}), ErrDuplicateService)
+}
+
+func testFailsOnServiceWithoutName(t *testing.T, h *Health, client gh.HealthClient, storage *checker, server *statusServer) {
+	require.ErrorIs(t, h.Register("", &checker{}), ErrInvalidService)
+}
+
+func testLiveDuringStartupAndFailures(t *testing.T, h *Health, client gh.HealthClient, storage *checker, server *statusServer) {
+	requireStatus(t, client, ServiceLiveness, gh.HealthCheckResponse_SERVING)
+
+	storage.Fail(errUnavailable)
+	h.Ready()
+	requireStatus(t, client, ServiceLiveness, gh.HealthCheckResponse_SERVING)
+
+	// The process is not restarted while it drains the requests
+	h.Shutdown()
+	requireStatus(t, client, ServiceLiveness, gh.HealthCheckResponse_SERVING)
+}
+
+func testFailsOnLivenessService(t *testing.T, h *Health, client gh.HealthClient, storage *checker, server *statusServer) {
+	require.ErrorIs(t, h.Register(ServiceLiveness, &checker{}), ErrInvalidService)
+}
+
+func testListsServices(t *testing.T, h *Health, client gh.HealthClient, storage *checker, server *statusServer) {
+	require.Equal(t, []string{serviceCache, serviceStorage}, h.Services())
+}
+
+func testReportsFailuresOfNotReady(t *testing.T, h *Health, client gh.HealthClient, storage *checker, server *statusServer) {
+	h.Probe(context.Background())
+
+	require.Equal(t, map[string]error{
+		serviceStorage: ErrNotReady,
+		serviceCache:   ErrNotReady,
+	}, h.Status())
+}
+
+func testReportsFailuresOfUnhealthy(t *testing.T, h *Health, client gh.HealthClient, storage *checker, server *statusServer) {
+	storage.Fail(errUnavailable)
+	h.Ready()
+
+	require.Equal(t, map[string]error{serviceStorage: errUnavailable}, h.Status())
+}
+
+func testReportsNoFailuresWhenHealthy(t *testing.T, h *Health, client gh.HealthClient, storage *checker, server *statusServer) {
+	h.Ready()
+
+	require.Empty(t, h.Status())
+}
+
+func testFailsIfNoLogger(t *testing.T, log logger.Log) {
+	_, err := NewHealth(nil, Config{CheckInterval: time.Second, CheckTimeout: time.Second})
+	require.ErrorIs(t, err, ErrNoLoggerProvided)
+}
+
+func testFailsIfNoInterval(t *testing.T, log logger.Log) {
+	_, err := NewHealth(log, Config{CheckTimeout: time.Second})
+	require.ErrorIs(t, err, ErrInvalidCheckInterval)
+}
+
+func testFailsIfNoTimeout(t *testing.T, log logger.Log) {
+	_, err := NewHealth(log, Config{CheckInterval: time.Second})
+	require.ErrorIs(t, err, ErrInvalidCheckInterval)
+}
+
+func testFailsIfNoHealthForServer(t *testing.T, log logger.Log) {
+	_, err := NewStatusServer(Config{}, nil, nil)
+	require.ErrorIs(t, err, ErrNoHealthProvided)
+}
+
+func testEndsStreamsOnStop(t *testing.T, h *Health, client gh.HealthClient, storage *checker, server *statusServer) {
+	stream, err := client.Watch(context.Background(), &gh.HealthCheckRequest{Service: serviceStorage})
+	require.NoError(t, err)
+
+	_, err = stream.Recv()
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	require.NoError(t, server.Stop(ctx))
+
+	_, err = stream.Recv()
+	require.Error(t, err)
+}
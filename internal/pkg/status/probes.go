@@ -0,0 +1,146 @@
+package status
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+const (
+
+	// PathLivez is the HTTP liveness probe, that passes while the process is able to respond.
+	PathLivez = "/livez"
+
+	// PathReadyz is the HTTP readiness probe, that passes while the service is
+	// started, isn't shutting down and all the components are healthy.
+	PathReadyz = "/readyz"
+
+	// PathStartupz is the HTTP startup probe, that passes once the startup is completed.
+	PathStartupz = "/startupz"
+
+	// Names of the probe checks that are not the components
+	checkPing     = "ping"
+	checkStartup  = "startup"
+	checkShutdown = "shutdown"
+)
+
+var (
+	// ErrNotStarted happens when the startup is not completed yet.
+	ErrNotStarted = errors.New("startup is not completed")
+
+	// ErrShuttingDown happens when the shutdown has started.
+	ErrShuttingDown = errors.New("shutdown has started")
+)
+
+// check is the result of a single check of the probe.
+type check struct {
+	name string
+	err  error
+}
+
+// probeHandler is an HTTP health probe of the health registry.
+//
+// The probe responds with 200 if all its checks pass, or with 503 and
+// the failed checks otherwise. The 'verbose' query parameter lists
+// all the checks even if they pass.
+type probeHandler struct {
+	name   string
+	checks func() []check
+}
+
+// NewProbeHandlers creates the HTTP liveness, readiness and startup
+// probes of the 'health' registry by the path they should be exposed on.
+func NewProbeHandlers(health *Health) (map[string]http.Handler, error) {
+	if health == nil {
+		return nil, ErrNoHealthProvided
+	}
+
+	return map[string]http.Handler{
+		PathLivez:    &probeHandler{name: "livez", checks: health.liveness},
+		PathReadyz:   &probeHandler{name: "readyz", checks: health.readiness},
+		PathStartupz: &probeHandler{name: "startupz", checks: health.startup},
+	}, nil
+}
+
+// ServeHTTP runs the checks of the probe and writes their results.
+func (p *probeHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	_, verbose := r.URL.Query()["verbose"]
+
+	checks := p.checks()
+	failed := false
+	for _, c := range checks {
+		failed = failed || c.err != nil
+	}
+
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+
+	if !failed && !verbose {
+		w.WriteHeader(http.StatusOK)
+		fmt.Fprint(w, "ok")
+		return
+	}
+
+	var b strings.Builder
+	for _, c := range checks {
+		if c.err != nil {
+			fmt.Fprintf(&b, "[-]%s failed: %s\n", c.name, c.err)
+		} else {
+			fmt.Fprintf(&b, "[+]%s ok\n", c.name)
+		}
+	}
+
+	if failed {
+		fmt.Fprintf(&b, "%s check failed\n", p.name)
+		w.WriteHeader(http.StatusServiceUnavailable)
+	} else {
+		fmt.Fprintf(&b, "%s check passed\n", p.name)
+		w.WriteHeader(http.StatusOK)
+	}
+
+	fmt.Fprint(w, b.String())
+}
+
+// liveness returns the checks of the liveness probe. The components
+// are not checked, because restarting the service doesn't fix them.
+func (h *Health) liveness() []check {
+	return []check{{name: checkPing}}
+}
+
+// startup returns the checks of the startup probe.
+func (h *Health) startup() []check {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	return []check{h.startupCheck()}
+}
+
+// readiness returns the checks of the readiness probe,
+// the components are ordered by their service names.
+func (h *Health) readiness() []check {
+	services := h.Services()
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	checks := []check{h.startupCheck(), {name: checkShutdown}}
+	if h.shutdown {
+		checks[1].err = ErrShuttingDown
+	}
+
+	// The components that haven't been probed yet are not ready
+	for _, service := range services {
+		checks = append(checks, check{name: service, err: h.failures[service]})
+	}
+
+	return checks
+}
+
+// startupCheck returns the check of the completed startup.
+func (h *Health) startupCheck() check {
+	if !h.ready {
+		return check{name: checkStartup, err: ErrNotStarted}
+	}
+	return check{name: checkStartup}
+}
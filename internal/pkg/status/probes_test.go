@@ -0,0 +1,158 @@
+package status
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"git.lothric.net/examples/go/gogin/internal/pkg/logger"
+)
+
+func TestProbes(t *testing.T) {
+	for scenario, fn := range map[string]func(
+		t *testing.T,
+		h *Health,
+		probes map[string]http.Handler,
+		storage *checker,
+	){
+		"live during startup":             testLiveDuringStartup,
+		"not started during startup":      testNotStartedDuringStartup,
+		"not ready during startup":        testNotReadyDuringStartup,
+		"started after startup":           testStartedAfterStartup,
+		"ready after startup":             testReadyAfterStartup,
+		"not ready if component fails":    testNotReadyIfComponentFails,
+		"live if component fails":         testLiveIfComponentFails,
+		"not ready during shutdown":       testNotReadyDuringShutdown,
+		"lists checks if verbose":         testListsChecksIfVerbose,
+		"lists checks of live if verbose": testListsChecksOfLiveIfVerbose,
+	} {
+		t.Run(scenario, func(t *testing.T) {
+			log, _ := logger.NewNullLogger()
+
+			health, err := NewHealth(log, Config{
+				CheckInterval: time.Second,
+				CheckTimeout:  time.Second,
+			})
+			require.NoError(t, err)
+
+			storage := &checker{}
+			require.NoError(t, health.Register(serviceStorage, storage))
+			require.NoError(t, health.Register(serviceCache, &checker{}))
+
+			probes, err := NewProbeHandlers(health)
+			require.NoError(t, err)
+
+			fn(t, health, probes, storage)
+		})
+	}
+}
+
+func TestProbesFailIfNoHealth(t *testing.T) {
+	_, err := NewProbeHandlers(nil)
+	require.ErrorIs(t, err, ErrNoHealthProvided)
+}
+
+// probe requests the probe on the 'path' with the 'query'
+// and returns the response status code and body.
+func probe(t *testing.T, probes map[string]http.Handler, path string, query string) (int, string) {
+	handler, ok := probes[path]
+	require.True(t, ok, path)
+
+	recorder := httptest.NewRecorder()
+	handler.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, path+query, nil))
+
+	require.Equal(t, "text/plain; charset=utf-8", recorder.Header().Get("Content-Type"))
+	return recorder.Code, recorder.Body.String()
+}
+
+func testLiveDuringStartup(t *testing.T, h *Health, probes map[string]http.Handler, storage *checker) {
+	code, body := probe(t, probes, PathLivez, "")
+	require.Equal(t, http.StatusOK, code)
+	require.Equal(t, "ok", body)
+}
+
+func testNotStartedDuringStartup(t *testing.T, h *Health, probes map[string]http.Handler, storage *checker) {
+	code, body := probe(t, probes, PathStartupz, "")
+	require.Equal(t, http.StatusServiceUnavailable, code)
+	require.Equal(t, "[-]startup failed: startup is not completed\nstartupz check failed\n", body)
+}
+
+func testNotReadyDuringStartup(t *testing.T, h *Health, probes map[string]http.Handler, storage *checker) {
+	code, body := probe(t, probes, PathReadyz, "")
+	require.Equal(t, http.StatusServiceUnavailable, code)
+	require.Equal(t, "[-]startup failed: startup is not completed\n"+
+		"[+]shutdown ok\n"+
+		"[-]cache failed: service is not ready\n"+
+		"[-]storage failed: service is not ready\n"+
+		"readyz check failed\n", body)
+}
+
+func testStartedAfterStartup(t *testing.T, h *Health, probes map[string]http.Handler, storage *checker) {
+	h.Ready()
+
+	code, body := probe(t, probes, PathStartupz, "")
+	require.Equal(t, http.StatusOK, code)
+	require.Equal(t, "ok", body)
+}
+
+func testReadyAfterStartup(t *testing.T, h *Health, probes map[string]http.Handler, storage *checker) {
+	h.Ready()
+
+	code, body := probe(t, probes, PathReadyz, "")
+	require.Equal(t, http.StatusOK, code)
+	require.Equal(t, "ok", body)
+}
+
+func testNotReadyIfComponentFails(t *testing.T, h *Health, probes map[string]http.Handler, storage *checker) {
+	storage.Fail(errUnavailable)
+	h.Ready()
+
+	code, body := probe(t, probes, PathReadyz, "")
+	require.Equal(t, http.StatusServiceUnavailable, code)
+	require.Equal(t, "[+]startup ok\n"+
+		"[+]shutdown ok\n"+
+		"[+]cache ok\n"+
+		"[-]storage failed: unavailable\n"+
+		"readyz check failed\n", body)
+}
+
+func testLiveIfComponentFails(t *testing.T, h *Health, probes map[string]http.Handler, storage *checker) {
+	storage.Fail(errUnavailable)
+	h.Ready()
+
+	code, _ := probe(t, probes, PathLivez, "")
+	require.Equal(t, http.StatusOK, code)
+}
+
+func testNotReadyDuringShutdown(t *testing.T, h *Health, probes map[string]http.Handler, storage *checker) {
+	h.Ready()
+	h.Shutdown()
+
+	code, body := probe(t, probes, PathReadyz, "")
+	require.Equal(t, http.StatusServiceUnavailable, code)
+	require.Contains(t, body, "[-]shutdown failed: shutdown has started\n")
+
+	code, _ = probe(t, probes, PathStartupz, "")
+	require.Equal(t, http.StatusOK, code)
+}
+
+func testListsChecksIfVerbose(t *testing.T, h *Health, probes map[string]http.Handler, storage *checker) {
+	h.Ready()
+
+	code, body := probe(t, probes, PathReadyz, "?verbose")
+	require.Equal(t, http.StatusOK, code)
+	require.Equal(t, "[+]startup ok\n"+
+		"[+]shutdown ok\n"+
+		"[+]cache ok\n"+
+		"[+]storage ok\n"+
+		"readyz check passed\n", body)
+}
+
+func testListsChecksOfLiveIfVerbose(t *testing.T, h *Health, probes map[string]http.Handler, storage *checker) {
+	code, body := probe(t, probes, PathLivez, "?verbose=1")
+	require.Equal(t, http.StatusOK, code)
+	require.Equal(t, "[+]ping ok\nlivez check passed\n", body)
+}
@@ -1,12 +1,18 @@
 package status
 
 import (
+	"context"
+	"crypto/tls"
 	"net"
+	"sync"
+	"time"
 
 	"google.golang.org/grpc"
-	"google.golang.org/grpc/health"
+	"google.golang.org/grpc/credentials"
 
 	gh "google.golang.org/grpc/health/grpc_health_v1"
+
+	"git.lothric.net/examples/go/gogin/internal/pkg/tlsconfig"
 )
 
 // Config defines the configuration for status server.
@@ -14,46 +20,134 @@ type Config struct {
 
 	// RpcAddr is the endpoint the status server is listening.
 	RpcAddr string
+
+	// CheckInterval is the interval between the health checks of the components.
+	// For example: 10s
+	CheckInterval time.Duration
+
+	// CheckTimeout is the timeout of the health check of a component.
+	// For example: 1s
+	CheckTimeout time.Duration
+
+	// TLS is the TLS configuration of the status server.
+	TLS tlsconfig.Config
 }
 
 // statusServer is grpc-based status server that exposes
 // service status via grpc on a particular port and is expected
 // to be called by `grpc_health_probe`.
 type statusServer struct {
-	server *grpc.Server
+	server   *grpc.Server
+	config   Config
+	listener net.Listener
+
+	// stopping is closed when the server stops, so the
+	// watch streams that never finish on their own end
+	stopping     chan struct{}
+	stoppingOnce sync.Once
 }
 
 // NewStatusServer creates a new health check status server
 // that integrates with `grpc_health_probe` and responds on
 // readiness and liveliness health probe checks.
-func NewStatusServer() (*statusServer, error) {
+//
+// The server reports the statuses of the 'health' registry,
+// both the overall service "" and the individual components.
+// The server uses TLS if the 'tlsConfig' is provided.
+func NewStatusServer(config Config, health *Health, tlsConfig *tls.Config) (*statusServer, error) {
+	if health == nil {
+		return nil, ErrNoHealthProvided
+	}
+
+	s := &statusServer{
+		config:   config,
+		stopping: make(chan struct{}),
+	}
+
+	options := []grpc.ServerOption{grpc.StreamInterceptor(s.endOnStop)}
+	if tlsConfig != nil {
+		options = append(options, grpc.Creds(credentials.NewTLS(tlsConfig)))
+	}
+
+	s.server = grpc.NewServer(options...)
+	gh.RegisterHealthServer(s.server, health.server)
+
+	return s, nil
+}
 
-	healthServer := health.NewServer()
-	healthServer.SetServingStatus("", gh.HealthCheckResponse_SERVING)
+// endOnStop cancels the context of the stream once the server stops.
+func (s *statusServer) endOnStop(
+	srv interface{},
+	stream grpc.ServerStream,
+	info *grpc.StreamServerInfo,
+	handler grpc.StreamHandler,
+) error {
 
-	grpcServer := grpc.NewServer()
-	gh.RegisterHealthServer(grpcServer, healthServer)
+	ctx, cancel := context.WithCancel(stream.Context())
+	defer cancel()
 
-	return &statusServer{
-		server: grpcServer,
-	}, nil
+	go func() {
+		select {
+		case <-s.stopping:
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+
+	return handler(srv, &serverStream{ServerStream: stream, ctx: ctx})
+}
+
+// serverStream is a server stream with the overridden context.
+type serverStream struct {
+	grpc.ServerStream
+	ctx context.Context
 }
 
-// Serve bootstrap the status server.
-func (s *statusServer) Serve(config Config) error {
-	ln, err := net.Listen("tcp", config.RpcAddr)
+// Context returns the context of the stream.
+func (s *serverStream) Context() context.Context {
+	return s.ctx
+}
+
+// Listen binds the listener of the status server.
+func (s *statusServer) Listen() error {
+	ln, err := net.Listen("tcp", s.config.RpcAddr)
 	if err != nil {
 		return err
 	}
 
-	if err := s.server.Serve(ln); err != nil {
-		return err
+	s.listener = ln
+	return nil
+}
+
+// Serve bootstrap the status server, the listener
+// is bound first if it hasn't been bound yet.
+func (s *statusServer) Serve() error {
+	if s.listener == nil {
+		if err := s.Listen(); err != nil {
+			return err
+		}
 	}
 
-	return nil
+	return s.server.Serve(s.listener)
 }
 
-// Stop terminates the status server.
-func (s *statusServer) Stop() {
-	s.server.Stop()
+// Stop terminates the status server, it ends the watch streams
+// and waits for the pending health checks to finish.
+// The server is stopped forcibly once the 'ctx' is done.
+func (s *statusServer) Stop(ctx context.Context) error {
+	s.stoppingOnce.Do(func() { close(s.stopping) })
+
+	stopped := make(chan struct{})
+	go func() {
+		s.server.GracefulStop()
+		close(stopped)
+	}()
+
+	select {
+	case <-stopped:
+		return nil
+	case <-ctx.Done():
+		s.server.Stop()
+		return ctx.Err()
+	}
 }
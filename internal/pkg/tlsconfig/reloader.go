@@ -0,0 +1,290 @@
+package tlsconfig
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	"git.lothric.net/examples/go/gogin/internal/pkg/lifecycle"
+	"git.lothric.net/examples/go/gogin/internal/pkg/logger"
+)
+
+var (
+	// ErrNoLoggerProvided happens when logger is not provided.
+	ErrNoLoggerProvided = errors.New("no logger provided")
+
+	// ErrNoCertificateProvided happens when either the certificate or the key file is not provided.
+	ErrNoCertificateProvided = errors.New("both TLS certificate and key files should be provided")
+
+	// ErrUnknownVersion happens when the minimal TLS version is not supported.
+	ErrUnknownVersion = errors.New("unknown TLS version")
+
+	// ErrUnknownCipherSuite happens when the cipher suite is unknown or insecure.
+	ErrUnknownCipherSuite = errors.New("unknown TLS cipher suite")
+
+	// ErrInvalidClientCA happens when the client CA file has no certificates.
+	ErrInvalidClientCA = errors.New("invalid TLS client CA")
+
+	// ErrInvalidReloadInterval happens when the reload interval is not positive.
+	ErrInvalidReloadInterval = errors.New("invalid TLS reload interval")
+)
+
+// versions are the supported minimal TLS versions.
+var versions = map[string]uint16{
+	"1.2": tls.VersionTLS12,
+	"1.3": tls.VersionTLS13,
+}
+
+// Config is a TLS configuration of the listener.
+type Config struct {
+
+	// CertFile is the path of the PEM encoded certificate chain,
+	// TLS is disabled if it is empty.
+	// For example: "/etc/gogin/tls/tls.crt"
+	CertFile string
+
+	// KeyFile is the path of the PEM encoded private key of the certificate.
+	// For example: "/etc/gogin/tls/tls.key"
+	KeyFile string
+
+	// ClientCAFile is the path of the PEM encoded CA certificates, that
+	// the client certificates are verified with. The clients are required
+	// to provide the certificate (mutual TLS) if it is not empty.
+	// For example: "/etc/gogin/tls/ca.crt"
+	ClientCAFile string
+
+	// MinVersion is the minimal TLS version: "1.2" or "1.3".
+	MinVersion string
+
+	// CipherSuites are the names of the TLS 1.2 cipher suites,
+	// the secure Go defaults are used if it is empty.
+	// For example: "TLS_ECDHE_ECDSA_WITH_AES_128_GCM_SHA256"
+	CipherSuites []string
+
+	// ReloadInterval is the interval between the checks of the files changes.
+	// For example: 10s
+	ReloadInterval time.Duration
+}
+
+// Enabled returns true if TLS is configured.
+func (c Config) Enabled() bool {
+	return c.CertFile != "" || c.KeyFile != ""
+}
+
+// Reloader provides the TLS configuration of the listener, and reloads
+// the certificate and the client CA once their files have changed,
+// so the certificates could be rotated without the restart.
+type Reloader struct {
+	log          logger.Log
+	config       Config
+	minVersion   uint16
+	cipherSuites []uint16
+
+	// files are the contents of the loaded files, and rejected
+	// are the contents of the invalid files that were reported already
+	mu       sync.RWMutex
+	files    [][]byte
+	rejected [][]byte
+	current  *tls.Config
+
+	periodic *lifecycle.Periodic
+}
+
+// NewReloader creates a new reloader of the TLS 'config',
+// the files are loaded right away and should be valid.
+func NewReloader(log logger.Log, config Config) (*Reloader, error) {
+	if log == nil {
+		return nil, ErrNoLoggerProvided
+	}
+
+	if config.CertFile == "" || config.KeyFile == "" {
+		return nil, ErrNoCertificateProvided
+	}
+
+	if config.ReloadInterval <= 0 {
+		return nil, fmt.Errorf("%w: %s", ErrInvalidReloadInterval, config.ReloadInterval)
+	}
+
+	minVersion, err := parseVersion(config.MinVersion)
+	if err != nil {
+		return nil, err
+	}
+
+	cipherSuites, err := parseCipherSuites(config.CipherSuites)
+	if err != nil {
+		return nil, err
+	}
+
+	r := &Reloader{
+		log:          log.WithField(logger.FieldPackage, "tlsconfig"),
+		config:       config,
+		minVersion:   minVersion,
+		cipherSuites: cipherSuites,
+	}
+	r.periodic = lifecycle.NewPeriodic(config.ReloadInterval, r.reload)
+
+	if err := r.Reload(); err != nil {
+		return nil, err
+	}
+
+	return r, nil
+}
+
+// TLSConfig returns the TLS configuration of the listener,
+// that always uses the latest loaded certificate and client CA.
+func (r *Reloader) TLSConfig() *tls.Config {
+	return &tls.Config{
+		MinVersion:   r.minVersion,
+		CipherSuites: r.cipherSuites,
+		GetConfigForClient: func(*tls.ClientHelloInfo) (*tls.Config, error) {
+			r.mu.RLock()
+			defer r.mu.RUnlock()
+			return r.current, nil
+		},
+	}
+}
+
+// Reload loads the certificate and the client CA if their files have changed.
+// The current configuration is kept if the files are not valid, the same
+// invalid files are reported only once.
+func (r *Reloader) Reload() error {
+	paths := []string{r.config.CertFile, r.config.KeyFile}
+	if r.config.ClientCAFile != "" {
+		paths = append(paths, r.config.ClientCAFile)
+	}
+
+	files := make([][]byte, len(paths))
+	for i, path := range paths {
+		content, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		files[i] = content
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if equal(files, r.files) || equal(files, r.rejected) {
+		return nil
+	}
+
+	current, err := r.parse(files)
+	if err != nil {
+		r.rejected = files
+		return err
+	}
+
+	r.files = files
+	r.rejected = nil
+	r.current = current
+
+	log := r.log.WithField(logger.FieldFunction, "Reload")
+	log.Info("Loaded TLS certificate ", r.config.CertFile)
+
+	return nil
+}
+
+// parse creates the TLS configuration from the certificate, key and client CA 'files'.
+func (r *Reloader) parse(files [][]byte) (*tls.Config, error) {
+	certificate, err := tls.X509KeyPair(files[0], files[1])
+	if err != nil {
+		return nil, err
+	}
+
+	config := &tls.Config{
+		MinVersion:   r.minVersion,
+		CipherSuites: r.cipherSuites,
+		Certificates: []tls.Certificate{certificate},
+
+		// The configuration replaces the listener's one,
+		// so it has to offer HTTP/2 for gRPC on its own
+		NextProtos: []string{"h2", "http/1.1"},
+	}
+
+	if len(files) > 2 {
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(files[2]) {
+			return nil, ErrInvalidClientCA
+		}
+
+		config.ClientCAs = pool
+		config.ClientAuth = tls.RequireAndVerifyClientCert
+	}
+
+	return config, nil
+}
+
+// Run reloads the files on every interval, until the reloader is stopped.
+func (r *Reloader) Run() error {
+	return r.periodic.Run()
+}
+
+// Stop stops the reloading.
+func (r *Reloader) Stop(ctx context.Context) error {
+	return r.periodic.Stop(ctx)
+}
+
+// reload reloads the files and logs the failures,
+// the current configuration is kept until the next interval.
+func (r *Reloader) reload(context.Context) {
+	log := r.log.WithField(logger.FieldFunction, "reload")
+
+	if err := r.Reload(); err != nil {
+		log.Error(err, "Failed to reload TLS certificate ", r.config.CertFile)
+	}
+}
+
+// parseVersion parses the minimal TLS version, TLS 1.2 by default.
+func parseVersion(version string) (uint16, error) {
+	if version == "" {
+		return tls.VersionTLS12, nil
+	}
+
+	v, ok := versions[version]
+	if !ok {
+		return 0, fmt.Errorf("%w: %s", ErrUnknownVersion, version)
+	}
+	return v, nil
+}
+
+// parseCipherSuites parses the names of the secure cipher suites.
+func parseCipherSuites(names []string) ([]uint16, error) {
+	if len(names) == 0 {
+		return nil, nil
+	}
+
+	known := make(map[string]uint16)
+	for _, suite := range tls.CipherSuites() {
+		known[suite.Name] = suite.ID
+	}
+
+	suites := make([]uint16, 0, len(names))
+	for _, name := range names {
+		id, ok := known[name]
+		if !ok {
+			return nil, fmt.Errorf("%w: %s", ErrUnknownCipherSuite, name)
+		}
+		suites = append(suites, id)
+	}
+	return suites, nil
+}
+
+// equal returns true if the files have the same content.
+func equal(a [][]byte, b [][]byte) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if !bytes.Equal(a[i], b[i]) {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,390 @@
+package tlsconfig
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"git.lothric.net/examples/go/gogin/internal/pkg/logger"
+)
+
+func TestReloader(t *testing.T) {
+	for scenario, fn := range map[string]func(t *testing.T, log logger.Log, ca *authority, dir string){
+		"serves certificate":               testServesCertificate,
+		"reloads changed certificate":      testReloadsChangedCertificate,
+		"keeps certificate if invalid":     testKeepsCertificateIfInvalid,
+		"reports invalid files once":       testReportsInvalidFilesOnce,
+		"reloads periodically":             testReloadsPeriodically,
+		"requires client certificate":      testRequiresClientCertificate,
+		"verifies client certificate":      testVerifiesClientCertificate,
+		"reloads client CA":                testReloadsClientCA,
+		"enforces min version":             testEnforcesMinVersion,
+		"fails if no logger":               testFailsIfNoLogger,
+		"fails if no key":                  testFailsIfNoKey,
+		"fails if no reload interval":      testFailsIfNoReloadInterval,
+		"fails if unknown version":         testFailsIfUnknownVersion,
+		"fails if unknown cipher suite":    testFailsIfUnknownCipherSuite,
+		"fails if insecure cipher suite":   testFailsIfInsecureCipherSuite,
+		"fails if invalid client CA":       testFailsIfInvalidClientCA,
+		"fails if certificate is missing":  testFailsIfCertificateIsMissing,
+		"is enabled if certificate is set": testIsEnabledIfCertificateIsSet,
+	} {
+		t.Run(scenario, func(t *testing.T) {
+			log, _ := logger.NewNullLogger()
+			fn(t, log, newAuthority(t, "ca"), t.TempDir())
+		})
+	}
+}
+
+// authority is a test certificate authority.
+type authority struct {
+	cert *x509.Certificate
+	key  *ecdsa.PrivateKey
+	pem  []byte
+}
+
+func newAuthority(t *testing.T, name string) *authority {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: name},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+		KeyUsage:              x509.KeyUsageCertSign,
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	require.NoError(t, err)
+
+	cert, err := x509.ParseCertificate(der)
+	require.NoError(t, err)
+
+	return &authority{
+		cert: cert,
+		key:  key,
+		pem:  pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}),
+	}
+}
+
+// issue issues the certificate of the 'name' and returns its PEM encoded certificate and key.
+func (a *authority) issue(t *testing.T, name string, usage x509.ExtKeyUsage) ([]byte, []byte) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	serial, err := rand.Int(rand.Reader, big.NewInt(1<<62))
+	require.NoError(t, err)
+
+	template := &x509.Certificate{
+		SerialNumber: serial,
+		Subject:      pkix.Name{CommonName: name, Organization: []string{"gogin"}},
+		DNSNames:     []string{name},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{usage},
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, template, a.cert, &key.PublicKey, a.key)
+	require.NoError(t, err)
+
+	keyDer, err := x509.MarshalECPrivateKey(key)
+	require.NoError(t, err)
+
+	return pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}),
+		pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDer})
+}
+
+// writeServer issues the server certificate of the 'name' and writes it to the 'dir'.
+func (a *authority) writeServer(t *testing.T, dir string, name string) Config {
+	cert, key := a.issue(t, name, x509.ExtKeyUsageServerAuth)
+
+	config := Config{
+		CertFile:       filepath.Join(dir, "tls.crt"),
+		KeyFile:        filepath.Join(dir, "tls.key"),
+		ReloadInterval: time.Hour,
+	}
+	require.NoError(t, os.WriteFile(config.CertFile, cert, 0o600))
+	require.NoError(t, os.WriteFile(config.KeyFile, key, 0o600))
+
+	return config
+}
+
+// writeClientCA writes the CA that verifies the client certificates to the 'dir'.
+func (a *authority) writeClientCA(t *testing.T, dir string, config Config) Config {
+	config.ClientCAFile = filepath.Join(dir, "ca.crt")
+	require.NoError(t, os.WriteFile(config.ClientCAFile, a.pem, 0o600))
+	return config
+}
+
+// client creates the client TLS configuration, that trusts the 'ca'
+// and presents the client certificate of the 'name', if it is not empty.
+func (a *authority) client(t *testing.T, name string) *tls.Config {
+	pool := x509.NewCertPool()
+	pool.AddCert(a.cert)
+
+	config := &tls.Config{RootCAs: pool, ServerName: "server"}
+	if name != "" {
+		cert, key := a.issue(t, name, x509.ExtKeyUsageClientAuth)
+		certificate, err := tls.X509KeyPair(cert, key)
+		require.NoError(t, err)
+		config.Certificates = []tls.Certificate{certificate}
+	}
+	return config
+}
+
+// handshake performs the TLS handshake of the 'client' with the 'server' and
+// returns the server certificate subject and the client certificate subject
+// as seen by the server.
+func handshake(server *tls.Config, client *tls.Config) (string, string, error) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return "", "", err
+	}
+	defer ln.Close()
+
+	type result struct {
+		peer string
+		err  error
+	}
+	results := make(chan result, 1)
+	go func() {
+		conn, err := ln.Accept()
+		if err != nil {
+			results <- result{err: err}
+			return
+		}
+		defer conn.Close()
+
+		s := tls.Server(conn, server)
+		if err := s.Handshake(); err != nil {
+			results <- result{err: err}
+			return
+		}
+
+		peer := ""
+		if certs := s.ConnectionState().PeerCertificates; len(certs) > 0 {
+			peer = certs[0].Subject.CommonName
+		}
+		results <- result{peer: peer}
+	}()
+
+	c, err := tls.Dial("tcp", ln.Addr().String(), client)
+	if err != nil {
+		return "", "", err
+	}
+	defer c.Close()
+
+	// TLS 1.3 reports the client certificate failures after the client handshake
+	r := <-results
+	if r.err != nil {
+		return "", "", r.err
+	}
+
+	return c.ConnectionState().PeerCertificates[0].Subject.CommonName, r.peer, nil
+}
+
+func testServesCertificate(t *testing.T, log logger.Log, ca *authority, dir string) {
+	r, err := NewReloader(log, ca.writeServer(t, dir, "server"))
+	require.NoError(t, err)
+
+	server, peer, err := handshake(r.TLSConfig(), ca.client(t, ""))
+	require.NoError(t, err)
+	require.Equal(t, "server", server)
+	require.Empty(t, peer)
+}
+
+func testReloadsChangedCertificate(t *testing.T, log logger.Log, ca *authority, dir string) {
+	r, err := NewReloader(log, ca.writeServer(t, dir, "server"))
+	require.NoError(t, err)
+	config := r.TLSConfig()
+
+	// The rotated certificate is issued by the other CA
+	rotated := newAuthority(t, "rotated")
+	rotated.writeServer(t, dir, "server")
+	require.NoError(t, r.Reload())
+
+	_, _, err = handshake(config, ca.client(t, ""))
+	require.Error(t, err)
+
+	_, _, err = handshake(config, rotated.client(t, ""))
+	require.NoError(t, err)
+}
+
+func testKeepsCertificateIfInvalid(t *testing.T, log logger.Log, ca *authority, dir string) {
+	config := ca.writeServer(t, dir, "server")
+	r, err := NewReloader(log, config)
+	require.NoError(t, err)
+
+	require.NoError(t, os.WriteFile(config.KeyFile, []byte("invalid"), 0o600))
+	require.Error(t, r.Reload())
+
+	_, _, err = handshake(r.TLSConfig(), ca.client(t, ""))
+	require.NoError(t, err)
+}
+
+func testReportsInvalidFilesOnce(t *testing.T, log logger.Log, ca *authority, dir string) {
+	config := ca.writeServer(t, dir, "server")
+	r, err := NewReloader(log, config)
+	require.NoError(t, err)
+
+	require.NoError(t, os.WriteFile(config.KeyFile, []byte("invalid"), 0o600))
+	require.Error(t, r.Reload())
+	require.NoError(t, r.Reload())
+}
+
+func testReloadsPeriodically(t *testing.T, log logger.Log, ca *authority, dir string) {
+	config := ca.writeServer(t, dir, "server")
+	config.ReloadInterval = 10 * time.Millisecond
+	r, err := NewReloader(log, config)
+	require.NoError(t, err)
+
+	go r.Run()
+	defer r.Stop(context.Background())
+
+	rotated := newAuthority(t, "rotated")
+	rotated.writeServer(t, dir, "server")
+
+	require.Eventually(t, func() bool {
+		_, _, err := handshake(r.TLSConfig(), rotated.client(t, ""))
+		return err == nil
+	}, time.Second, 10*time.Millisecond)
+}
+
+func testRequiresClientCertificate(t *testing.T, log logger.Log, ca *authority, dir string) {
+	r, err := NewReloader(log, ca.writeClientCA(t, dir, ca.writeServer(t, dir, "server")))
+	require.NoError(t, err)
+
+	_, _, err = handshake(r.TLSConfig(), ca.client(t, ""))
+	require.Error(t, err)
+}
+
+func testVerifiesClientCertificate(t *testing.T, log logger.Log, ca *authority, dir string) {
+	r, err := NewReloader(log, ca.writeClientCA(t, dir, ca.writeServer(t, dir, "server")))
+	require.NoError(t, err)
+
+	_, peer, err := handshake(r.TLSConfig(), ca.client(t, "client"))
+	require.NoError(t, err)
+	require.Equal(t, "client", peer)
+
+	// The client certificate of the other CA is rejected
+	other := newAuthority(t, "other")
+	client := other.client(t, "client")
+	client.RootCAs = ca.client(t, "").RootCAs
+
+	_, _, err = handshake(r.TLSConfig(), client)
+	require.Error(t, err)
+}
+
+func testReloadsClientCA(t *testing.T, log logger.Log, ca *authority, dir string) {
+	config := ca.writeClientCA(t, dir, ca.writeServer(t, dir, "server"))
+	r, err := NewReloader(log, config)
+	require.NoError(t, err)
+
+	other := newAuthority(t, "other")
+	other.writeClientCA(t, dir, config)
+	require.NoError(t, r.Reload())
+
+	client := other.client(t, "client")
+	client.RootCAs = ca.client(t, "").RootCAs
+
+	_, peer, err := handshake(r.TLSConfig(), client)
+	require.NoError(t, err)
+	require.Equal(t, "client", peer)
+}
+
+func testEnforcesMinVersion(t *testing.T, log logger.Log, ca *authority, dir string) {
+	config := ca.writeServer(t, dir, "server")
+	config.MinVersion = "1.3"
+	r, err := NewReloader(log, config)
+	require.NoError(t, err)
+
+	client := ca.client(t, "")
+	client.MaxVersion = tls.VersionTLS12
+
+	_, _, err = handshake(r.TLSConfig(), client)
+	require.Error(t, err)
+}
+
+func testFailsIfNoLogger(t *testing.T, log logger.Log, ca *authority, dir string) {
+	_, err := NewReloader(nil, ca.writeServer(t, dir, "server"))
+	require.ErrorIs(t, err, ErrNoLoggerProvided)
+}
+
+func testFailsIfNoKey(t *testing.T, log logger.Log, ca *authority, dir string) {
+	config := ca.writeServer(t, dir, "server")
+	config.KeyFile = ""
+
+	_, err := NewReloader(log, config)
+	require.ErrorIs(t, err, ErrNoCertificateProvided)
+}
+
+func testFailsIfNoReloadInterval(t *testing.T, log logger.Log, ca *authority, dir string) {
+	config := ca.writeServer(t, dir, "server")
+	config.ReloadInterval = 0
+
+	_, err := NewReloader(log, config)
+	require.ErrorIs(t, err, ErrInvalidReloadInterval)
+}
+
+func testFailsIfUnknownVersion(t *testing.T, log logger.Log, ca *authority, dir string) {
+	config := ca.writeServer(t, dir, "server")
+	config.MinVersion = "1.0"
+
+	_, err := NewReloader(log, config)
+	require.ErrorIs(t, err, ErrUnknownVersion)
+}
+
+func testFailsIfUnknownCipherSuite(t *testing.T, log logger.Log, ca *authority, dir string) {
+	config := ca.writeServer(t, dir, "server")
+	config.CipherSuites = []string{"TLS_UNKNOWN"}
+
+	_, err := NewReloader(log, config)
+	require.ErrorIs(t, err, ErrUnknownCipherSuite)
+}
+
+func testFailsIfInsecureCipherSuite(t *testing.T, log logger.Log, ca *authority, dir string) {
+	config := ca.writeServer(t, dir, "server")
+	config.CipherSuites = []string{"TLS_RSA_WITH_RC4_128_SHA"}
+
+	_, err := NewReloader(log, config)
+	require.ErrorIs(t, err, ErrUnknownCipherSuite)
+}
+
+func testFailsIfInvalidClientCA(t *testing.T, log logger.Log, ca *authority, dir string) {
+	config := ca.writeServer(t, dir, "server")
+	config.ClientCAFile = config.KeyFile
+
+	_, err := NewReloader(log, config)
+	require.ErrorIs(t, err, ErrInvalidClientCA)
+}
+
+func testFailsIfCertificateIsMissing(t *testing.T, log logger.Log, ca *authority, dir string) {
+	config := ca.writeServer(t, dir, "server")
+	config.CertFile = filepath.Join(dir, "missing.crt")
+
+	_, err := NewReloader(log, config)
+	require.ErrorIs(t, err, os.ErrNotExist)
+}
+
+func testIsEnabledIfCertificateIsSet(t *testing.T, log logger.Log, ca *authority, dir string) {
+	require.False(t, Config{}.Enabled())
+	require.True(t, Config{CertFile: "tls.crt"}.Enabled())
+	require.True(t, Config{KeyFile: "tls.key"}.Enabled())
+}
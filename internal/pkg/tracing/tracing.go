@@ -0,0 +1,167 @@
+package tracing
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	"go.opentelemetry.io/otel/trace"
+
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.20.0"
+)
+
+const (
+
+	// ExporterNone creates the spans, so the requests have trace ids
+	// in the logs and metric exemplars, but doesn't export them.
+	ExporterNone = "none"
+
+	// ExporterOtlp exports the spans to the OpenTelemetry collector via OTLP/HTTP.
+	ExporterOtlp = "otlp"
+
+	// ExporterStdout writes the spans to the standard output as JSON.
+	ExporterStdout = "stdout"
+
+	// ExporterFile writes the spans to the file as JSON.
+	ExporterFile = "file"
+)
+
+var (
+	// ErrUnknownExporter happens when the configured span exporter is not supported.
+	ErrUnknownExporter = errors.New("unknown trace exporter")
+
+	// ErrNoFileProvided happens when the file exporter is configured without the file path.
+	ErrNoFileProvided = errors.New("no trace file provided")
+
+	// ErrInvalidSampleRatio happens when the sample ratio is not within [0, 1].
+	ErrInvalidSampleRatio = errors.New("invalid trace sample ratio")
+)
+
+// Propagator extracts and injects the W3C Trace Context,
+// the 'traceparent' and 'tracestate' headers.
+var Propagator propagation.TextMapPropagator = propagation.TraceContext{}
+
+// Config is a tracing configuration.
+type Config struct {
+
+	// Supported span exporters:
+	// - none
+	// - otlp
+	// - stdout
+	// - file
+	Exporter string
+
+	// Endpoint is the OTLP/HTTP endpoint of the OpenTelemetry collector.
+	// For example: "localhost:4318"
+	Endpoint string
+
+	// Insecure disables TLS of the connection to the OpenTelemetry collector.
+	Insecure bool
+
+	// File is the path of the file the spans are appended to by the file exporter.
+	// For example: "/var/log/gogin/traces.json"
+	File string
+
+	// SampleRatio is the ratio of the new traces that are sampled.
+	// The traces started by the callers follow the caller's decision.
+	SampleRatio float64
+}
+
+// Provider creates the tracers and exports their spans.
+type Provider struct {
+	provider *sdktrace.TracerProvider
+	file     io.Closer
+}
+
+// NewProvider creates a new tracer provider that exports
+// the spans of the 'service' running on the 'node'.
+func NewProvider(config Config, service string, node string) (*Provider, error) {
+	if config.SampleRatio < 0 || config.SampleRatio > 1 {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidSampleRatio, config.SampleRatio)
+	}
+
+	p := &Provider{}
+	options := []sdktrace.TracerProviderOption{
+		sdktrace.WithSampler(sdktrace.ParentBased(sdktrace.TraceIDRatioBased(config.SampleRatio))),
+		sdktrace.WithResource(resource.NewWithAttributes(
+			semconv.SchemaURL,
+			semconv.ServiceName(service),
+			semconv.ServiceInstanceID(node),
+		)),
+	}
+
+	exporter, err := p.createExporter(config)
+	if err != nil {
+		return nil, err
+	}
+	if exporter != nil {
+		options = append(options, sdktrace.WithBatcher(exporter))
+	}
+
+	p.provider = sdktrace.NewTracerProvider(options...)
+	return p, nil
+}
+
+// createExporter creates the configured span exporter,
+// or nil if the spans should not be exported.
+func (p *Provider) createExporter(config Config) (sdktrace.SpanExporter, error) {
+	switch config.Exporter {
+	case ExporterNone, "":
+		return nil, nil
+
+	case ExporterOtlp:
+		options := []otlptracehttp.Option{
+			otlptracehttp.WithEndpoint(config.Endpoint),
+		}
+		if config.Insecure {
+			options = append(options, otlptracehttp.WithInsecure())
+		}
+
+		// The client connects lazily, so the collector
+		// doesn't have to be available on startup
+		return otlptracehttp.New(context.Background(), options...)
+
+	case ExporterStdout:
+		return stdouttrace.New(stdouttrace.WithWriter(os.Stdout))
+
+	case ExporterFile:
+		if config.File == "" {
+			return nil, ErrNoFileProvided
+		}
+
+		file, err := os.OpenFile(config.File, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o644)
+		if err != nil {
+			return nil, err
+		}
+		p.file = file
+
+		return stdouttrace.New(stdouttrace.WithWriter(file))
+	}
+
+	return nil, fmt.Errorf("%w: %s", ErrUnknownExporter, config.Exporter)
+}
+
+// Tracer returns the tracer of the instrumented component 'name'.
+func (p *Provider) Tracer(name string) trace.Tracer {
+	return p.provider.Tracer(name)
+}
+
+// Shutdown exports the pending spans and stops the exporter.
+func (p *Provider) Shutdown(ctx context.Context) error {
+	err := p.provider.Shutdown(ctx)
+
+	if p.file != nil {
+		if closeErr := p.file.Close(); err == nil {
+			err = closeErr
+		}
+	}
+
+	return err
+}
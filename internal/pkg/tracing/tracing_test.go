@@ -0,0 +1,142 @@
+package tracing
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func TestProvider(t *testing.T) {
+	for scenario, fn := range map[string]func(
+		t *testing.T,
+	){
+		"exports spans to file":          testExportsSpansToFile,
+		"continues caller trace":         testContinuesCallerTrace,
+		"creates spans without exporter": testCreatesSpansWithoutExporter,
+		"rejects invalid configuration":  testRejectsInvalidConfiguration,
+	} {
+		t.Run(scenario, func(t *testing.T) {
+			fn(t)
+		})
+	}
+}
+
+// span is the part of the exported span that is verified.
+type span struct {
+	Name        string
+	SpanContext struct {
+		TraceID string
+		SpanID  string
+	}
+	Parent struct {
+		SpanID string
+	}
+	Resource []struct {
+		Key   string
+		Value struct {
+			Value interface{}
+		}
+	}
+}
+
+// exportedSpans reads the spans that have been written by the file exporter.
+func exportedSpans(t *testing.T, path string) []span {
+	content, err := os.ReadFile(path)
+	require.NoError(t, err)
+
+	var spans []span
+	decoder := json.NewDecoder(strings.NewReader(string(content)))
+	for decoder.More() {
+		var s span
+		require.NoError(t, decoder.Decode(&s))
+		spans = append(spans, s)
+	}
+	return spans
+}
+
+func testExportsSpansToFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "traces.json")
+	provider, err := NewProvider(Config{
+		Exporter:    ExporterFile,
+		File:        path,
+		SampleRatio: 1,
+	}, "gogin", "node-1")
+	require.NoError(t, err)
+
+	ctx, parent := provider.Tracer("test").Start(context.Background(), "parent")
+	_, child := provider.Tracer("test").Start(ctx, "child")
+	child.End()
+	parent.End()
+
+	require.NoError(t, provider.Shutdown(context.Background()))
+
+	spans := exportedSpans(t, path)
+	require.Len(t, spans, 2)
+	require.Equal(t, "child", spans[0].Name)
+	require.Equal(t, "parent", spans[1].Name)
+	require.Equal(t, spans[1].SpanContext.TraceID, spans[0].SpanContext.TraceID)
+	require.Equal(t, spans[1].SpanContext.SpanID, spans[0].Parent.SpanID)
+
+	resource := map[string]interface{}{}
+	for _, attr := range spans[0].Resource {
+		resource[attr.Key] = attr.Value.Value
+	}
+	require.Equal(t, "gogin", resource["service.name"])
+	require.Equal(t, "node-1", resource["service.instance.id"])
+}
+
+func testContinuesCallerTrace(t *testing.T) {
+	provider, err := NewProvider(Config{Exporter: ExporterNone}, "gogin", "node-1")
+	require.NoError(t, err)
+	defer provider.Shutdown(context.Background())
+
+	// The sampling decision of the caller is respected,
+	// even if the new traces are never sampled
+	headers := propagation.MapCarrier{
+		"traceparent": "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01",
+		"tracestate":  "vendor=value",
+	}
+	ctx := Propagator.Extract(context.Background(), headers)
+	ctx, span := provider.Tracer("test").Start(ctx, "request")
+	defer span.End()
+
+	sc := trace.SpanContextFromContext(ctx)
+	require.Equal(t, "4bf92f3577b34da6a3ce929d0e0e4736", sc.TraceID().String())
+	require.True(t, sc.IsSampled())
+
+	injected := propagation.MapCarrier{}
+	Propagator.Inject(ctx, injected)
+	require.Regexp(t, `^00-4bf92f3577b34da6a3ce929d0e0e4736-[0-9a-f]{16}-01$`, injected["traceparent"])
+	require.NotContains(t, injected["traceparent"], "00f067aa0ba902b7")
+	require.Equal(t, "vendor=value", injected["tracestate"])
+}
+
+func testCreatesSpansWithoutExporter(t *testing.T) {
+	provider, err := NewProvider(Config{Exporter: ExporterNone, SampleRatio: 1}, "gogin", "node-1")
+	require.NoError(t, err)
+	defer provider.Shutdown(context.Background())
+
+	_, span := provider.Tracer("test").Start(context.Background(), "request")
+	defer span.End()
+
+	require.True(t, span.SpanContext().IsValid())
+	require.True(t, span.SpanContext().IsSampled())
+}
+
+func testRejectsInvalidConfiguration(t *testing.T) {
+	_, err := NewProvider(Config{Exporter: "zipkin"}, "gogin", "node-1")
+	require.ErrorIs(t, err, ErrUnknownExporter)
+
+	_, err = NewProvider(Config{Exporter: ExporterFile}, "gogin", "node-1")
+	require.ErrorIs(t, err, ErrNoFileProvided)
+
+	_, err = NewProvider(Config{Exporter: ExporterNone, SampleRatio: 1.5}, "gogin", "node-1")
+	require.ErrorIs(t, err, ErrInvalidSampleRatio)
+}